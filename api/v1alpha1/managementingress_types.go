@@ -16,8 +16,11 @@
 package v1alpha1
 
 import (
+	autoscalingv2beta2 "k8s.io/api/autoscaling/v2beta2"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // ManagementIngressSpec defines the desired state of ManagementIngress
@@ -37,27 +40,281 @@ type ManagementIngressSpec struct {
 	IgnoreRouteCert          bool                         `json:"ignoreRouteCert,omitempty"`
 	ProxyRouteHost           string                       `json:"proxyRouteHost,omitempty"`
 	MultipleInstancesEnabled bool                         `json:"multipleInstancesEnabled,omitempty"`
-	Version                  string                       `json:"version,omitempty"`
+	// Version of the operand, set as the app.kubernetes.io/version label of the management ingress pods.
+	Version string `json:"version,omitempty"`
+	// ImagePullSecrets used to pull the operand image, e.g. for a mirror registry that requires authentication.
+	ImagePullSecrets []corev1.LocalObjectReference `json:"imagePullSecrets,omitempty"`
+	// Exposure selects how the console and proxy are exposed outside of the cluster. Defaults to
+	// Route on OpenShift and None on other clusters.
+	Exposure *Exposure `json:"exposure,omitempty"`
+	// IngressConfig holds the supported settings of management ingress. The settings which are set are
+	// rendered into the management-ingress-config configmap and take precedence over the same keys in
+	// Config, which is copied as is. Unset settings are not rendered. Keys of Config which are not
+	// supported are reported with the UnknownConfigKeys condition.
+	IngressConfig *IngressConfig `json:"ingressConfig,omitempty"`
+	// Autoscaling scales the management ingress pods with a HorizontalPodAutoscaler. Replicas is
+	// ignored while it is enabled, the autoscaler owns the replicas of the deployment.
+	Autoscaling *Autoscaling `json:"autoscaling,omitempty"`
+	// Availability controls how many management ingress pods may be down during rollouts and node
+	// drains. The defaults roll out without downtime when it is not set.
+	Availability *Availability `json:"availability,omitempty"`
+	// NetworkPolicy restricts which pods can reach management ingress. No NetworkPolicy is created
+	// when it is not set.
+	NetworkPolicy *NetworkPolicy `json:"networkPolicy,omitempty"`
+	// Scheduling overrides or extends where the management ingress pods run. NodeSelector and
+	// Tolerations are applied as well.
+	Scheduling *Scheduling `json:"scheduling,omitempty"`
 }
 
+// PodAntiAffinityMode is how strictly the management ingress pods are kept on different nodes.
+type PodAntiAffinityMode string
+
+const (
+	// PodAntiAffinityPreferred schedules the pods on different nodes when possible.
+	PodAntiAffinityPreferred PodAntiAffinityMode = "Preferred"
+	// PodAntiAffinityRequired never schedules two pods on the same node.
+	PodAntiAffinityRequired PodAntiAffinityMode = "Required"
+)
+
+// Scheduling configures the affinity, the topology spread and the priority of the management ingress
+// pods. It is merged with the defaults of the operator.
+type Scheduling struct {
+	// Architectures are the values of the kubernetes.io/arch node label the pods can run on.
+	// Defaults to amd64, ppc64le and s390x.
+	Architectures []string `json:"architectures,omitempty"`
+	// Affinity is merged with the default affinity. The required node selector terms are each ANDed
+	// with the architectures, the other terms are added to the defaults.
+	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+	// PodAntiAffinity keeps the pods on different nodes, Preferred or Required. Defaults to Preferred.
+	// +kubebuilder:validation:Enum=Preferred;Required
+	PodAntiAffinity PodAntiAffinityMode `json:"podAntiAffinity,omitempty"`
+	// TopologySpreadConstraints replace the default constraints with the same topologyKey, by default
+	// the pods are spread across topology.kubernetes.io/zone and topology.kubernetes.io/region when
+	// possible. Constraints without labelSelector select the management ingress pods.
+	TopologySpreadConstraints []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+	// PriorityClassName of the pods.
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+}
+
+// NetworkPolicy configures the NetworkPolicy of the management ingress pods. It allows the routers or
+// ingress controllers, the IAM services in the namespace of the CR and the extra peers to reach the
+// https and http ports, and denies all other ingress traffic.
+type NetworkPolicy struct {
+	// Enabled creates the NetworkPolicy, it is removed when disabled.
+	Enabled bool `json:"enabled,omitempty"`
+	// IngressNamespaceSelector selects the namespaces of the routers or ingress controllers. Defaults to
+	// the network.openshift.io/policy-group=ingress namespaces on OpenShift, and to the ingress-nginx
+	// namespace on other clusters.
+	IngressNamespaceSelector *metav1.LabelSelector `json:"ingressNamespaceSelector,omitempty"`
+	// ExtraPeers are also allowed, e.g. the clients of a LoadBalancer or Gateway exposure, or other
+	// workloads which call management ingress.
+	ExtraPeers []networkingv1.NetworkPolicyPeer `json:"extraPeers,omitempty"`
+}
+
+// Availability configures the rollout strategy, the termination and the PodDisruptionBudget of the
+// management ingress pods.
+type Availability struct {
+	// MaxSurge is the number or percentage of pods created above the replicas during a rollout. Defaults to 1.
+	MaxSurge *intstr.IntOrString `json:"maxSurge,omitempty"`
+	// MaxUnavailable is the number or percentage of pods which may be unavailable during a rollout.
+	// Defaults to 0, a pod is only replaced once its successor is available.
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+	// MinReadySeconds is how long a new pod must be ready before it counts as available. Defaults to 10.
+	// +kubebuilder:validation:Minimum=0
+	MinReadySeconds *int32 `json:"minReadySeconds,omitempty"`
+	// PreStopDelay is how long a terminating pod keeps serving, so it is removed from the endpoints
+	// and load balancers before nginx stops accepting connections. Defaults to 10s.
+	PreStopDelay *metav1.Duration `json:"preStopDelay,omitempty"`
+	// PodDisruptionBudget limits the pods evicted at once by node drains. Defaults to maxUnavailable 1
+	// when there is more than one replica, no budget is created for a single replica.
+	PodDisruptionBudget *PodDisruptionBudget `json:"podDisruptionBudget,omitempty"`
+}
+
+// PodDisruptionBudget sets either MinAvailable or MaxUnavailable of the management ingress pods.
+type PodDisruptionBudget struct {
+	// MinAvailable is the number or percentage of pods which must stay available.
+	MinAvailable *intstr.IntOrString `json:"minAvailable,omitempty"`
+	// MaxUnavailable is the number or percentage of pods which may be evicted at once.
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+}
+
+// Autoscaling configures the HorizontalPodAutoscaler of the management ingress deployment.
+type Autoscaling struct {
+	// Enabled creates the HorizontalPodAutoscaler, it is removed when disabled.
+	Enabled bool `json:"enabled,omitempty"`
+	// MinReplicas is the lower limit of the replicas. Defaults to 1.
+	// +kubebuilder:validation:Minimum=1
+	MinReplicas *int32 `json:"minReplicas,omitempty"`
+	// MaxReplicas is the upper limit of the replicas, at least MinReplicas. Required when enabled.
+	// +kubebuilder:validation:Minimum=1
+	MaxReplicas int32 `json:"maxReplicas,omitempty"`
+	// TargetCPUUtilizationPercentage is the average CPU utilization of the pods, relative to their
+	// requests, the autoscaler aims for. Defaults to 80 when no target is set.
+	// +kubebuilder:validation:Minimum=1
+	TargetCPUUtilizationPercentage *int32 `json:"targetCPUUtilizationPercentage,omitempty"`
+	// TargetMemoryUtilizationPercentage is the average memory utilization of the pods, relative to
+	// their requests, the autoscaler aims for.
+	// +kubebuilder:validation:Minimum=1
+	TargetMemoryUtilizationPercentage *int32 `json:"targetMemoryUtilizationPercentage,omitempty"`
+	// Behavior of scaling up and down, the defaults of the HorizontalPodAutoscaler when not set.
+	Behavior *autoscalingv2beta2.HorizontalPodAutoscalerBehavior `json:"behavior,omitempty"`
+}
+
+// IngressConfig holds the nginx settings of management ingress. Unset settings are left to
+// spec.config or to the defaults of management ingress, which are given for each setting.
+type IngressConfig struct {
+	// ProxyConnectTimeout is the timeout for connecting to a backend, at most 75s. Defaults to 5s.
+	ProxyConnectTimeout *metav1.Duration `json:"proxyConnectTimeout,omitempty"`
+	// ProxyReadTimeout is the timeout between two reads from a backend. Defaults to 60s.
+	ProxyReadTimeout *metav1.Duration `json:"proxyReadTimeout,omitempty"`
+	// ProxySendTimeout is the timeout between two writes to a backend. Defaults to 60s.
+	ProxySendTimeout *metav1.Duration `json:"proxySendTimeout,omitempty"`
+	// ProxyBodySize is the maximum size of a request body, e.g. 10m, 0 disables the limit. Defaults to 1m.
+	// +kubebuilder:validation:Pattern=`^[0-9]+[kKmMgG]?$`
+	ProxyBodySize string `json:"proxyBodySize,omitempty"`
+	// AccessLog enables the access log. Defaults to true.
+	AccessLog *bool `json:"accessLog,omitempty"`
+	// Gzip compresses responses. Defaults to false.
+	Gzip *bool `json:"gzip,omitempty"`
+	// GzipLevel from 1 to 9. Defaults to 1.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=9
+	GzipLevel int32 `json:"gzipLevel,omitempty"`
+	// WorkerProcesses is the number of nginx worker processes, auto for one per CPU. Defaults to auto.
+	WorkerProcesses string `json:"workerProcesses,omitempty"`
+	// MaxWorkerConnections is the number of connections of each worker. Defaults to 16384.
+	// +kubebuilder:validation:Minimum=0
+	MaxWorkerConnections int32 `json:"maxWorkerConnections,omitempty"`
+	// KeepAliveTimeout of client connections, 0 disables keep-alive. Defaults to 75s.
+	KeepAliveTimeout *metav1.Duration `json:"keepAliveTimeout,omitempty"`
+	// LargeClientHeaderBuffers is the number and size of the buffers for large request headers, e.g. 4 8k.
+	// Defaults to 4 8k.
+	LargeClientHeaderBuffers string `json:"largeClientHeaderBuffers,omitempty"`
+	// HSTS sends the Strict-Transport-Security header. Defaults to true.
+	HSTS *bool `json:"hsts,omitempty"`
+	// HSTSMaxAge is the max-age of the Strict-Transport-Security header. Defaults to 15724800s.
+	HSTSMaxAge *metav1.Duration `json:"hstsMaxAge,omitempty"`
+	// ServerTokens sends the nginx version in the Server header and error pages. Defaults to false.
+	ServerTokens *bool `json:"serverTokens,omitempty"`
+}
+
+// ExposureType is the kind of object used to expose management ingress.
+type ExposureType string
+
+const (
+	// ExposureRoute uses OpenShift routes, only available on OpenShift.
+	ExposureRoute ExposureType = "Route"
+	// ExposureIngress uses networking.k8s.io/v1 ingresses.
+	ExposureIngress ExposureType = "Ingress"
+	// ExposureGateway uses Gateway API routes attached to an existing gateway.
+	ExposureGateway ExposureType = "Gateway"
+	// ExposureLoadBalancer uses a service of type LoadBalancer, the TLS connection is passed through.
+	ExposureLoadBalancer ExposureType = "LoadBalancer"
+	// ExposureNone creates no exposure, it is managed outside of the operator.
+	ExposureNone ExposureType = "None"
+)
+
+// TLSTermination is where the TLS connection of the client ends, the same as the OpenShift route termination.
+type TLSTermination string
+
+const (
+	// TLSTerminationPassthrough sends the TLS connection to management ingress unchanged.
+	TLSTerminationPassthrough TLSTermination = "Passthrough"
+	// TLSTerminationReencrypt terminates TLS with the route certificate and opens a new TLS connection to management ingress.
+	TLSTerminationReencrypt TLSTermination = "Reencrypt"
+)
+
+type Exposure struct {
+	// +kubebuilder:validation:Enum=Route;Ingress;Gateway;LoadBalancer;None
+	Type ExposureType `json:"type,omitempty"`
+	// TLSTermination defaults to Reencrypt for Route, Ingress and Gateway. LoadBalancer only supports Passthrough.
+	// +kubebuilder:validation:Enum=Passthrough;Reencrypt
+	TLSTermination TLSTermination `json:"tlsTermination,omitempty"`
+	// IngressClassName of the ingresses, the default ingress class of the cluster is used when empty.
+	IngressClassName string `json:"ingressClassName,omitempty"`
+	// Annotations added to the created ingresses, routes or load balancer service.
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// Gateway the Gateway API routes are attached to, required for the Gateway type.
+	Gateway *GatewayReference `json:"gateway,omitempty"`
+}
+
+type GatewayReference struct {
+	Name string `json:"name"`
+	// Namespace of the gateway, defaults to the namespace of the CR.
+	Namespace string `json:"namespace,omitempty"`
+	// SectionName is the listener of the gateway to attach to.
+	SectionName string `json:"sectionName,omitempty"`
+}
+
+// OperandImage overrides parts of the operand image reference. The image from the
+// ICP_MANAGEMENT_INGRESS_IMAGE environment variable of the operator is used for
+// any part which is not set, and ImageRegistry replaces its registry.
 type OperandImage struct {
+	// Repository is the image name, relative to ImageRegistry unless it includes a registry host.
 	Repository string `json:"repository,omitempty"`
 	Tag        string `json:"tag,omitempty"`
+	// Digest pins the image, e.g. sha256:<hex>. It takes precedence over the tag.
+	Digest string `json:"digest,omitempty"`
 }
 
 type Cert struct {
+	// Issuer of the certificates, any Issuer or ClusterIssuer of cert-manager or an external issuer
+	// when group is set. It takes precedence over NamespacedIssuer.
 	// +kubebuilder:validation:Optional
 	Issuer CertIssuer `json:"issuer"`
+	// NamespacedIssuer is used when Issuer is not set, the cs-ca-issuer Issuer when neither is set.
 	// +kubebuilder:validation:Optional
 	NamespacedIssuer CertIssuer `json:"namespacedIssuer"`
-	CommonName       string     `json:"repository,omitempty"`
-	DNSNames         []string   `json:"dnsNames,omitempty"`
-	IPAddresses      []string   `json:"ipAddresses,omitempty"`
+	// CommonName of the certificates, defaults to management-ingress.
+	CommonName  string   `json:"commonName,omitempty"`
+	DNSNames    []string `json:"dnsNames,omitempty"`
+	IPAddresses []string `json:"ipAddresses,omitempty"`
+	// Duration is the requested lifetime of the certificates, defaults to 8760h.
+	Duration *metav1.Duration `json:"duration,omitempty"`
+	// RenewBefore is how long before expiry the certificates are renewed, defaults to 24h.
+	RenewBefore *metav1.Duration `json:"renewBefore,omitempty"`
+	// PrivateKey of the certificates, cert-manager defaults are used when empty.
+	PrivateKey *CertPrivateKey `json:"privateKey,omitempty"`
+	// TLSSecretRef is a kubernetes.io/tls secret in the namespace of the CR used as the certificate of
+	// the management ingress service instead of requesting one from cert-manager. It must cover
+	// the DNS name of the service and should include the CA in ca.crt.
+	TLSSecretRef *corev1.LocalObjectReference `json:"tlsSecretRef,omitempty"`
+	// RouteSecretRef is a kubernetes.io/tls secret in the namespace of the CR used as the certificate
+	// of the console route instead of requesting one from cert-manager. It must cover the route host.
+	RouteSecretRef *corev1.LocalObjectReference `json:"routeSecretRef,omitempty"`
 }
 
+type CertPrivateKey struct {
+	// +kubebuilder:validation:Enum=RSA;ECDSA
+	Algorithm PrivateKeyAlgorithm `json:"algorithm,omitempty"`
+	// Size in bits, 2048, 3072 or 4096 for RSA and 256, 384 or 521 for ECDSA.
+	Size int `json:"size,omitempty"`
+	// RotationPolicy Always generates a new private key on every renewal. It is only supported
+	// by cert-manager.io/v1, certmanager.k8s.io/v1alpha1 always keeps the private key.
+	// +kubebuilder:validation:Enum=Never;Always
+	RotationPolicy PrivateKeyRotationPolicy `json:"rotationPolicy,omitempty"`
+}
+
+type PrivateKeyAlgorithm string
+
+const (
+	RSAKeyAlgorithm   PrivateKeyAlgorithm = "RSA"
+	ECDSAKeyAlgorithm PrivateKeyAlgorithm = "ECDSA"
+)
+
+type PrivateKeyRotationPolicy string
+
+const (
+	RotationPolicyNever  PrivateKeyRotationPolicy = "Never"
+	RotationPolicyAlways PrivateKeyRotationPolicy = "Always"
+)
+
 type CertIssuer struct {
-	Name string     `json:"name"`
+	Name string `json:"name"`
+	// Kind is Issuer or ClusterIssuer for cert-manager, or the kind of an external issuer.
 	Kind IssuerKind `json:"kind"`
+	// Group of an external issuer, e.g. awspca.cert-manager.io. Empty for the issuers of cert-manager.
+	Group string `json:"group,omitempty"`
 }
 
 type IssuerKind string
@@ -67,6 +324,11 @@ const (
 	Issuer        IssuerKind = "Issuer"
 )
 
+// IsCertManagerGroup reports whether an issuer group refers to the issuers of cert-manager itself.
+func IsCertManagerGroup(group string) bool {
+	return group == "" || group == "cert-manager.io" || group == "certmanager.k8s.io"
+}
+
 type ConditionList []Condition
 
 // ManagementIngressStatus defines the observed state of ManagementIngress
@@ -75,6 +337,32 @@ type ManagementIngressStatus struct {
 	PodState   PodStateMap              `json:"podstate"`
 	Host       string                   `json:"host"`
 	State      OperandState             `json:"operandState"`
+	// Image is the resolved operand image used by the management ingress deployment.
+	Image string `json:"image,omitempty"`
+	// Exposure is the exposure currently in use, its objects are removed when spec.exposure changes.
+	Exposure ExposureType `json:"exposure,omitempty"`
+	// Issuer is the issuer of the certificates requested from cert-manager.
+	Issuer *CertIssuer `json:"issuer,omitempty"`
+	// Objects are the names of the objects generated for the CR in its namespace.
+	Objects *ObjectNames `json:"objects,omitempty"`
+}
+
+// ObjectNames are the names of the objects generated for a ManagementIngress. The objects of the
+// ManagementIngress named default keep the names used before several instances could coexist in
+// a namespace, the names of the objects of other instances are prefixed with the name of the CR.
+type ObjectNames struct {
+	// Deployment is also the name of the HorizontalPodAutoscaler, the PodDisruptionBudget and the NetworkPolicy.
+	Deployment          string `json:"deployment"`
+	Service             string `json:"service"`
+	ConfigMap           string `json:"configMap"`
+	Certificate         string `json:"certificate"`
+	TLSSecret           string `json:"tlsSecret"`
+	RouteCertificate    string `json:"routeCertificate"`
+	RouteSecret         string `json:"routeSecret"`
+	ConsoleRoute        string `json:"consoleRoute"`
+	ProxyRoute          string `json:"proxyRoute"`
+	LoadBalancerService string `json:"loadBalancerService"`
+	BackendCAConfigMap  string `json:"backendCAConfigMap"`
 }
 
 type OperandState struct {
@@ -126,6 +414,46 @@ const (
 	WaitingResource          ConditionType = "WaitingResource"
 	ResourceFailedOnCreation ConditionType = "ResourceFailedOnCreation"
 	DiscoveringClusterInfo   ConditionType = "DiscoveringClusterInfo"
+	ResourceReady            ConditionType = "ResourceReady"
+	// CertificateExpiring is True when a certificate used by management ingress expires soon or does not verify.
+	CertificateExpiring ConditionType = "CertificateExpiring"
+	// UnknownConfigKeys is True when spec.config has keys which are not supported by management ingress.
+	UnknownConfigKeys ConditionType = "UnknownConfigKeys"
+	// APIServerUnknown is True when the API server of the cluster cannot be discovered for the cluster info.
+	APIServerUnknown ConditionType = "APIServerUnknown"
+	// ListenerCertificateRequired is True when a Gateway terminates TLS for the console, the message names the
+	// secret the listener of the gateway must reference in its certificateRefs.
+	ListenerCertificateRequired ConditionType = "ListenerCertificateRequired"
+	// GatewayKindNotServed is True when a Gateway API kind of the Gateway exposure is not served by the cluster.
+	GatewayKindNotServed ConditionType = "GatewayKindNotServed"
+	// IssuerUnverified is True when the operator is not allowed to get the issuer to verify it is ready.
+	IssuerUnverified ConditionType = "IssuerUnverified"
+)
+
+// Keys of ManagementIngressStatus.Conditions, one for each reconcile phase.
+const (
+	ServiceAccountPhase            = "ServiceAccount"
+	SecurityContextConstraintPhase = "SecurityContextConstraint"
+	CertificatePhase               = "Certificate"
+	ServicePhase                   = "Service"
+	NetworkPolicyPhase             = "NetworkPolicy"
+	ConfigMapPhase                 = "ConfigMap"
+	RoutePhase                     = "Route"
+	ExposurePhase                  = "Exposure"
+	AutoscalingPhase               = "Autoscaling"
+	DeploymentPhase                = "Deployment"
+	// CertificateExpiryPhase is not a reconcile phase, it holds the result of the certificate expiry check.
+	CertificateExpiryPhase = "CertificateExpiry"
+	// IngressConfigPhase is not a reconcile phase, it holds the result of the spec.config check.
+	IngressConfigPhase = "IngressConfig"
+	// APIServerPhase is not a reconcile phase, it holds the result of the API server discovery.
+	APIServerPhase = "APIServer"
+	// GatewayListenerPhase is not a reconcile phase, it holds the certificate required by the gateway listener.
+	GatewayListenerPhase = "GatewayListener"
+	// GatewayAPIPhase is not a reconcile phase, it holds the Gateway API kinds missing for the Gateway exposure.
+	GatewayAPIPhase = "GatewayAPI"
+	// IssuerPhase is not a reconcile phase, it holds the result of the issuer readiness check.
+	IssuerPhase = "Issuer"
 )
 
 type PodStateType string
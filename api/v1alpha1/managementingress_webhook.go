@@ -0,0 +1,708 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package v1alpha1
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	autoscalingv2beta2 "k8s.io/api/autoscaling/v2beta2"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+const (
+	// DefaultName is the name of the ManagementIngress whose objects keep their legacy names.
+	DefaultName = "default"
+	// MaxNameLength is the longest name of the other ManagementIngress instances, the name prefixes the
+	// names of their services, e.g. <name>-icp-management-ingress-lb, which are DNS-1035 labels.
+	MaxNameLength = 37
+
+	// DefaultReplicas is the number of management ingress pods when spec.replicas is not set.
+	DefaultReplicas int32 = 1
+	// DefaultTargetCPUUtilizationPercentage is the CPU target of the autoscaler when spec.autoscaling sets no target.
+	DefaultTargetCPUUtilizationPercentage int32 = 80
+	// DefaultMinReadySeconds and DefaultPreStopDelay are the rollout settings when spec.availability does not set them.
+	DefaultMinReadySeconds int32 = 10
+	DefaultPreStopDelay          = 10 * time.Second
+	// DefaultCAIssuerName and DefaultCAIssuerKind identify the issuer used when the CR does not set one.
+	DefaultCAIssuerName string = "cs-ca-issuer"
+	DefaultCAIssuerKind        = Issuer
+	// DefaultCertDuration and DefaultCertRenewBefore are the lifetime and renewal window of the
+	// certificates when spec.cert does not set them.
+	DefaultCertDuration    = 8760 * time.Hour
+	DefaultCertRenewBefore = 24 * time.Hour
+)
+
+var (
+	DefaultMemoryRequest resource.Quantity = resource.MustParse("300Mi")
+	DefaultCPURequest    resource.Quantity = resource.MustParse("50m")
+
+	DefaultMemoryLimit resource.Quantity = resource.MustParse("512Mi")
+	DefaultCPULimit    resource.Quantity = resource.MustParse("200m")
+)
+
+// DefaultResources returns the resources of the management ingress container when spec.resources is not set.
+func DefaultResources() *corev1.ResourceRequirements {
+	return &corev1.ResourceRequirements{
+		Limits: corev1.ResourceList{
+			corev1.ResourceMemory: DefaultMemoryLimit.DeepCopy(),
+			corev1.ResourceCPU:    DefaultCPULimit.DeepCopy(),
+		},
+		Requests: corev1.ResourceList{
+			corev1.ResourceMemory: DefaultMemoryRequest.DeepCopy(),
+			corev1.ResourceCPU:    DefaultCPURequest.DeepCopy(),
+		},
+	}
+}
+
+// SetupWebhookWithManager registers the defaulting and validating webhooks of ManagementIngress.
+func (r *ManagementIngress) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/mutate-operator-ibm-com-v1alpha1-managementingress,mutating=true,failurePolicy=fail,groups=operator.ibm.com,resources=managementingresses,verbs=create;update,versions=v1alpha1,name=mmanagementingress.kb.io
+
+var _ webhook.Defaulter = &ManagementIngress{}
+
+// Default implements webhook.Defaulter so a webhook will be registered for the type
+func (r *ManagementIngress) Default() {
+	// "Managed" is accepted for the CRs created with the capitalization of Unmanaged.
+	if r.Spec.ManagementState == "" || r.Spec.ManagementState == "Managed" {
+		r.Spec.ManagementState = ManagementStateManaged
+	}
+	if r.Spec.Replicas == 0 {
+		r.Spec.Replicas = DefaultReplicas
+	}
+	if r.Spec.Resources == nil {
+		r.Spec.Resources = DefaultResources()
+	}
+	if r.Spec.Cert == nil {
+		r.Spec.Cert = &Cert{}
+	}
+	if r.Spec.Cert.NamespacedIssuer == (CertIssuer{}) && r.Spec.Cert.Issuer == (CertIssuer{}) {
+		r.Spec.Cert.NamespacedIssuer = CertIssuer{
+			Name: DefaultCAIssuerName,
+			Kind: DefaultCAIssuerKind,
+		}
+	}
+	if r.Spec.Autoscaling != nil {
+		r.Spec.Autoscaling.Default()
+	}
+	if r.Spec.Availability != nil {
+		r.Spec.Availability.Default()
+	}
+}
+
+// Default sets the unset rollout settings to a rollout without downtime. The PodDisruptionBudget depends
+// on the replicas, it is not defaulted.
+func (a *Availability) Default() {
+	if a.MaxSurge == nil {
+		maxSurge := intstr.FromInt(1)
+		a.MaxSurge = &maxSurge
+	}
+	if a.MaxUnavailable == nil {
+		maxUnavailable := intstr.FromInt(0)
+		a.MaxUnavailable = &maxUnavailable
+	}
+	if a.MinReadySeconds == nil {
+		minReadySeconds := DefaultMinReadySeconds
+		a.MinReadySeconds = &minReadySeconds
+	}
+	if a.PreStopDelay == nil {
+		a.PreStopDelay = &metav1.Duration{Duration: DefaultPreStopDelay}
+	}
+}
+
+// Default sets the minimum replicas and, when no target is set, the CPU target of the autoscaler.
+func (a *Autoscaling) Default() {
+	if a.MinReplicas == nil {
+		minReplicas := DefaultReplicas
+		a.MinReplicas = &minReplicas
+	}
+	if a.TargetCPUUtilizationPercentage == nil && a.TargetMemoryUtilizationPercentage == nil {
+		target := DefaultTargetCPUUtilizationPercentage
+		a.TargetCPUUtilizationPercentage = &target
+	}
+}
+
+// +kubebuilder:webhook:verbs=create;update,path=/validate-operator-ibm-com-v1alpha1-managementingress,mutating=false,failurePolicy=fail,groups=operator.ibm.com,resources=managementingresses,versions=v1alpha1,name=vmanagementingress.kb.io
+
+var _ webhook.Validator = &ManagementIngress{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *ManagementIngress) ValidateCreate() error {
+	return r.toInvalid(append(r.validateName(), r.validateSpec()...))
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (r *ManagementIngress) ValidateUpdate(old runtime.Object) error {
+	errs := r.validateSpec()
+
+	if oldIngress, ok := old.(*ManagementIngress); ok {
+		specPath := field.NewPath("spec")
+		// The route hosts and the cluster scoped resources are named after this flag.
+		if oldIngress.Spec.MultipleInstancesEnabled != r.Spec.MultipleInstancesEnabled {
+			errs = append(errs, field.Forbidden(specPath.Child("multipleInstancesEnabled"), "field is immutable"))
+		}
+		// The exposure and whether the certificates are provided by the user or issued by cert-manager
+		// are chosen when the CR is created.
+		if oldIngress.exposureType() != r.exposureType() {
+			errs = append(errs, field.Forbidden(specPath.Child("exposure", "type"), "field is immutable"))
+		}
+		oldTLSSecret, oldRouteSecret := oldIngress.userSecretRefs()
+		tlsSecret, routeSecret := r.userSecretRefs()
+		if oldTLSSecret != tlsSecret {
+			errs = append(errs, field.Forbidden(specPath.Child("cert", "tlsSecretRef"), "cannot be set or unset on update"))
+		}
+		if oldRouteSecret != routeSecret {
+			errs = append(errs, field.Forbidden(specPath.Child("cert", "routeSecretRef"), "cannot be set or unset on update"))
+		}
+	}
+
+	return r.toInvalid(errs)
+}
+
+// exposureType returns spec.exposure.type, empty when it is not set.
+func (r *ManagementIngress) exposureType() ExposureType {
+	if r.Spec.Exposure == nil {
+		return ""
+	}
+	return r.Spec.Exposure.Type
+}
+
+// userSecretRefs tells whether the certificates of the service and of the route are provided by the user,
+// rather than issued by cert-manager.
+func (r *ManagementIngress) userSecretRefs() (bool, bool) {
+	if r.Spec.Cert == nil {
+		return false, false
+	}
+	return r.Spec.Cert.TLSSecretRef != nil, r.Spec.Cert.RouteSecretRef != nil
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *ManagementIngress) ValidateDelete() error {
+	return nil
+}
+
+func (r *ManagementIngress) toInvalid(errs field.ErrorList) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(GroupVersion.WithKind("ManagementIngress").GroupKind(), r.Name, errs)
+}
+
+// validateName checks that the names of the objects of the CR, which are prefixed with its name unless it
+// is the default one, are valid.
+func (r *ManagementIngress) validateName() field.ErrorList {
+	var errs field.ErrorList
+	namePath := field.NewPath("metadata", "name")
+
+	if len(r.Name) == 0 || r.Name == DefaultName {
+		return errs
+	}
+	for _, msg := range validation.IsDNS1035Label(r.Name) {
+		errs = append(errs, field.Invalid(namePath, r.Name, msg))
+	}
+	if len(r.Name) > MaxNameLength {
+		errs = append(errs, field.TooLong(namePath, r.Name, MaxNameLength))
+	}
+	return errs
+}
+
+func (r *ManagementIngress) validateSpec() field.ErrorList {
+	var errs field.ErrorList
+	specPath := field.NewPath("spec")
+
+	switch r.Spec.ManagementState {
+	case "", ManagementStateManaged, ManagementStateUnmanaged:
+	default:
+		errs = append(errs, field.NotSupported(specPath.Child("managementState"), r.Spec.ManagementState,
+			[]string{string(ManagementStateManaged), string(ManagementStateUnmanaged)}))
+	}
+
+	if r.Spec.Replicas < 0 {
+		errs = append(errs, field.Invalid(specPath.Child("replicas"), r.Spec.Replicas, validation.InclusiveRangeError(0, 1<<31-1)))
+	}
+
+	errs = append(errs, validateHost(specPath.Child("routeHost"), r.Spec.RouteHost)...)
+	errs = append(errs, validateHost(specPath.Child("proxyRouteHost"), r.Spec.ProxyRouteHost)...)
+
+	if exposure := r.Spec.Exposure; exposure != nil {
+		errs = append(errs, validateExposure(specPath.Child("exposure"), exposure)...)
+	}
+
+	if cert := r.Spec.Cert; cert != nil {
+		certPath := specPath.Child("cert")
+		errs = append(errs, validateIssuer(certPath.Child("issuer"), cert.Issuer)...)
+		errs = append(errs, validateIssuer(certPath.Child("namespacedIssuer"), cert.NamespacedIssuer)...)
+		for i, ip := range cert.IPAddresses {
+			if net.ParseIP(ip) == nil {
+				errs = append(errs, field.Invalid(certPath.Child("ipAddresses").Index(i), ip, "must be a valid IP address"))
+			}
+		}
+		errs = append(errs, validateCertLifetime(certPath, cert)...)
+		if cert.PrivateKey != nil {
+			errs = append(errs, validatePrivateKey(certPath.Child("privateKey"), cert.PrivateKey)...)
+		}
+		if len(cert.CommonName) > 64 {
+			errs = append(errs, field.TooLong(certPath.Child("commonName"), cert.CommonName, 64))
+		}
+		errs = append(errs, validateSecretRef(certPath.Child("tlsSecretRef"), cert.TLSSecretRef)...)
+		errs = append(errs, validateSecretRef(certPath.Child("routeSecretRef"), cert.RouteSecretRef)...)
+	}
+
+	if ingressConfig := r.Spec.IngressConfig; ingressConfig != nil {
+		errs = append(errs, validateIngressConfig(specPath.Child("ingressConfig"), ingressConfig)...)
+	}
+
+	if autoscaling := r.Spec.Autoscaling; autoscaling != nil {
+		errs = append(errs, validateAutoscaling(specPath.Child("autoscaling"), autoscaling)...)
+	}
+
+	if availability := r.Spec.Availability; availability != nil {
+		errs = append(errs, validateAvailability(specPath.Child("availability"), availability)...)
+	}
+
+	if networkPolicy := r.Spec.NetworkPolicy; networkPolicy != nil {
+		errs = append(errs, validateNetworkPolicy(specPath.Child("networkPolicy"), networkPolicy)...)
+	}
+
+	if scheduling := r.Spec.Scheduling; scheduling != nil {
+		errs = append(errs, validateScheduling(specPath.Child("scheduling"), scheduling)...)
+	}
+
+	return errs
+}
+
+var (
+	sizePattern            = regexp.MustCompile(`^[0-9]+[kKmMgG]?$`)
+	headerBuffersPattern   = regexp.MustCompile(`^[1-9][0-9]* [0-9]+[kKmM]?$`)
+	workerProcessesPattern = regexp.MustCompile(`^(auto|[1-9][0-9]*)$`)
+)
+
+// validateIngressConfig checks the settings of the ingress config within the limits of nginx.
+func validateIngressConfig(path *field.Path, c *IngressConfig) field.ErrorList {
+	var errs field.ErrorList
+
+	// nginx takes the durations in seconds.
+	for _, d := range []struct {
+		name     string
+		duration *metav1.Duration
+	}{
+		{"proxyConnectTimeout", c.ProxyConnectTimeout},
+		{"proxyReadTimeout", c.ProxyReadTimeout},
+		{"proxySendTimeout", c.ProxySendTimeout},
+		{"keepAliveTimeout", c.KeepAliveTimeout},
+		{"hstsMaxAge", c.HSTSMaxAge},
+	} {
+		if d.duration != nil && (d.duration.Duration < 0 || d.duration.Duration%time.Second != 0) {
+			errs = append(errs, field.Invalid(path.Child(d.name), d.duration.Duration.String(), "must be a whole number of seconds"))
+		}
+	}
+	if c.ProxyConnectTimeout != nil && (c.ProxyConnectTimeout.Duration < time.Second || c.ProxyConnectTimeout.Duration > 75*time.Second) {
+		errs = append(errs, field.Invalid(path.Child("proxyConnectTimeout"), c.ProxyConnectTimeout.Duration.String(), "must be between 1s and 75s"))
+	}
+
+	if len(c.ProxyBodySize) > 0 && !sizePattern.MatchString(c.ProxyBodySize) {
+		errs = append(errs, field.Invalid(path.Child("proxyBodySize"), c.ProxyBodySize, "must be a size, e.g. 1m"))
+	}
+	if c.GzipLevel < 0 || c.GzipLevel > 9 {
+		errs = append(errs, field.Invalid(path.Child("gzipLevel"), c.GzipLevel, validation.InclusiveRangeError(1, 9)))
+	}
+	if len(c.WorkerProcesses) > 0 && !workerProcessesPattern.MatchString(c.WorkerProcesses) {
+		errs = append(errs, field.Invalid(path.Child("workerProcesses"), c.WorkerProcesses, "must be auto or a positive number"))
+	}
+	if c.MaxWorkerConnections < 0 {
+		errs = append(errs, field.Invalid(path.Child("maxWorkerConnections"), c.MaxWorkerConnections, validation.InclusiveRangeError(0, 1<<31-1)))
+	}
+	if len(c.LargeClientHeaderBuffers) > 0 && !headerBuffersPattern.MatchString(c.LargeClientHeaderBuffers) {
+		errs = append(errs, field.Invalid(path.Child("largeClientHeaderBuffers"), c.LargeClientHeaderBuffers, "must be a number and a size, e.g. 4 8k"))
+	}
+
+	return errs
+}
+
+// validateAutoscaling checks the replica limits and targets of the autoscaler, and the scaling
+// policies within the limits of the HorizontalPodAutoscaler API.
+func validateAutoscaling(path *field.Path, a *Autoscaling) field.ErrorList {
+	var errs field.ErrorList
+
+	minReplicas := DefaultReplicas
+	if a.MinReplicas != nil {
+		minReplicas = *a.MinReplicas
+		if minReplicas < 1 {
+			errs = append(errs, field.Invalid(path.Child("minReplicas"), minReplicas, "must be at least 1"))
+		}
+	}
+	if a.Enabled && a.MaxReplicas < minReplicas {
+		errs = append(errs, field.Invalid(path.Child("maxReplicas"), a.MaxReplicas, fmt.Sprintf("must be at least minReplicas %d", minReplicas)))
+	}
+	if a.TargetCPUUtilizationPercentage != nil && *a.TargetCPUUtilizationPercentage < 1 {
+		errs = append(errs, field.Invalid(path.Child("targetCPUUtilizationPercentage"), *a.TargetCPUUtilizationPercentage, "must be at least 1"))
+	}
+	if a.TargetMemoryUtilizationPercentage != nil && *a.TargetMemoryUtilizationPercentage < 1 {
+		errs = append(errs, field.Invalid(path.Child("targetMemoryUtilizationPercentage"), *a.TargetMemoryUtilizationPercentage, "must be at least 1"))
+	}
+
+	if a.Behavior != nil {
+		errs = append(errs, validateScalingRules(path.Child("behavior", "scaleUp"), a.Behavior.ScaleUp)...)
+		errs = append(errs, validateScalingRules(path.Child("behavior", "scaleDown"), a.Behavior.ScaleDown)...)
+	}
+	return errs
+}
+
+// validateAvailability checks the rollout settings and the PodDisruptionBudget like the API server
+// validates them on the deployment and the budget.
+func validateAvailability(path *field.Path, a *Availability) field.ErrorList {
+	var errs field.ErrorList
+
+	errs = append(errs, validateIntOrPercent(path.Child("maxSurge"), a.MaxSurge)...)
+	errs = append(errs, validateIntOrPercent(path.Child("maxUnavailable"), a.MaxUnavailable)...)
+	if isZero(a.MaxSurge) && isZero(a.MaxUnavailable) {
+		errs = append(errs, field.Invalid(path.Child("maxUnavailable"), a.MaxUnavailable.String(), "may not be 0 when maxSurge is 0"))
+	}
+	if a.MinReadySeconds != nil && *a.MinReadySeconds < 0 {
+		errs = append(errs, field.Invalid(path.Child("minReadySeconds"), *a.MinReadySeconds, "must be at least 0"))
+	}
+	if d := a.PreStopDelay; d != nil && (d.Duration < 0 || d.Duration%time.Second != 0) {
+		errs = append(errs, field.Invalid(path.Child("preStopDelay"), d.Duration.String(), "must be a whole number of seconds"))
+	}
+
+	if pdb := a.PodDisruptionBudget; pdb != nil {
+		pdbPath := path.Child("podDisruptionBudget")
+		if pdb.MinAvailable != nil && pdb.MaxUnavailable != nil {
+			errs = append(errs, field.Invalid(pdbPath, pdb, "minAvailable and maxUnavailable cannot be both set"))
+		}
+		errs = append(errs, validateIntOrPercent(pdbPath.Child("minAvailable"), pdb.MinAvailable)...)
+		errs = append(errs, validateIntOrPercent(pdbPath.Child("maxUnavailable"), pdb.MaxUnavailable)...)
+	}
+	return errs
+}
+
+// validateNetworkPolicy checks the namespace selector and the peers like the API server validates them
+// on the NetworkPolicy.
+func validateNetworkPolicy(path *field.Path, p *NetworkPolicy) field.ErrorList {
+	var errs field.ErrorList
+
+	if p.IngressNamespaceSelector != nil {
+		if _, err := metav1.LabelSelectorAsSelector(p.IngressNamespaceSelector); err != nil {
+			errs = append(errs, field.Invalid(path.Child("ingressNamespaceSelector"), p.IngressNamespaceSelector, err.Error()))
+		}
+	}
+
+	for i, peer := range p.ExtraPeers {
+		peerPath := path.Child("extraPeers").Index(i)
+		if peer.PodSelector == nil && peer.NamespaceSelector == nil && peer.IPBlock == nil {
+			errs = append(errs, field.Required(peerPath, "must set podSelector, namespaceSelector or ipBlock"))
+			continue
+		}
+		if peer.IPBlock != nil && (peer.PodSelector != nil || peer.NamespaceSelector != nil) {
+			errs = append(errs, field.Invalid(peerPath.Child("ipBlock"), peer.IPBlock.CIDR, "may not be set with podSelector or namespaceSelector"))
+		}
+		for _, selector := range []struct {
+			name     string
+			selector *metav1.LabelSelector
+		}{
+			{"podSelector", peer.PodSelector},
+			{"namespaceSelector", peer.NamespaceSelector},
+		} {
+			if selector.selector == nil {
+				continue
+			}
+			if _, err := metav1.LabelSelectorAsSelector(selector.selector); err != nil {
+				errs = append(errs, field.Invalid(peerPath.Child(selector.name), selector.selector, err.Error()))
+			}
+		}
+		if ipBlock := peer.IPBlock; ipBlock != nil {
+			_, cidr, err := net.ParseCIDR(ipBlock.CIDR)
+			if err != nil {
+				errs = append(errs, field.Invalid(peerPath.Child("ipBlock", "cidr"), ipBlock.CIDR, "must be a valid CIDR"))
+				continue
+			}
+			for j, except := range ipBlock.Except {
+				exceptIP, _, err := net.ParseCIDR(except)
+				if err != nil || !cidr.Contains(exceptIP) {
+					errs = append(errs, field.Invalid(peerPath.Child("ipBlock", "except").Index(j), except, "must be a valid CIDR within cidr"))
+				}
+			}
+		}
+	}
+	return errs
+}
+
+// validateScheduling checks the architectures, the anti-affinity mode, the topology spread constraints
+// and the priority class name. The affinity is validated by the API server on the deployment.
+func validateScheduling(path *field.Path, s *Scheduling) field.ErrorList {
+	var errs field.ErrorList
+
+	for i, arch := range s.Architectures {
+		for _, msg := range validation.IsValidLabelValue(arch) {
+			errs = append(errs, field.Invalid(path.Child("architectures").Index(i), arch, msg))
+		}
+		if len(arch) == 0 {
+			errs = append(errs, field.Required(path.Child("architectures").Index(i), "must not be empty"))
+		}
+	}
+
+	switch s.PodAntiAffinity {
+	case "", PodAntiAffinityPreferred, PodAntiAffinityRequired:
+	default:
+		errs = append(errs, field.NotSupported(path.Child("podAntiAffinity"), s.PodAntiAffinity,
+			[]string{string(PodAntiAffinityPreferred), string(PodAntiAffinityRequired)}))
+	}
+
+	topologyKeys := map[string]bool{}
+	for i, constraint := range s.TopologySpreadConstraints {
+		constraintPath := path.Child("topologySpreadConstraints").Index(i)
+		if constraint.MaxSkew < 1 {
+			errs = append(errs, field.Invalid(constraintPath.Child("maxSkew"), constraint.MaxSkew, "must be at least 1"))
+		}
+		if len(constraint.TopologyKey) == 0 {
+			errs = append(errs, field.Required(constraintPath.Child("topologyKey"), "must not be empty"))
+		} else if topologyKeys[constraint.TopologyKey] {
+			errs = append(errs, field.Duplicate(constraintPath.Child("topologyKey"), constraint.TopologyKey))
+		}
+		topologyKeys[constraint.TopologyKey] = true
+		switch constraint.WhenUnsatisfiable {
+		case corev1.DoNotSchedule, corev1.ScheduleAnyway:
+		default:
+			errs = append(errs, field.NotSupported(constraintPath.Child("whenUnsatisfiable"), constraint.WhenUnsatisfiable,
+				[]string{string(corev1.DoNotSchedule), string(corev1.ScheduleAnyway)}))
+		}
+	}
+
+	if len(s.PriorityClassName) > 0 {
+		for _, msg := range validation.IsDNS1123Subdomain(s.PriorityClassName) {
+			errs = append(errs, field.Invalid(path.Child("priorityClassName"), s.PriorityClassName, msg))
+		}
+	}
+	return errs
+}
+
+// validateIntOrPercent checks an optional number, which must not be negative, or percentage up to 100%.
+func validateIntOrPercent(path *field.Path, value *intstr.IntOrString) field.ErrorList {
+	var errs field.ErrorList
+	if value == nil {
+		return errs
+	}
+	if value.Type == intstr.String {
+		percent, err := strconv.Atoi(strings.TrimSuffix(value.StrVal, "%"))
+		if err != nil || !strings.HasSuffix(value.StrVal, "%") {
+			return append(errs, field.Invalid(path, value.StrVal, "must be a number or a percentage, e.g. 25%"))
+		}
+		if percent < 0 || percent > 100 {
+			errs = append(errs, field.Invalid(path, value.StrVal, "must be between 0% and 100%"))
+		}
+		return errs
+	}
+	if value.IntVal < 0 {
+		errs = append(errs, field.Invalid(path, value.IntVal, "must be at least 0"))
+	}
+	return errs
+}
+
+func isZero(value *intstr.IntOrString) bool {
+	return value != nil && (value.Type == intstr.Int && value.IntVal == 0 || value.Type == intstr.String && value.StrVal == "0%")
+}
+
+func validateScalingRules(path *field.Path, rules *autoscalingv2beta2.HPAScalingRules) field.ErrorList {
+	var errs field.ErrorList
+	if rules == nil {
+		return errs
+	}
+	if w := rules.StabilizationWindowSeconds; w != nil && (*w < 0 || *w > 3600) {
+		errs = append(errs, field.Invalid(path.Child("stabilizationWindowSeconds"), *w, validation.InclusiveRangeError(0, 3600)))
+	}
+	if rules.SelectPolicy != nil {
+		switch *rules.SelectPolicy {
+		case autoscalingv2beta2.MaxPolicySelect, autoscalingv2beta2.MinPolicySelect, autoscalingv2beta2.DisabledPolicySelect:
+		default:
+			errs = append(errs, field.NotSupported(path.Child("selectPolicy"), *rules.SelectPolicy,
+				[]string{string(autoscalingv2beta2.MaxPolicySelect), string(autoscalingv2beta2.MinPolicySelect), string(autoscalingv2beta2.DisabledPolicySelect)}))
+		}
+	}
+	for i, policy := range rules.Policies {
+		policyPath := path.Child("policies").Index(i)
+		if policy.Type != autoscalingv2beta2.PodsScalingPolicy && policy.Type != autoscalingv2beta2.PercentScalingPolicy {
+			errs = append(errs, field.NotSupported(policyPath.Child("type"), policy.Type,
+				[]string{string(autoscalingv2beta2.PodsScalingPolicy), string(autoscalingv2beta2.PercentScalingPolicy)}))
+		}
+		if policy.Value < 1 {
+			errs = append(errs, field.Invalid(policyPath.Child("value"), policy.Value, "must be at least 1"))
+		}
+		if policy.PeriodSeconds < 1 || policy.PeriodSeconds > 1800 {
+			errs = append(errs, field.Invalid(policyPath.Child("periodSeconds"), policy.PeriodSeconds, validation.InclusiveRangeError(1, 1800)))
+		}
+	}
+	return errs
+}
+
+// validateHost checks an optional host name, which must be a DNS subdomain.
+func validateHost(path *field.Path, host string) field.ErrorList {
+	var errs field.ErrorList
+	if len(host) == 0 {
+		return errs
+	}
+	for _, msg := range validation.IsDNS1123Subdomain(host) {
+		errs = append(errs, field.Invalid(path, host, msg))
+	}
+	return errs
+}
+
+// validateCertLifetime checks the certificate duration and renewal window within the limits of cert-manager.
+func validateCertLifetime(path *field.Path, cert *Cert) field.ErrorList {
+	var errs field.ErrorList
+	if cert.Duration != nil && cert.Duration.Duration < time.Hour {
+		errs = append(errs, field.Invalid(path.Child("duration"), cert.Duration.Duration.String(), "must be at least 1h"))
+	}
+	if cert.RenewBefore != nil {
+		if cert.RenewBefore.Duration < 5*time.Minute {
+			errs = append(errs, field.Invalid(path.Child("renewBefore"), cert.RenewBefore.Duration.String(), "must be at least 5m"))
+		}
+		duration := DefaultCertDuration
+		if cert.Duration != nil {
+			duration = cert.Duration.Duration
+		}
+		if cert.RenewBefore.Duration >= duration {
+			errs = append(errs, field.Invalid(path.Child("renewBefore"), cert.RenewBefore.Duration.String(), "must be less than the duration "+duration.String()))
+		}
+	}
+	return errs
+}
+
+// validatePrivateKey checks the key algorithm and the sizes it supports.
+func validatePrivateKey(path *field.Path, key *CertPrivateKey) field.ErrorList {
+	var errs field.ErrorList
+
+	var sizes []int
+	switch key.Algorithm {
+	case "", RSAKeyAlgorithm:
+		sizes = []int{2048, 3072, 4096}
+	case ECDSAKeyAlgorithm:
+		sizes = []int{256, 384, 521}
+	default:
+		errs = append(errs, field.NotSupported(path.Child("algorithm"), key.Algorithm, []string{string(RSAKeyAlgorithm), string(ECDSAKeyAlgorithm)}))
+	}
+	if key.Size != 0 && sizes != nil {
+		supported := false
+		for _, size := range sizes {
+			supported = supported || key.Size == size
+		}
+		if !supported {
+			errs = append(errs, field.Invalid(path.Child("size"), key.Size, fmt.Sprintf("must be one of %v", sizes)))
+		}
+	}
+
+	switch key.RotationPolicy {
+	case "", RotationPolicyNever, RotationPolicyAlways:
+	default:
+		errs = append(errs, field.NotSupported(path.Child("rotationPolicy"), key.RotationPolicy, []string{string(RotationPolicyNever), string(RotationPolicyAlways)}))
+	}
+	return errs
+}
+
+// validateSecretRef checks an optional reference to a user provided secret.
+func validateSecretRef(path *field.Path, ref *corev1.LocalObjectReference) field.ErrorList {
+	var errs field.ErrorList
+	if ref == nil {
+		return errs
+	}
+	if len(ref.Name) == 0 {
+		errs = append(errs, field.Required(path.Child("name"), "secret name is required"))
+		return errs
+	}
+	for _, msg := range validation.IsDNS1123Subdomain(ref.Name) {
+		errs = append(errs, field.Invalid(path.Child("name"), ref.Name, msg))
+	}
+	return errs
+}
+
+// validateIssuer checks an optional issuer reference, which needs a name and a kind. The kind of a
+// cert-manager issuer is Issuer or ClusterIssuer, external issuers have their own kinds.
+func validateIssuer(path *field.Path, issuer CertIssuer) field.ErrorList {
+	var errs field.ErrorList
+	if issuer == (CertIssuer{}) {
+		return errs
+	}
+	if len(issuer.Name) == 0 {
+		errs = append(errs, field.Required(path.Child("name"), "issuer name is required when kind is set"))
+	}
+	if !IsCertManagerGroup(issuer.Group) {
+		for _, msg := range validation.IsDNS1123Subdomain(issuer.Group) {
+			errs = append(errs, field.Invalid(path.Child("group"), issuer.Group, msg))
+		}
+		if len(issuer.Kind) == 0 {
+			errs = append(errs, field.Required(path.Child("kind"), "the kind of the external issuer is required"))
+		}
+		return errs
+	}
+	switch issuer.Kind {
+	case Issuer, ClusterIssuer:
+	default:
+		errs = append(errs, field.NotSupported(path.Child("kind"), issuer.Kind, []string{string(Issuer), string(ClusterIssuer)}))
+	}
+	return errs
+}
+
+// validateExposure checks the exposure type and the settings it requires.
+func validateExposure(path *field.Path, exposure *Exposure) field.ErrorList {
+	var errs field.ErrorList
+
+	switch exposure.Type {
+	case "", ExposureRoute, ExposureIngress, ExposureGateway, ExposureLoadBalancer, ExposureNone:
+	default:
+		errs = append(errs, field.NotSupported(path.Child("type"), exposure.Type,
+			[]string{string(ExposureRoute), string(ExposureIngress), string(ExposureGateway), string(ExposureLoadBalancer), string(ExposureNone)}))
+	}
+
+	switch exposure.TLSTermination {
+	case "", TLSTerminationPassthrough, TLSTerminationReencrypt:
+	default:
+		errs = append(errs, field.NotSupported(path.Child("tlsTermination"), exposure.TLSTermination,
+			[]string{string(TLSTerminationPassthrough), string(TLSTerminationReencrypt)}))
+	}
+
+	if exposure.Type == ExposureLoadBalancer && exposure.TLSTermination == TLSTerminationReencrypt {
+		errs = append(errs, field.Invalid(path.Child("tlsTermination"), exposure.TLSTermination, "a LoadBalancer exposure requires Passthrough"))
+	}
+
+	if exposure.Type == ExposureGateway && (exposure.Gateway == nil || len(exposure.Gateway.Name) == 0) {
+		errs = append(errs, field.Required(path.Child("gateway", "name"), "a Gateway exposure requires the gateway to attach to"))
+	}
+
+	if len(exposure.IngressClassName) > 0 {
+		for _, msg := range validation.IsDNS1123Subdomain(exposure.IngressClassName) {
+			errs = append(errs, field.Invalid(path.Child("ingressClassName"), exposure.IngressClassName, msg))
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,347 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package v1alpha1
+
+import (
+	"testing"
+	"time"
+
+	autoscalingv2beta2 "k8s.io/api/autoscaling/v2beta2"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestDefault(t *testing.T) {
+	ingress := &ManagementIngress{}
+	ingress.Default()
+
+	if ingress.Spec.ManagementState != ManagementStateManaged {
+		t.Errorf("expected managementState %q, got %q", ManagementStateManaged, ingress.Spec.ManagementState)
+	}
+	if ingress.Spec.Replicas != DefaultReplicas {
+		t.Errorf("expected %d replicas, got %d", DefaultReplicas, ingress.Spec.Replicas)
+	}
+	if ingress.Spec.Resources == nil || !ingress.Spec.Resources.Limits.Memory().Equal(DefaultMemoryLimit) {
+		t.Errorf("expected default resources, got %v", ingress.Spec.Resources)
+	}
+	expectedIssuer := CertIssuer{Name: DefaultCAIssuerName, Kind: DefaultCAIssuerKind}
+	if ingress.Spec.Cert == nil || ingress.Spec.Cert.NamespacedIssuer != expectedIssuer {
+		t.Errorf("expected default issuer %v, got %v", expectedIssuer, ingress.Spec.Cert)
+	}
+
+	capitalized := &ManagementIngress{Spec: ManagementIngressSpec{ManagementState: "Managed"}}
+	capitalized.Default()
+	if capitalized.Spec.ManagementState != ManagementStateManaged {
+		t.Errorf("expected managementState Managed to be normalized, got %q", capitalized.Spec.ManagementState)
+	}
+
+	// An issuer set by the user is kept.
+	custom := &ManagementIngress{Spec: ManagementIngressSpec{Cert: &Cert{Issuer: CertIssuer{Name: "my-issuer", Kind: ClusterIssuer}}}}
+	custom.Default()
+	if custom.Spec.Cert.NamespacedIssuer != (CertIssuer{}) {
+		t.Errorf("expected namespacedIssuer to stay empty when issuer is set, got %v", custom.Spec.Cert.NamespacedIssuer)
+	}
+}
+
+func TestValidateCreate(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    ManagementIngressSpec
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			spec: ManagementIngressSpec{
+				ManagementState: ManagementStateManaged,
+				RouteHost:       "cp-console.apps.example.com",
+				ProxyRouteHost:  "cp-proxy.apps.example.com",
+				Cert: &Cert{
+					NamespacedIssuer: CertIssuer{Name: DefaultCAIssuerName, Kind: Issuer},
+					IPAddresses:      []string{"10.0.0.1", "fd00::1"},
+				},
+			},
+		},
+		{
+			name:    "invalid route host",
+			spec:    ManagementIngressSpec{RouteHost: "CP_Console.example.com"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid proxy route host",
+			spec:    ManagementIngressSpec{ProxyRouteHost: "cp-proxy..example.com"},
+			wantErr: true,
+		},
+		{
+			name:    "malformed ip address",
+			spec:    ManagementIngressSpec{Cert: &Cert{IPAddresses: []string{"10.0.0.256"}}},
+			wantErr: true,
+		},
+		{
+			name:    "unknown issuer kind",
+			spec:    ManagementIngressSpec{Cert: &Cert{Issuer: CertIssuer{Name: "ca", Kind: "Vault"}}},
+			wantErr: true,
+		},
+		{
+			name:    "load balancer with reencrypt",
+			spec:    ManagementIngressSpec{Exposure: &Exposure{Type: ExposureLoadBalancer, TLSTermination: TLSTerminationReencrypt}},
+			wantErr: true,
+		},
+		{
+			name:    "gateway without gateway reference",
+			spec:    ManagementIngressSpec{Exposure: &Exposure{Type: ExposureGateway}},
+			wantErr: true,
+		},
+		{
+			name: "ingress exposure",
+			spec: ManagementIngressSpec{Exposure: &Exposure{Type: ExposureIngress, IngressClassName: "nginx"}},
+		},
+		{
+			name: "user provided certificate secrets",
+			spec: ManagementIngressSpec{Cert: &Cert{
+				TLSSecretRef:   &corev1.LocalObjectReference{Name: "corporate-service-tls"},
+				RouteSecretRef: &corev1.LocalObjectReference{Name: "corporate-route-tls"},
+			}},
+		},
+		{
+			name:    "certificate secret without name",
+			spec:    ManagementIngressSpec{Cert: &Cert{RouteSecretRef: &corev1.LocalObjectReference{}}},
+			wantErr: true,
+		},
+		{
+			name: "90 day ECDSA certificates",
+			spec: ManagementIngressSpec{Cert: &Cert{
+				Duration:    &metav1.Duration{Duration: 90 * 24 * time.Hour},
+				RenewBefore: &metav1.Duration{Duration: 30 * 24 * time.Hour},
+				PrivateKey:  &CertPrivateKey{Algorithm: ECDSAKeyAlgorithm, Size: 256, RotationPolicy: RotationPolicyAlways},
+			}},
+		},
+		{
+			name:    "renewBefore longer than duration",
+			spec:    ManagementIngressSpec{Cert: &Cert{Duration: &metav1.Duration{Duration: 12 * time.Hour}, RenewBefore: &metav1.Duration{Duration: 24 * time.Hour}}},
+			wantErr: true,
+		},
+		{
+			name:    "unsupported ECDSA key size",
+			spec:    ManagementIngressSpec{Cert: &Cert{PrivateKey: &CertPrivateKey{Algorithm: ECDSAKeyAlgorithm, Size: 2048}}},
+			wantErr: true,
+		},
+		{
+			name: "external issuer",
+			spec: ManagementIngressSpec{Cert: &Cert{Issuer: CertIssuer{Name: "pca", Kind: "AWSPCAClusterIssuer", Group: "awspca.cert-manager.io"}}},
+		},
+		{
+			name:    "external issuer without kind",
+			spec:    ManagementIngressSpec{Cert: &Cert{Issuer: CertIssuer{Name: "step", Group: "certmanager.step.sm"}}},
+			wantErr: true,
+		},
+		{
+			name: "ingress config",
+			spec: ManagementIngressSpec{IngressConfig: &IngressConfig{
+				ProxyReadTimeout:         &metav1.Duration{Duration: 5 * time.Minute},
+				ProxyBodySize:            "100m",
+				WorkerProcesses:          "4",
+				LargeClientHeaderBuffers: "8 16k",
+			}},
+		},
+		{
+			name:    "proxy connect timeout longer than 75s",
+			spec:    ManagementIngressSpec{IngressConfig: &IngressConfig{ProxyConnectTimeout: &metav1.Duration{Duration: 90 * time.Second}}},
+			wantErr: true,
+		},
+		{
+			name:    "gzip level out of range",
+			spec:    ManagementIngressSpec{IngressConfig: &IngressConfig{GzipLevel: 10}},
+			wantErr: true,
+		},
+		{
+			name:    "malformed proxy body size",
+			spec:    ManagementIngressSpec{IngressConfig: &IngressConfig{ProxyBodySize: "1 MB"}},
+			wantErr: true,
+		},
+		{
+			name: "autoscaling",
+			spec: ManagementIngressSpec{Autoscaling: &Autoscaling{Enabled: true, MaxReplicas: 3}},
+		},
+		{
+			name:    "autoscaling with maxReplicas lower than minReplicas",
+			spec:    ManagementIngressSpec{Autoscaling: &Autoscaling{Enabled: true, MinReplicas: &[]int32{3}[0], MaxReplicas: 2}},
+			wantErr: true,
+		},
+		{
+			name: "autoscaling with unsupported scaling policy",
+			spec: ManagementIngressSpec{Autoscaling: &Autoscaling{Enabled: true, MaxReplicas: 3, Behavior: &autoscalingv2beta2.HorizontalPodAutoscalerBehavior{
+				ScaleDown: &autoscalingv2beta2.HPAScalingRules{Policies: []autoscalingv2beta2.HPAScalingPolicy{{Type: "Nodes", Value: 1, PeriodSeconds: 60}}},
+			}}},
+			wantErr: true,
+		},
+		{
+			name: "availability",
+			spec: ManagementIngressSpec{Availability: &Availability{
+				MaxSurge:            &[]intstr.IntOrString{intstr.FromString("25%")}[0],
+				PodDisruptionBudget: &PodDisruptionBudget{MinAvailable: &[]intstr.IntOrString{intstr.FromInt(1)}[0]},
+			}},
+		},
+		{
+			name:    "no surge and no unavailable pods",
+			spec:    ManagementIngressSpec{Availability: &Availability{MaxSurge: &[]intstr.IntOrString{intstr.FromInt(0)}[0], MaxUnavailable: &[]intstr.IntOrString{intstr.FromString("0%")}[0]}},
+			wantErr: true,
+		},
+		{
+			name: "pod disruption budget with minAvailable and maxUnavailable",
+			spec: ManagementIngressSpec{Availability: &Availability{PodDisruptionBudget: &PodDisruptionBudget{
+				MinAvailable:   &[]intstr.IntOrString{intstr.FromInt(1)}[0],
+				MaxUnavailable: &[]intstr.IntOrString{intstr.FromInt(1)}[0],
+			}}},
+			wantErr: true,
+		},
+		{
+			name:    "malformed maxUnavailable",
+			spec:    ManagementIngressSpec{Availability: &Availability{MaxUnavailable: &[]intstr.IntOrString{intstr.FromString("half")}[0]}},
+			wantErr: true,
+		},
+		{
+			name: "network policy",
+			spec: ManagementIngressSpec{NetworkPolicy: &NetworkPolicy{Enabled: true, ExtraPeers: []networkingv1.NetworkPolicyPeer{
+				{IPBlock: &networkingv1.IPBlock{CIDR: "10.0.0.0/8", Except: []string{"10.1.0.0/16"}}},
+				{NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "console"}}},
+			}}},
+		},
+		{
+			name:    "network policy peer without selector",
+			spec:    ManagementIngressSpec{NetworkPolicy: &NetworkPolicy{Enabled: true, ExtraPeers: []networkingv1.NetworkPolicyPeer{{}}}},
+			wantErr: true,
+		},
+		{
+			name: "network policy ip block outside of cidr",
+			spec: ManagementIngressSpec{NetworkPolicy: &NetworkPolicy{Enabled: true, ExtraPeers: []networkingv1.NetworkPolicyPeer{
+				{IPBlock: &networkingv1.IPBlock{CIDR: "10.0.0.0/8", Except: []string{"192.168.0.0/16"}}},
+			}}},
+			wantErr: true,
+		},
+		{
+			name: "scheduling",
+			spec: ManagementIngressSpec{Scheduling: &Scheduling{
+				Architectures:   []string{"amd64", "arm64"},
+				PodAntiAffinity: PodAntiAffinityRequired,
+				TopologySpreadConstraints: []corev1.TopologySpreadConstraint{
+					{MaxSkew: 1, TopologyKey: "topology.kubernetes.io/zone", WhenUnsatisfiable: corev1.DoNotSchedule},
+				},
+				PriorityClassName: "system-cluster-critical",
+			}},
+		},
+		{
+			name:    "empty architecture",
+			spec:    ManagementIngressSpec{Scheduling: &Scheduling{Architectures: []string{""}}},
+			wantErr: true,
+		},
+		{
+			name:    "unknown pod anti-affinity",
+			spec:    ManagementIngressSpec{Scheduling: &Scheduling{PodAntiAffinity: "Always"}},
+			wantErr: true,
+		},
+		{
+			name: "duplicate topology key",
+			spec: ManagementIngressSpec{Scheduling: &Scheduling{TopologySpreadConstraints: []corev1.TopologySpreadConstraint{
+				{MaxSkew: 1, TopologyKey: "topology.kubernetes.io/zone", WhenUnsatisfiable: corev1.DoNotSchedule},
+				{MaxSkew: 2, TopologyKey: "topology.kubernetes.io/zone", WhenUnsatisfiable: corev1.ScheduleAnyway},
+			}}},
+			wantErr: true,
+		},
+		{
+			name: "topology spread without maxSkew",
+			spec: ManagementIngressSpec{Scheduling: &Scheduling{TopologySpreadConstraints: []corev1.TopologySpreadConstraint{
+				{TopologyKey: "topology.kubernetes.io/zone", WhenUnsatisfiable: corev1.DoNotSchedule},
+			}}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid priority class",
+			spec:    ManagementIngressSpec{Scheduling: &Scheduling{PriorityClassName: "Critical_Pods"}},
+			wantErr: true,
+		},
+		{
+			name:    "unknown management state",
+			spec:    ManagementIngressSpec{ManagementState: "Removed"},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		ingress := &ManagementIngress{Spec: test.spec}
+		if err := ingress.ValidateCreate(); (err != nil) != test.wantErr {
+			t.Errorf("%s: ValidateCreate() error = %v, wantErr %v", test.name, err, test.wantErr)
+		}
+	}
+}
+
+func TestValidateUpdateImmutable(t *testing.T) {
+	old := &ManagementIngress{}
+	updated := old.DeepCopy()
+	updated.Spec.MultipleInstancesEnabled = true
+
+	if err := updated.ValidateUpdate(old); err == nil {
+		t.Errorf("expected changing multipleInstancesEnabled to be rejected")
+	}
+	if err := old.DeepCopy().ValidateUpdate(old); err != nil {
+		t.Errorf("expected unchanged update to be accepted, got %v", err)
+	}
+}
+
+func TestValidateUpdateExposureAndSecretRefs(t *testing.T) {
+	old := &ManagementIngress{Spec: ManagementIngressSpec{
+		Exposure: &Exposure{Type: ExposureIngress},
+		Cert:     &Cert{TLSSecretRef: &corev1.LocalObjectReference{Name: "my-tls"}},
+	}}
+
+	for name, update := range map[string]func(*ManagementIngress){
+		"exposure type":    func(r *ManagementIngress) { r.Spec.Exposure.Type = ExposureLoadBalancer },
+		"no exposure":      func(r *ManagementIngress) { r.Spec.Exposure = nil },
+		"tls secret unset": func(r *ManagementIngress) { r.Spec.Cert.TLSSecretRef = nil },
+		"route secret set": func(r *ManagementIngress) { r.Spec.Cert.RouteSecretRef = &corev1.LocalObjectReference{Name: "route"} },
+		"cert unset":       func(r *ManagementIngress) { r.Spec.Cert = nil },
+	} {
+		updated := old.DeepCopy()
+		update(updated)
+		if err := updated.ValidateUpdate(old); err == nil {
+			t.Errorf("%s: expected the update to be rejected", name)
+		}
+	}
+
+	// Another user provided secret is fine.
+	updated := old.DeepCopy()
+	updated.Spec.Cert.TLSSecretRef.Name = "my-other-tls"
+	if err := updated.ValidateUpdate(old); err != nil {
+		t.Errorf("expected changing the user provided secret to be accepted, got %v", err)
+	}
+}
+
+func TestValidateCreateName(t *testing.T) {
+	for name, wantErr := range map[string]bool{
+		DefaultName: false,
+		"staging":   false,
+		"prod-2":    false,
+		"2prod":     true,
+		"prod.eu":   true,
+		"a-name-which-is-too-long-for-the-services": true,
+	} {
+		ingress := &ManagementIngress{ObjectMeta: metav1.ObjectMeta{Name: name}}
+		if err := ingress.ValidateCreate(); (err != nil) != wantErr {
+			t.Errorf("%s: ValidateCreate() error = %v, wantErr %v", name, err, wantErr)
+		}
+	}
+}
@@ -22,10 +22,89 @@
 package v1alpha1
 
 import (
+	"k8s.io/api/autoscaling/v2beta2"
 	"k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Autoscaling) DeepCopyInto(out *Autoscaling) {
+	*out = *in
+	if in.MinReplicas != nil {
+		in, out := &in.MinReplicas, &out.MinReplicas
+		*out = new(int32)
+		**out = **in
+	}
+	if in.TargetCPUUtilizationPercentage != nil {
+		in, out := &in.TargetCPUUtilizationPercentage, &out.TargetCPUUtilizationPercentage
+		*out = new(int32)
+		**out = **in
+	}
+	if in.TargetMemoryUtilizationPercentage != nil {
+		in, out := &in.TargetMemoryUtilizationPercentage, &out.TargetMemoryUtilizationPercentage
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Behavior != nil {
+		in, out := &in.Behavior, &out.Behavior
+		*out = new(v2beta2.HorizontalPodAutoscalerBehavior)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Autoscaling.
+func (in *Autoscaling) DeepCopy() *Autoscaling {
+	if in == nil {
+		return nil
+	}
+	out := new(Autoscaling)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Availability) DeepCopyInto(out *Availability) {
+	*out = *in
+	if in.MaxSurge != nil {
+		in, out := &in.MaxSurge, &out.MaxSurge
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+	if in.MaxUnavailable != nil {
+		in, out := &in.MaxUnavailable, &out.MaxUnavailable
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+	if in.MinReadySeconds != nil {
+		in, out := &in.MinReadySeconds, &out.MinReadySeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.PreStopDelay != nil {
+		in, out := &in.PreStopDelay, &out.PreStopDelay
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.PodDisruptionBudget != nil {
+		in, out := &in.PodDisruptionBudget, &out.PodDisruptionBudget
+		*out = new(PodDisruptionBudget)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Availability.
+func (in *Availability) DeepCopy() *Availability {
+	if in == nil {
+		return nil
+	}
+	out := new(Availability)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Cert) DeepCopyInto(out *Cert) {
 	*out = *in
@@ -41,6 +120,31 @@ func (in *Cert) DeepCopyInto(out *Cert) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Duration != nil {
+		in, out := &in.Duration, &out.Duration
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.RenewBefore != nil {
+		in, out := &in.RenewBefore, &out.RenewBefore
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.PrivateKey != nil {
+		in, out := &in.PrivateKey, &out.PrivateKey
+		*out = new(CertPrivateKey)
+		**out = **in
+	}
+	if in.TLSSecretRef != nil {
+		in, out := &in.TLSSecretRef, &out.TLSSecretRef
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+	if in.RouteSecretRef != nil {
+		in, out := &in.RouteSecretRef, &out.RouteSecretRef
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Cert.
@@ -68,6 +172,21 @@ func (in *CertIssuer) DeepCopy() *CertIssuer {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertPrivateKey) DeepCopyInto(out *CertPrivateKey) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertPrivateKey.
+func (in *CertPrivateKey) DeepCopy() *CertPrivateKey {
+	if in == nil {
+		return nil
+	}
+	out := new(CertPrivateKey)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Condition) DeepCopyInto(out *Condition) {
 	*out = *in
@@ -105,6 +224,108 @@ func (in ConditionList) DeepCopy() ConditionList {
 	return *out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Exposure) DeepCopyInto(out *Exposure) {
+	*out = *in
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Gateway != nil {
+		in, out := &in.Gateway, &out.Gateway
+		*out = new(GatewayReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Exposure.
+func (in *Exposure) DeepCopy() *Exposure {
+	if in == nil {
+		return nil
+	}
+	out := new(Exposure)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GatewayReference) DeepCopyInto(out *GatewayReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GatewayReference.
+func (in *GatewayReference) DeepCopy() *GatewayReference {
+	if in == nil {
+		return nil
+	}
+	out := new(GatewayReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IngressConfig) DeepCopyInto(out *IngressConfig) {
+	*out = *in
+	if in.ProxyConnectTimeout != nil {
+		in, out := &in.ProxyConnectTimeout, &out.ProxyConnectTimeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.ProxyReadTimeout != nil {
+		in, out := &in.ProxyReadTimeout, &out.ProxyReadTimeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.ProxySendTimeout != nil {
+		in, out := &in.ProxySendTimeout, &out.ProxySendTimeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.AccessLog != nil {
+		in, out := &in.AccessLog, &out.AccessLog
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Gzip != nil {
+		in, out := &in.Gzip, &out.Gzip
+		*out = new(bool)
+		**out = **in
+	}
+	if in.KeepAliveTimeout != nil {
+		in, out := &in.KeepAliveTimeout, &out.KeepAliveTimeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.HSTS != nil {
+		in, out := &in.HSTS, &out.HSTS
+		*out = new(bool)
+		**out = **in
+	}
+	if in.HSTSMaxAge != nil {
+		in, out := &in.HSTSMaxAge, &out.HSTSMaxAge
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.ServerTokens != nil {
+		in, out := &in.ServerTokens, &out.ServerTokens
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IngressConfig.
+func (in *IngressConfig) DeepCopy() *IngressConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(IngressConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ManagementIngress) DeepCopyInto(out *ManagementIngress) {
 	*out = *in
@@ -199,6 +420,41 @@ func (in *ManagementIngressSpec) DeepCopyInto(out *ManagementIngressSpec) {
 			(*out)[key] = val
 		}
 	}
+	if in.ImagePullSecrets != nil {
+		in, out := &in.ImagePullSecrets, &out.ImagePullSecrets
+		*out = make([]v1.LocalObjectReference, len(*in))
+		copy(*out, *in)
+	}
+	if in.Exposure != nil {
+		in, out := &in.Exposure, &out.Exposure
+		*out = new(Exposure)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.IngressConfig != nil {
+		in, out := &in.IngressConfig, &out.IngressConfig
+		*out = new(IngressConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Autoscaling != nil {
+		in, out := &in.Autoscaling, &out.Autoscaling
+		*out = new(Autoscaling)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Availability != nil {
+		in, out := &in.Availability, &out.Availability
+		*out = new(Availability)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NetworkPolicy != nil {
+		in, out := &in.NetworkPolicy, &out.NetworkPolicy
+		*out = new(NetworkPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Scheduling != nil {
+		in, out := &in.Scheduling, &out.Scheduling
+		*out = new(Scheduling)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManagementIngressSpec.
@@ -247,6 +503,16 @@ func (in *ManagementIngressStatus) DeepCopyInto(out *ManagementIngressStatus) {
 		}
 	}
 	out.State = in.State
+	if in.Issuer != nil {
+		in, out := &in.Issuer, &out.Issuer
+		*out = new(CertIssuer)
+		**out = **in
+	}
+	if in.Objects != nil {
+		in, out := &in.Objects, &out.Objects
+		*out = new(ObjectNames)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManagementIngressStatus.
@@ -259,6 +525,48 @@ func (in *ManagementIngressStatus) DeepCopy() *ManagementIngressStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkPolicy) DeepCopyInto(out *NetworkPolicy) {
+	*out = *in
+	if in.IngressNamespaceSelector != nil {
+		in, out := &in.IngressNamespaceSelector, &out.IngressNamespaceSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ExtraPeers != nil {
+		in, out := &in.ExtraPeers, &out.ExtraPeers
+		*out = make([]networkingv1.NetworkPolicyPeer, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkPolicy.
+func (in *NetworkPolicy) DeepCopy() *NetworkPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ObjectNames) DeepCopyInto(out *ObjectNames) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObjectNames.
+func (in *ObjectNames) DeepCopy() *ObjectNames {
+	if in == nil {
+		return nil
+	}
+	out := new(ObjectNames)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *OperandImage) DeepCopyInto(out *OperandImage) {
 	*out = *in
@@ -289,6 +597,31 @@ func (in *OperandState) DeepCopy() *OperandState {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PodDisruptionBudget) DeepCopyInto(out *PodDisruptionBudget) {
+	*out = *in
+	if in.MinAvailable != nil {
+		in, out := &in.MinAvailable, &out.MinAvailable
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+	if in.MaxUnavailable != nil {
+		in, out := &in.MaxUnavailable, &out.MaxUnavailable
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodDisruptionBudget.
+func (in *PodDisruptionBudget) DeepCopy() *PodDisruptionBudget {
+	if in == nil {
+		return nil
+	}
+	out := new(PodDisruptionBudget)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in PodStateMap) DeepCopyInto(out *PodStateMap) {
 	{
@@ -317,3 +650,35 @@ func (in PodStateMap) DeepCopy() PodStateMap {
 	in.DeepCopyInto(out)
 	return *out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Scheduling) DeepCopyInto(out *Scheduling) {
+	*out = *in
+	if in.Architectures != nil {
+		in, out := &in.Architectures, &out.Architectures
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Affinity != nil {
+		in, out := &in.Affinity, &out.Affinity
+		*out = new(v1.Affinity)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TopologySpreadConstraints != nil {
+		in, out := &in.TopologySpreadConstraints, &out.TopologySpreadConstraints
+		*out = make([]v1.TopologySpreadConstraint, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Scheduling.
+func (in *Scheduling) DeepCopy() *Scheduling {
+	if in == nil {
+		return nil
+	}
+	out := new(Scheduling)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,284 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+// Package clusterinfo reads the ibmcloud-cluster-info config map and the ibmcloud-cluster-ca-cert secret
+// maintained by the management ingress operator in its namespace.
+package clusterinfo
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// ConfigMapName is the name of the config map of the cluster info.
+	ConfigMapName string = "ibmcloud-cluster-info"
+	// CASecretName is the name of the secret of the CA of the cluster certificates.
+	CASecretName string = "ibmcloud-cluster-ca-cert"
+	// CAKey is the key of the PEM encoded CA in the secret.
+	CAKey string = "ca.crt"
+
+	// SchemaVersion is the version of the keys of the cluster info written by this package.
+	SchemaVersion string = "v1"
+)
+
+// Keys of the cluster info config map.
+const (
+	SchemaVersionKey    string = "schema_version"
+	ClusterAddressKey   string = "cluster_address"
+	ClusterCADomainKey  string = "cluster_ca_domain"
+	ClusterEndpointKey  string = "cluster_endpoint"
+	ClusterNameKey      string = "cluster_name"
+	RouterHTTPPortKey   string = "cluster_router_http_port"
+	RouterHTTPSPortKey  string = "cluster_router_https_port"
+	RouterBaseDomainKey string = "openshift_router_base_domain"
+	VersionKey          string = "version"
+	APIServerHostKey    string = "cluster_kube_apiserver_host"
+	APIServerPortKey    string = "cluster_kube_apiserver_port"
+	ProxyAddressKey     string = "proxy_address"
+	ProxyHTTPPortKey    string = "proxy_ingress_http_port"
+	ProxyHTTPSPortKey   string = "proxy_ingress_https_port"
+)
+
+// ClusterInfo is the content of the cluster info config map.
+type ClusterInfo struct {
+	// SchemaVersion is the version of the keys, v1 when the config map does not set it.
+	SchemaVersion string
+	// ClusterAddress is the host of the console of the cluster.
+	ClusterAddress string
+	// ClusterCADomain is the domain of the cluster certificates.
+	ClusterCADomain string
+	// ClusterEndpoint is the in-cluster URL of the management ingress service.
+	ClusterEndpoint string
+	ClusterName     string
+	RouterHTTPPort  int32
+	RouterHTTPSPort int32
+	// RouterBaseDomain is the domain of the routes of OpenShift clusters, empty on other clusters.
+	RouterBaseDomain string
+	// Version is the version of IBM Cloud Platform Common Services.
+	Version string
+	// APIServerHost and APIServerPort are the API server of OpenShift clusters, empty when it is not known.
+	APIServerHost  string
+	APIServerPort  int32
+	ProxyAddress   string
+	ProxyHTTPPort  int32
+	ProxyHTTPSPort int32
+}
+
+// FromData returns the cluster info of the data of the config map, validated.
+func FromData(data map[string]string) (*ClusterInfo, error) {
+	info := &ClusterInfo{
+		SchemaVersion:    data[SchemaVersionKey],
+		ClusterAddress:   data[ClusterAddressKey],
+		ClusterCADomain:  data[ClusterCADomainKey],
+		ClusterEndpoint:  data[ClusterEndpointKey],
+		ClusterName:      data[ClusterNameKey],
+		RouterBaseDomain: data[RouterBaseDomainKey],
+		Version:          data[VersionKey],
+		APIServerHost:    data[APIServerHostKey],
+		ProxyAddress:     data[ProxyAddressKey],
+	}
+	if len(info.SchemaVersion) == 0 {
+		info.SchemaVersion = SchemaVersion
+	}
+
+	var errs []string
+	for _, port := range []struct {
+		key   string
+		value *int32
+	}{
+		{RouterHTTPPortKey, &info.RouterHTTPPort},
+		{RouterHTTPSPortKey, &info.RouterHTTPSPort},
+		{APIServerPortKey, &info.APIServerPort},
+		{ProxyHTTPPortKey, &info.ProxyHTTPPort},
+		{ProxyHTTPSPortKey, &info.ProxyHTTPSPort},
+	} {
+		value := data[port.key]
+		if len(value) == 0 {
+			continue
+		}
+		parsed, err := strconv.ParseInt(value, 10, 32)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %q is not a port", port.key, value))
+			continue
+		}
+		*port.value = int32(parsed)
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("invalid cluster info: %s", strings.Join(errs, "; "))
+	}
+
+	if err := info.Validate(); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// Data returns the data of the config map of the cluster info. The optional keys are left out when empty.
+func (info *ClusterInfo) Data() map[string]string {
+	schemaVersion := info.SchemaVersion
+	if len(schemaVersion) == 0 {
+		schemaVersion = SchemaVersion
+	}
+	data := map[string]string{
+		SchemaVersionKey:   schemaVersion,
+		ClusterAddressKey:  info.ClusterAddress,
+		ClusterCADomainKey: info.ClusterCADomain,
+		ClusterEndpointKey: info.ClusterEndpoint,
+		ClusterNameKey:     info.ClusterName,
+		RouterHTTPPortKey:  formatPort(info.RouterHTTPPort),
+		RouterHTTPSPortKey: formatPort(info.RouterHTTPSPort),
+		VersionKey:         info.Version,
+		ProxyAddressKey:    info.ProxyAddress,
+		ProxyHTTPPortKey:   formatPort(info.ProxyHTTPPort),
+		ProxyHTTPSPortKey:  formatPort(info.ProxyHTTPSPort),
+	}
+	if len(info.RouterBaseDomain) > 0 {
+		data[RouterBaseDomainKey] = info.RouterBaseDomain
+	}
+	if len(info.APIServerHost) > 0 {
+		data[APIServerHostKey] = info.APIServerHost
+		data[APIServerPortKey] = formatPort(info.APIServerPort)
+	}
+	return data
+}
+
+// Validate returns an error listing the invalid fields of the cluster info.
+func (info *ClusterInfo) Validate() error {
+	var errs []string
+	if len(info.SchemaVersion) > 0 && info.SchemaVersion != SchemaVersion {
+		errs = append(errs, fmt.Sprintf("%s: unsupported version %q, %s is supported", SchemaVersionKey, info.SchemaVersion, SchemaVersion))
+	}
+
+	for _, host := range []field{
+		{ClusterAddressKey, info.ClusterAddress},
+		{ClusterCADomainKey, info.ClusterCADomain},
+		{ProxyAddressKey, info.ProxyAddress},
+	} {
+		if msgs := validation.IsDNS1123Subdomain(host.value); len(msgs) > 0 {
+			errs = append(errs, fmt.Sprintf("%s: %q is not a host: %s", host.key, host.value, strings.Join(msgs, ", ")))
+		}
+	}
+	if len(info.RouterBaseDomain) > 0 {
+		if msgs := validation.IsDNS1123Subdomain(info.RouterBaseDomain); len(msgs) > 0 {
+			errs = append(errs, fmt.Sprintf("%s: %q is not a domain: %s", RouterBaseDomainKey, info.RouterBaseDomain, strings.Join(msgs, ", ")))
+		}
+	}
+
+	if endpoint, err := url.Parse(info.ClusterEndpoint); err != nil || endpoint.Scheme != "https" || len(endpoint.Hostname()) == 0 {
+		errs = append(errs, fmt.Sprintf("%s: %q is not an https URL", ClusterEndpointKey, info.ClusterEndpoint))
+	}
+
+	for _, required := range []field{{ClusterNameKey, info.ClusterName}, {VersionKey, info.Version}} {
+		if len(required.value) == 0 {
+			errs = append(errs, fmt.Sprintf("%s: must be set", required.key))
+		}
+	}
+
+	errs = append(errs, validatePort(RouterHTTPPortKey, info.RouterHTTPPort)...)
+	errs = append(errs, validatePort(RouterHTTPSPortKey, info.RouterHTTPSPort)...)
+	errs = append(errs, validatePort(ProxyHTTPPortKey, info.ProxyHTTPPort)...)
+	errs = append(errs, validatePort(ProxyHTTPSPortKey, info.ProxyHTTPSPort)...)
+
+	// The API server is optional, but its host and port come together.
+	if len(info.APIServerHost) > 0 || info.APIServerPort != 0 {
+		if len(info.APIServerHost) == 0 {
+			errs = append(errs, fmt.Sprintf("%s: must be set with %s", APIServerHostKey, APIServerPortKey))
+		}
+		errs = append(errs, validatePort(APIServerPortKey, info.APIServerPort)...)
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid cluster info: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// field is a key of the config map and its value, in the order of the errors.
+type field struct {
+	key   string
+	value string
+}
+
+func validatePort(key string, port int32) []string {
+	if msgs := validation.IsValidPortNum(int(port)); len(msgs) > 0 {
+		return []string{fmt.Sprintf("%s: %s", key, strings.Join(msgs, ", "))}
+	}
+	return nil
+}
+
+func formatPort(port int32) string {
+	return strconv.FormatInt(int64(port), 10)
+}
+
+// ClusterCA is the CA of the certificates of the cluster, e.g. of the console and the proxy.
+type ClusterCA struct {
+	// PEM is the PEM encoded CA, possibly a bundle.
+	PEM []byte
+}
+
+// FromSecretData returns the CA of the data of the secret, validated.
+func FromSecretData(data map[string][]byte) (*ClusterCA, error) {
+	ca := &ClusterCA{PEM: data[CAKey]}
+	if _, err := ca.Certificates(); err != nil {
+		return nil, err
+	}
+	return ca, nil
+}
+
+// Certificates returns the certificates of the CA.
+func (ca *ClusterCA) Certificates() ([]*x509.Certificate, error) {
+	var certificates []*x509.Certificate
+	rest := ca.PEM
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		certificate, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cluster CA: %v", err)
+		}
+		certificates = append(certificates, certificate)
+	}
+	if len(certificates) == 0 {
+		return nil, fmt.Errorf("invalid cluster CA: no certificate in %s", CAKey)
+	}
+	return certificates, nil
+}
+
+// CertPool returns a pool of the certificates of the CA, e.g. for the tls.Config of a client of the cluster.
+func (ca *ClusterCA) CertPool() (*x509.CertPool, error) {
+	certificates, err := ca.Certificates()
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	for _, certificate := range certificates {
+		pool.AddCert(certificate)
+	}
+	return pool, nil
+}
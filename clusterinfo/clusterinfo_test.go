@@ -0,0 +1,143 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package clusterinfo
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newData() map[string]string {
+	return map[string]string{
+		ClusterAddressKey:   "cp-console.apps.example.com",
+		ClusterCADomainKey:  "cp-console.apps.example.com",
+		ClusterEndpointKey:  "https://icp-management-ingress.ibm-common-services.svc:443",
+		ClusterNameKey:      "mycluster",
+		RouterHTTPPortKey:   "80",
+		RouterHTTPSPortKey:  "443",
+		RouterBaseDomainKey: "apps.example.com",
+		VersionKey:          "3.8.0",
+		APIServerHostKey:    "api.example.com",
+		APIServerPortKey:    "6443",
+		ProxyAddressKey:     "cp-proxy.apps.example.com",
+		ProxyHTTPPortKey:    "80",
+		ProxyHTTPSPortKey:   "443",
+	}
+}
+
+func newCAPEM(t *testing.T) []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "cs-ca-certificate"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func TestFromData(t *testing.T) {
+	info, err := FromData(newData())
+	if err != nil {
+		t.Fatalf("FromData returned unexpected error: %v", err)
+	}
+	if info.SchemaVersion != SchemaVersion || info.APIServerPort != 6443 || info.ProxyHTTPSPort != 443 {
+		t.Errorf("expected the cluster info of the data, got %+v", info)
+	}
+
+	// The data written by earlier operators has no schema version.
+	want := newData()
+	want[SchemaVersionKey] = SchemaVersion
+	if data := info.Data(); !reflect.DeepEqual(data, want) {
+		t.Errorf("expected the data with the schema version, got %v", data)
+	}
+
+	// The API server and the router domain are optional.
+	data := newData()
+	delete(data, APIServerHostKey)
+	delete(data, APIServerPortKey)
+	delete(data, RouterBaseDomainKey)
+	if info, err = FromData(data); err != nil {
+		t.Fatalf("FromData returned unexpected error: %v", err)
+	}
+	if _, ok := info.Data()[APIServerPortKey]; ok {
+		t.Errorf("expected no API server port, got %v", info.Data())
+	}
+}
+
+func TestFromDataInvalid(t *testing.T) {
+	for name, tc := range map[string]struct {
+		key, value, wantErr string
+	}{
+		"schema version":  {SchemaVersionKey, "v2", "unsupported version"},
+		"no address":      {ClusterAddressKey, "", ClusterAddressKey},
+		"address":         {ClusterAddressKey, "cp-console.", ClusterAddressKey},
+		"endpoint":        {ClusterEndpointKey, "icp-management-ingress:443", ClusterEndpointKey},
+		"no cluster name": {ClusterNameKey, "", ClusterNameKey},
+		"port":            {ProxyHTTPSPortKey, "https", ProxyHTTPSPortKey},
+		"port range":      {RouterHTTPPortKey, "70000", RouterHTTPPortKey},
+		"no port":         {RouterHTTPSPortKey, "", RouterHTTPSPortKey},
+		"API server host": {APIServerHostKey, "", APIServerHostKey},
+	} {
+		data := newData()
+		data[tc.key] = tc.value
+		if _, err := FromData(data); err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+			t.Errorf("%s: expected an error about %s, got %v", name, tc.wantErr, err)
+		}
+	}
+}
+
+func TestFromSecretData(t *testing.T) {
+	ca, err := FromSecretData(map[string][]byte{CAKey: newCAPEM(t)})
+	if err != nil {
+		t.Fatalf("FromSecretData returned unexpected error: %v", err)
+	}
+	certificates, err := ca.Certificates()
+	if err != nil || len(certificates) != 1 || certificates[0].Subject.CommonName != "cs-ca-certificate" {
+		t.Errorf("expected the CA certificate, got %v, %v", certificates, err)
+	}
+	if _, err := ca.CertPool(); err != nil {
+		t.Errorf("CertPool returned unexpected error: %v", err)
+	}
+
+	for _, data := range []map[string][]byte{
+		{},
+		{CAKey: []byte("not a certificate")},
+		{CAKey: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte("garbage")})},
+	} {
+		if _, err := FromSecretData(data); err == nil {
+			t.Errorf("expected an error for %q", data[CAKey])
+		}
+	}
+}
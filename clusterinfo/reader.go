@@ -0,0 +1,162 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package clusterinfo
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	toolscache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// Get reads the cluster info of namespace.
+func Get(ctx context.Context, reader client.Reader, namespace string) (*ClusterInfo, error) {
+	configMap := &core.ConfigMap{}
+	if err := reader.Get(ctx, types.NamespacedName{Name: ConfigMapName, Namespace: namespace}, configMap); err != nil {
+		return nil, fmt.Errorf("failure getting config map %s/%s: %v", namespace, ConfigMapName, err)
+	}
+	return FromData(configMap.Data)
+}
+
+// GetCA reads the cluster CA of namespace.
+func GetCA(ctx context.Context, reader client.Reader, namespace string) (*ClusterCA, error) {
+	secret := &core.Secret{}
+	if err := reader.Get(ctx, types.NamespacedName{Name: CASecretName, Namespace: namespace}, secret); err != nil {
+		return nil, fmt.Errorf("failure getting secret %s/%s: %v", namespace, CASecretName, err)
+	}
+	return FromSecretData(secret.Data)
+}
+
+// CachedReader reads the cluster info and CA of a namespace, and keeps them for a while. Pass Invalidate
+// to Watch to read them again as soon as they change.
+type CachedReader struct {
+	reader    client.Reader
+	namespace string
+	ttl       time.Duration
+	now       func() time.Time
+
+	lock       sync.Mutex
+	info       *ClusterInfo
+	infoExpiry time.Time
+	ca         *ClusterCA
+	caExpiry   time.Time
+}
+
+// NewCachedReader returns a reader of the cluster info and CA of namespace, which are read again after ttl.
+func NewCachedReader(reader client.Reader, namespace string, ttl time.Duration) *CachedReader {
+	return &CachedReader{reader: reader, namespace: namespace, ttl: ttl, now: time.Now}
+}
+
+// ClusterInfo returns the cluster info, read again when it is expired.
+func (r *CachedReader) ClusterInfo(ctx context.Context) (*ClusterInfo, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.info != nil && r.now().Before(r.infoExpiry) {
+		return r.info, nil
+	}
+	info, err := Get(ctx, r.reader, r.namespace)
+	if err != nil {
+		return nil, err
+	}
+	r.info, r.infoExpiry = info, r.now().Add(r.ttl)
+	return info, nil
+}
+
+// CA returns the cluster CA, read again when it is expired.
+func (r *CachedReader) CA(ctx context.Context) (*ClusterCA, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.ca != nil && r.now().Before(r.caExpiry) {
+		return r.ca, nil
+	}
+	ca, err := GetCA(ctx, r.reader, r.namespace)
+	if err != nil {
+		return nil, err
+	}
+	r.ca, r.caExpiry = ca, r.now().Add(r.ttl)
+	return ca, nil
+}
+
+// Invalidate drops the cluster info and CA, they are read again on next use.
+func (r *CachedReader) Invalidate() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.info, r.ca = nil, nil
+}
+
+// IsClusterInfoObject reports whether an object is the cluster info config map or the cluster CA secret of namespace.
+func IsClusterInfoObject(namespace string, obj interface{}) bool {
+	if tombstone, ok := obj.(toolscache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	accessor, err := meta.Accessor(obj)
+	if err != nil || accessor.GetNamespace() != namespace {
+		return false
+	}
+
+	switch obj.(type) {
+	case *core.ConfigMap:
+		return accessor.GetName() == ConfigMapName
+	case *core.Secret:
+		return accessor.GetName() == CASecretName
+	}
+	return false
+}
+
+// Predicate selects the events of the cluster info and CA of namespace, for the controllers which watch them.
+func Predicate(namespace string) predicate.Funcs {
+	return predicate.Funcs{
+		CreateFunc:  func(e event.CreateEvent) bool { return IsClusterInfoObject(namespace, e.Object) },
+		DeleteFunc:  func(e event.DeleteEvent) bool { return IsClusterInfoObject(namespace, e.Object) },
+		UpdateFunc:  func(e event.UpdateEvent) bool { return IsClusterInfoObject(namespace, e.ObjectNew) },
+		GenericFunc: func(e event.GenericEvent) bool { return IsClusterInfoObject(namespace, e.Object) },
+	}
+}
+
+// Watch calls onChange when the cluster info or CA of namespace change in the informers, e.g. the cache
+// of a manager, whose informers of config maps and secrets must hold namespace.
+func Watch(ctx context.Context, informers cache.Informers, namespace string, onChange func()) error {
+	handler := toolscache.FilteringResourceEventHandler{
+		FilterFunc: func(obj interface{}) bool { return IsClusterInfoObject(namespace, obj) },
+		Handler: toolscache.ResourceEventHandlerFuncs{
+			AddFunc:    func(interface{}) { onChange() },
+			UpdateFunc: func(interface{}, interface{}) { onChange() },
+			DeleteFunc: func(interface{}) { onChange() },
+		},
+	}
+
+	for _, obj := range []runtime.Object{&core.ConfigMap{}, &core.Secret{}} {
+		informer, err := informers.GetInformer(ctx, obj)
+		if err != nil {
+			return fmt.Errorf("failure getting informer of %T: %v", obj, err)
+		}
+		informer.AddEventHandler(handler)
+	}
+	return nil
+}
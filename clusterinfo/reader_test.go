@@ -0,0 +1,118 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package clusterinfo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	core "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	toolscache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+const namespace = "ibm-common-services"
+
+func newClient(t *testing.T, objs ...runtime.Object) client.Client {
+	s := runtime.NewScheme()
+	if err := core.AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+	return fake.NewFakeClientWithScheme(s, objs...)
+}
+
+func TestGet(t *testing.T) {
+	c := newClient(t,
+		&core.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: ConfigMapName, Namespace: namespace}, Data: newData()},
+		&core.Secret{ObjectMeta: metav1.ObjectMeta{Name: CASecretName, Namespace: namespace}, Data: map[string][]byte{CAKey: newCAPEM(t)}},
+	)
+
+	info, err := Get(context.TODO(), c, namespace)
+	if err != nil || info.ClusterName != "mycluster" {
+		t.Errorf("expected the cluster info, got %+v, %v", info, err)
+	}
+	if _, err := GetCA(context.TODO(), c, namespace); err != nil {
+		t.Errorf("GetCA returned unexpected error: %v", err)
+	}
+
+	if _, err := Get(context.TODO(), c, "default"); err == nil {
+		t.Error("expected an error without cluster info")
+	}
+	if _, err := GetCA(context.TODO(), c, "default"); err == nil {
+		t.Error("expected an error without cluster CA")
+	}
+}
+
+func TestCachedReader(t *testing.T) {
+	configMap := &core.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: ConfigMapName, Namespace: namespace}, Data: newData()}
+	c := newClient(t, configMap)
+	now := time.Now()
+	reader := NewCachedReader(c, namespace, time.Minute)
+	reader.now = func() time.Time { return now }
+
+	if _, err := reader.ClusterInfo(context.TODO()); err != nil {
+		t.Fatalf("ClusterInfo returned unexpected error: %v", err)
+	}
+
+	configMap.Data[ClusterNameKey] = "renamed"
+	if err := c.Update(context.TODO(), configMap); err != nil {
+		t.Fatal(err)
+	}
+	if info, _ := reader.ClusterInfo(context.TODO()); info.ClusterName != "mycluster" {
+		t.Errorf("expected the cached cluster info, got %+v", info)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if info, _ := reader.ClusterInfo(context.TODO()); info.ClusterName != "renamed" {
+		t.Errorf("expected the cluster info to be read again once expired, got %+v", info)
+	}
+
+	configMap.Data[ClusterNameKey] = "invalidated"
+	if err := c.Update(context.TODO(), configMap); err != nil {
+		t.Fatal(err)
+	}
+	reader.Invalidate()
+	if info, _ := reader.ClusterInfo(context.TODO()); info.ClusterName != "invalidated" {
+		t.Errorf("expected the cluster info to be read again once invalidated, got %+v", info)
+	}
+}
+
+func TestPredicate(t *testing.T) {
+	p := Predicate(namespace)
+	for _, tc := range []struct {
+		obj  runtime.Object
+		want bool
+	}{
+		{&core.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: ConfigMapName, Namespace: namespace}}, true},
+		{&core.Secret{ObjectMeta: metav1.ObjectMeta{Name: CASecretName, Namespace: namespace}}, true},
+		{&core.Secret{ObjectMeta: metav1.ObjectMeta{Name: ConfigMapName, Namespace: namespace}}, false},
+		{&core.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: ConfigMapName, Namespace: "default"}}, false},
+	} {
+		accessor := tc.obj.(metav1.Object)
+		if got := p.Create(event.CreateEvent{Meta: accessor, Object: tc.obj}); got != tc.want {
+			t.Errorf("%T %s/%s: expected %v, got %v", tc.obj, accessor.GetNamespace(), accessor.GetName(), tc.want, got)
+		}
+		tombstone := toolscache.DeletedFinalStateUnknown{Obj: tc.obj}
+		if got := IsClusterInfoObject(namespace, tombstone); got != tc.want {
+			t.Errorf("%T %s/%s deleted: expected %v, got %v", tc.obj, accessor.GetNamespace(), accessor.GetName(), tc.want, got)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+
+	operatorv1alpha1 "github.com/IBM/ibm-management-ingress-operator/api/v1alpha1"
+)
+
+const (
+	ReasonAPIServerDiscovered string = "APIServerDiscovered"
+	ReasonAPIServerUnknown    string = "APIServerUnknown"
+
+	// InfrastructureName is the name of the cluster wide Infrastructure config of OpenShift.
+	InfrastructureName string = "cluster"
+)
+
+// InfrastructureGVK is the cluster wide config of OpenShift which holds the URL of the API server.
+var InfrastructureGVK = schema.GroupVersionKind{Group: "config.openshift.io", Version: "v1", Kind: "Infrastructure"}
+
+// APIServerProvider discovers the URL of the API server of the cluster, as published to the clients
+// of the cluster, e.g. https://api.example.com:6443.
+type APIServerProvider interface {
+	Name() string
+	APIServerURL() (string, error)
+}
+
+// infrastructureProvider reads the URL of the API server from the Infrastructure config of OpenShift.
+type infrastructureProvider struct {
+	reader client.Reader
+}
+
+func (p infrastructureProvider) Name() string {
+	return "Infrastructure " + InfrastructureName
+}
+
+func (p infrastructureProvider) APIServerURL() (string, error) {
+	infrastructure := &unstructured.Unstructured{}
+	infrastructure.SetGroupVersionKind(InfrastructureGVK)
+	if err := p.reader.Get(context.TODO(), types.NamespacedName{Name: InfrastructureName}, infrastructure); err != nil {
+		return "", err
+	}
+
+	apiServerURL, _, err := unstructured.NestedString(infrastructure.Object, "status", "apiServerURL")
+	if err != nil {
+		return "", err
+	}
+	if len(apiServerURL) == 0 {
+		return "", fmt.Errorf("status.apiServerURL is not set")
+	}
+	return apiServerURL, nil
+}
+
+// consoleConfigProvider reads the URL of the API server from the config of the OpenShift console.
+type consoleConfigProvider struct {
+	reader client.Reader
+}
+
+func (p consoleConfigProvider) Name() string {
+	return "config map " + ConsoleNS + "/" + ConsoleCfg
+}
+
+func (p consoleConfigProvider) APIServerURL() (string, error) {
+	console := &core.ConfigMap{}
+	if err := p.reader.Get(context.TODO(), types.NamespacedName{Name: ConsoleCfg, Namespace: ConsoleNS}, console); err != nil {
+		return "", err
+	}
+	return parseConsoleConfig(console.Data[ConsoleCfgYaml])
+}
+
+// parseConsoleConfig returns the masterPublicURL of the clusterInfo of a console config.
+func parseConsoleConfig(data string) (string, error) {
+	var consoleConfig map[string]interface{}
+	if err := yaml.Unmarshal([]byte(data), &consoleConfig); err != nil {
+		return "", fmt.Errorf("failure parsing %s: %v", ConsoleCfgYaml, err)
+	}
+
+	clusterInfo, ok := consoleConfig[ConsoleClusterInfo].(map[interface{}]interface{})
+	if !ok {
+		return "", fmt.Errorf("%s has no %s", ConsoleCfgYaml, ConsoleClusterInfo)
+	}
+	masterURL, ok := clusterInfo[ConsoleMasterURL].(string)
+	if !ok || len(masterURL) == 0 {
+		return "", fmt.Errorf("%s has no %s.%s", ConsoleCfgYaml, ConsoleClusterInfo, ConsoleMasterURL)
+	}
+	return masterURL, nil
+}
+
+// restConfigProvider uses the host of the rest config of the operator, the in-cluster config when it runs
+// in the cluster. It may be an internal address of the API server.
+type restConfigProvider struct {
+	getConfig func() (*rest.Config, error)
+}
+
+func (p restConfigProvider) Name() string {
+	return "rest config"
+}
+
+func (p restConfigProvider) APIServerURL() (string, error) {
+	cfg, err := p.getConfig()
+	if err != nil {
+		return "", err
+	}
+	if len(cfg.Host) == 0 {
+		return "", fmt.Errorf("the rest config has no host")
+	}
+	return cfg.Host, nil
+}
+
+// defaultAPIServerProviders returns the providers of the API server of OpenShift clusters, in order of preference.
+func defaultAPIServerProviders(reader client.Reader) []APIServerProvider {
+	return []APIServerProvider{
+		infrastructureProvider{reader: reader},
+		consoleConfigProvider{reader: reader},
+		restConfigProvider{getConfig: config.GetConfig},
+	}
+}
+
+// SplitAPIServerURL returns the host and port of the URL of an API server. The scheme is optional,
+// the port defaults to 443.
+func SplitAPIServerURL(apiServerURL string) (string, string, error) {
+	if !strings.Contains(apiServerURL, "://") {
+		apiServerURL = "https://" + apiServerURL
+	}
+	u, err := url.Parse(apiServerURL)
+	if err != nil {
+		return "", "", err
+	}
+	if len(u.Hostname()) == 0 {
+		return "", "", fmt.Errorf("no host in %q", apiServerURL)
+	}
+
+	port := u.Port()
+	if len(port) == 0 {
+		port = "443"
+	}
+	return u.Hostname(), port, nil
+}
+
+// DiscoverAPIServer returns the host and port of the API server from the first of providers which finds it.
+func DiscoverAPIServer(providers []APIServerProvider) (string, string, error) {
+	var errs []string
+	for _, provider := range providers {
+		apiServerURL, err := provider.APIServerURL()
+		if err == nil {
+			var host, port string
+			if host, port, err = SplitAPIServerURL(apiServerURL); err == nil {
+				klog.Infof("Discovered API server %s:%s from %s", host, port, provider.Name())
+				return host, port, nil
+			}
+		}
+		errs = append(errs, fmt.Sprintf("%s: %v", provider.Name(), err))
+	}
+	return "", "", fmt.Errorf("failure discovering the API server: %s", strings.Join(errs, "; "))
+}
+
+// discoverAPIServer returns the host and port of the API server, and reports whether they are found with
+// the APIServerUnknown condition. The cluster info is populated without them rather than failing.
+func (ingressRequest *IngressRequest) discoverAPIServer(providers []APIServerProvider) (string, string, bool) {
+	host, port, err := DiscoverAPIServer(providers)
+	if err != nil {
+		klog.Warningf("Managementingress %s/%s %v", ingressRequest.managementIngress.Namespace, ingressRequest.managementIngress.Name, err)
+		ingressRequest.setCondition(operatorv1alpha1.APIServerPhase, operatorv1alpha1.APIServerUnknown, operatorv1alpha1.ConditionTrue, ReasonAPIServerUnknown, err.Error())
+		return "", "", false
+	}
+
+	ingressRequest.setCondition(operatorv1alpha1.APIServerPhase, operatorv1alpha1.APIServerUnknown, operatorv1alpha1.ConditionFalse, ReasonAPIServerDiscovered,
+		fmt.Sprintf("%s:%s", host, port))
+	return host, port, true
+}
@@ -0,0 +1,127 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package handler
+
+import (
+	"fmt"
+	"testing"
+
+	core "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	operatorv1alpha1 "github.com/IBM/ibm-management-ingress-operator/api/v1alpha1"
+)
+
+type fakeAPIServerProvider struct {
+	apiServerURL string
+	err          error
+}
+
+func (p fakeAPIServerProvider) Name() string {
+	return "fake"
+}
+
+func (p fakeAPIServerProvider) APIServerURL() (string, error) {
+	return p.apiServerURL, p.err
+}
+
+func TestSplitAPIServerURL(t *testing.T) {
+	for apiServerURL, want := range map[string][2]string{
+		"https://api.example.com:6443": {"api.example.com", "6443"},
+		"https://api.example.com":      {"api.example.com", "443"},
+		"api.example.com:6443":         {"api.example.com", "6443"},
+		"https://[fd00::1]:6443":       {"fd00::1", "6443"},
+	} {
+		host, port, err := SplitAPIServerURL(apiServerURL)
+		if err != nil || host != want[0] || port != want[1] {
+			t.Errorf("%s: expected %v, got %s, %s, %v", apiServerURL, want, host, port, err)
+		}
+	}
+
+	if _, _, err := SplitAPIServerURL("https://:6443"); err == nil {
+		t.Error("expected an error without host")
+	}
+}
+
+func TestParseConsoleConfig(t *testing.T) {
+	masterURL, err := parseConsoleConfig("clusterInfo:\n  masterPublicURL: https://api.example.com:6443\n")
+	if err != nil || masterURL != "https://api.example.com:6443" {
+		t.Errorf("expected the master URL, got %s, %v", masterURL, err)
+	}
+
+	for _, data := range []string{
+		"",
+		"clusterInfo: example",
+		"clusterInfo:\n  consoleBaseAddress: https://console.example.com\n",
+		"clusterInfo:\n  masterPublicURL: 6443\n",
+		"clusterInfo: [",
+	} {
+		if _, err := parseConsoleConfig(data); err == nil {
+			t.Errorf("expected an error for %q", data)
+		}
+	}
+}
+
+func TestConsoleConfigProvider(t *testing.T) {
+	s := runtime.NewScheme()
+	if err := core.AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+	console := &core.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: ConsoleCfg, Namespace: ConsoleNS},
+		Data:       map[string]string{ConsoleCfgYaml: "clusterInfo:\n  masterPublicURL: https://api.example.com:6443\n"},
+	}
+
+	apiServerURL, err := consoleConfigProvider{reader: fake.NewFakeClientWithScheme(s, console)}.APIServerURL()
+	if err != nil || apiServerURL != "https://api.example.com:6443" {
+		t.Errorf("expected the URL of the console config, got %s, %v", apiServerURL, err)
+	}
+	if _, err := (consoleConfigProvider{reader: fake.NewFakeClientWithScheme(s)}).APIServerURL(); err == nil {
+		t.Error("expected an error without console config")
+	}
+}
+
+func TestDiscoverAPIServer(t *testing.T) {
+	providers := []APIServerProvider{
+		fakeAPIServerProvider{err: fmt.Errorf("not found")},
+		fakeAPIServerProvider{apiServerURL: "https://"},
+		fakeAPIServerProvider{apiServerURL: "https://api.example.com:6443"},
+		fakeAPIServerProvider{apiServerURL: "https://kubernetes.default.svc:443"},
+	}
+	host, port, err := DiscoverAPIServer(providers)
+	if err != nil || host != "api.example.com" || port != "6443" {
+		t.Errorf("expected the API server of the first provider which finds it, got %s, %s, %v", host, port, err)
+	}
+
+	ingressRequest := newFakeIngressRequest()
+	if _, _, found := ingressRequest.discoverAPIServer(providers[:2]); found {
+		t.Error("expected the API server not to be found")
+	}
+	conditions := ingressRequest.managementIngress.Status.Conditions[operatorv1alpha1.APIServerPhase]
+	if len(conditions) != 1 || conditions[0].Type != operatorv1alpha1.APIServerUnknown || conditions[0].Status != operatorv1alpha1.ConditionTrue {
+		t.Errorf("expected the APIServerUnknown condition, got %v", conditions)
+	}
+
+	if _, _, found := ingressRequest.discoverAPIServer(providers); !found {
+		t.Error("expected the API server to be found")
+	}
+	conditions = ingressRequest.managementIngress.Status.Conditions[operatorv1alpha1.APIServerPhase]
+	if len(conditions) != 1 || conditions[0].Status != operatorv1alpha1.ConditionFalse || conditions[0].Message != "api.example.com:6443" {
+		t.Errorf("expected the APIServerUnknown condition to be cleared, got %v", conditions)
+	}
+}
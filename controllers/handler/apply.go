@@ -0,0 +1,203 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package handler
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/IBM/ibm-management-ingress-operator/utils"
+)
+
+// FieldManager is the field manager of the operator for server-side apply.
+const FieldManager = "ibm-management-ingress-operator"
+
+// Apply creates or updates obj with server-side apply. Fields which are not set in obj and are set
+// by other controllers, e.g. the replicas of an autoscaler or the containers injected by a service
+// mesh, are kept. Fields of obj which another field manager owns are always taken over: the apply is
+// forced, and the ApplyConflict Warning event is the only signal of the conflict.
+// The object is labeled with the instance of the CR.
+//
+// The object is not applied when it did not drift from the desired state, see isApplied.
+func (ingressRequest *IngressRequest) Apply(obj runtime.Object) (controllerutil.OperationResult, error) {
+	gvk, err := apiutil.GVKForObject(obj, ingressRequest.scheme)
+	if err != nil {
+		return controllerutil.OperationResultNone, fmt.Errorf("failure getting kind of object: %v", err)
+	}
+	// The apply patch is the object itself, it needs its kind and no resourceVersion or managedFields.
+	obj.GetObjectKind().SetGroupVersionKind(gvk)
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return controllerutil.OperationResultNone, fmt.Errorf("failure accessing %s metadata: %v", gvk.Kind, err)
+	}
+	accessor.SetResourceVersion("")
+	accessor.SetManagedFields(nil)
+	name := accessor.GetName()
+
+	if err := controllerutil.SetControllerReference(ingressRequest.managementIngress, accessor, ingressRequest.scheme); err != nil {
+		klog.Errorf("Error setting controller reference on %s: %v", gvk.Kind, err)
+	}
+	// The objects of the CR are told apart from the objects of the other instances in the namespace.
+	accessor.SetLabels(utils.AppendLabels(nonNilMap(accessor.GetLabels()), map[string]string{InstanceLabelKey: ingressRequest.names().Service}))
+
+	desired, err := desiredState(obj)
+	if err != nil {
+		return controllerutil.OperationResultNone, fmt.Errorf("failure hashing %s %s: %v", gvk.Kind, name, err)
+	}
+
+	current, err := ingressRequest.getCurrentState(gvk, name, accessor.GetNamespace())
+	if err != nil {
+		return controllerutil.OperationResultNone, err
+	}
+	if current != nil && isApplied(current, desired) {
+		klog.V(4).Infof("No change found from %s: %s, skip applying it.", gvk.Kind, name)
+		return controllerutil.OperationResultNone, nil
+	}
+
+	klog.V(4).Infof("Applying object: %v", obj)
+	err = ingressRequest.client.Patch(context.TODO(), obj, client.Apply, client.FieldOwner(FieldManager))
+	if errors.IsConflict(err) {
+		klog.Warningf("Taking over conflicting fields of %s %s: %v", gvk.Kind, name, err)
+		ingressRequest.recorder.Eventf(ingressRequest.managementIngress, "Warning", "ApplyConflict", "Taking over fields of %s %q managed by another controller: %v", gvk.Kind, name, err)
+		err = ingressRequest.client.Patch(context.TODO(), obj, client.Apply, client.FieldOwner(FieldManager), client.ForceOwnership)
+	}
+	if err != nil {
+		ingressRequest.recorder.Eventf(ingressRequest.managementIngress, "Warning", "Applied"+gvk.Kind, "Failed to apply %s %q", gvk.Kind, name)
+		return controllerutil.OperationResultNone, fmt.Errorf("failure applying %s %s: %v", gvk.Kind, name, err)
+	}
+
+	switch {
+	case current == nil:
+		klog.Infof("Created %s: %s.", gvk.Kind, name)
+		ingressRequest.recorder.Eventf(ingressRequest.managementIngress, "Normal", "Created"+gvk.Kind, "Successfully created %s %q", gvk.Kind, name)
+		return controllerutil.OperationResultCreated, nil
+	case current.GetResourceVersion() == accessor.GetResourceVersion():
+		klog.V(4).Infof("No change found from %s: %s.", gvk.Kind, name)
+		return controllerutil.OperationResultNone, nil
+	default:
+		klog.Infof("Updated %s: %s.", gvk.Kind, name)
+		ingressRequest.recorder.Eventf(ingressRequest.managementIngress, "Normal", "Updated"+gvk.Kind, "Successfully updated %s %q", gvk.Kind, name)
+		return controllerutil.OperationResultUpdated, nil
+	}
+}
+
+// desiredState returns the fields of obj which the operator applies, and annotates obj with their hash.
+// The status and the metadata set by the API server are not part of the desired state.
+func desiredState(obj runtime.Object) (*unstructured.Unstructured, error) {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+	desired := &unstructured.Unstructured{Object: content}
+	delete(desired.Object, "status")
+
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return nil, err
+	}
+	metadata := map[string]interface{}{}
+	for _, key := range []string{"name", "namespace", "labels", "annotations", "ownerReferences"} {
+		if value, found := desired.Object["metadata"].(map[string]interface{})[key]; found {
+			metadata[key] = value
+		}
+	}
+	desired.Object["metadata"] = metadata
+
+	annotations := desired.GetAnnotations()
+	delete(annotations, SpecHashAnnotationKey)
+	desired.SetAnnotations(annotations)
+	data, err := json.Marshal(desired.Object)
+	if err != nil {
+		return nil, err
+	}
+	hash := fmt.Sprintf("%x", sha256.Sum256(data))
+
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[SpecHashAnnotationKey] = hash
+	desired.SetAnnotations(annotations)
+	accessor.SetAnnotations(utils.AppendAnnotations(nonNilMap(accessor.GetAnnotations()), map[string]string{SpecHashAnnotationKey: hash}))
+	return desired, nil
+}
+
+// isApplied reports whether the current object is in the desired state. The spec hash annotation
+// changes with the desired state, so fields which the operator no longer sets are removed by the next
+// apply. Fields of the desired state which were edited out of band are found by comparing them with the
+// current object, fields which are only set in the current object, e.g. defaulted by the API server or
+// set by other controllers, are ignored.
+func isApplied(current, desired *unstructured.Unstructured) bool {
+	if current.GetAnnotations()[SpecHashAnnotationKey] != desired.GetAnnotations()[SpecHashAnnotationKey] {
+		return false
+	}
+	if !utils.ContainsFields(current.Object, desired.Object) {
+		klog.Infof("Found drift of %s: %s from the desired state, trying to revert it.", current.GetKind(), current.GetName())
+		return false
+	}
+	return true
+}
+
+// getCurrentState returns the current object, nil when it does not exist.
+func (ingressRequest *IngressRequest) getCurrentState(gvk schema.GroupVersionKind, name, namespace string) (*unstructured.Unstructured, error) {
+	current := &unstructured.Unstructured{}
+	current.SetGroupVersionKind(gvk)
+
+	var obj runtime.Object = current
+	// Typed objects are read from the cache of the client.
+	if typed, err := ingressRequest.scheme.New(gvk); err == nil {
+		obj = typed
+	}
+
+	err := ingressRequest.Get(name, namespace, obj)
+	if meta.IsNoMatchError(err) {
+		return nil, fmt.Errorf("failure applying %s %s, %s is not served on this cluster: %v", gvk.Kind, name, gvk.GroupVersion(), err)
+	}
+	if errors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failure getting %s %s: %v", gvk.Kind, name, err)
+	}
+
+	if obj != current {
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+		if err != nil {
+			return nil, fmt.Errorf("failure converting %s %s: %v", gvk.Kind, name, err)
+		}
+		current.Object = content
+		current.SetGroupVersionKind(gvk)
+	}
+	return current, nil
+}
+
+func nonNilMap(m map[string]string) map[string]string {
+	if m == nil {
+		return map[string]string{}
+	}
+	return m
+}
@@ -0,0 +1,152 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package handler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// applyClient adds server-side apply to the fake client, which does not support it. The apply patch
+// is merged into the current object. The first conflicts applies without force fail with a conflict.
+type applyClient struct {
+	client.Client
+	conflicts int
+}
+
+func (c *applyClient) Patch(ctx context.Context, obj runtime.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if patch.Type() != types.ApplyPatchType {
+		return c.Client.Patch(ctx, obj, patch, opts...)
+	}
+	patchOptions := &client.PatchOptions{}
+	patchOptions.ApplyOptions(opts)
+	if patchOptions.FieldManager != FieldManager {
+		return fmt.Errorf("unexpected field manager %q", patchOptions.FieldManager)
+	}
+
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return err
+	}
+	if c.conflicts > 0 && (patchOptions.Force == nil || !*patchOptions.Force) {
+		c.conflicts--
+		return errors.NewConflict(schema.GroupResource{}, accessor.GetName(), fmt.Errorf("conflict with \"kubectl\""))
+	}
+
+	data, err := patch.Data(obj)
+	if err != nil {
+		return err
+	}
+	current := obj.DeepCopyObject()
+	err = c.Client.Get(ctx, types.NamespacedName{Name: accessor.GetName(), Namespace: accessor.GetNamespace()}, current)
+	if errors.IsNotFound(err) {
+		return c.Client.Create(ctx, obj)
+	}
+	if err != nil {
+		return err
+	}
+	return c.Client.Patch(ctx, obj, client.RawPatch(types.MergePatchType, data))
+}
+
+func TestApply(t *testing.T) {
+	ingressRequest := newFakeIngressRequest()
+	recorder := ingressRequest.recorder.(*record.FakeRecorder)
+
+	result, err := ingressRequest.Apply(NewConfigMap(ConfigName, "ibm-common-services", map[string]string{"a": "1"}))
+	if err != nil || result != controllerutil.OperationResultCreated {
+		t.Fatalf("expected configmap to be created, got %v, %v", result, err)
+	}
+	if event := <-recorder.Events; !strings.Contains(event, "CreatedConfigMap") {
+		t.Errorf("unexpected event %q", event)
+	}
+
+	current := &core.ConfigMap{}
+	if err := ingressRequest.Get(ConfigName, "ibm-common-services", current); err != nil {
+		t.Fatalf("failure getting configmap: %v", err)
+	}
+	if len(current.OwnerReferences) != 1 || current.OwnerReferences[0].Name != "default" {
+		t.Errorf("expected the managementingress to own the configmap, got %v", current.OwnerReferences)
+	}
+
+	// A field manager owning one of the fields is reported, then the fields are taken over.
+	ingressRequest.client.(*applyClient).conflicts = 1
+	result, err = ingressRequest.Apply(NewConfigMap(ConfigName, "ibm-common-services", map[string]string{"a": "2"}))
+	if err != nil || result != controllerutil.OperationResultUpdated {
+		t.Fatalf("expected configmap to be updated, got %v, %v", result, err)
+	}
+	if event := <-recorder.Events; !strings.Contains(event, "ApplyConflict") {
+		t.Errorf("expected a conflict event, got %q", event)
+	}
+	if err := ingressRequest.Get(ConfigName, "ibm-common-services", current); err != nil {
+		t.Fatalf("failure getting configmap: %v", err)
+	}
+	if current.Data["a"] != "2" {
+		t.Errorf("expected the conflicting field to be applied, got %v", current.Data)
+	}
+}
+
+func TestApplyDrift(t *testing.T) {
+	ingressRequest := newFakeIngressRequest()
+	desired := func() *core.ConfigMap {
+		return NewConfigMap(ConfigName, "ibm-common-services", map[string]string{"a": "1"})
+	}
+
+	if _, err := ingressRequest.Apply(desired()); err != nil {
+		t.Fatalf("Apply returned unexpected error: %v", err)
+	}
+	current := &core.ConfigMap{}
+	if err := ingressRequest.Get(ConfigName, "ibm-common-services", current); err != nil {
+		t.Fatalf("failure getting configmap: %v", err)
+	}
+	if len(current.Annotations[SpecHashAnnotationKey]) == 0 {
+		t.Errorf("expected the spec hash annotation, got %v", current.Annotations)
+	}
+
+	// Fields set by others are not drift.
+	current.Labels["other"] = "controller"
+	if err := ingressRequest.Update(current); err != nil {
+		t.Fatalf("failure updating configmap: %v", err)
+	}
+	if result, err := ingressRequest.Apply(desired()); err != nil || result != controllerutil.OperationResultNone {
+		t.Errorf("expected no change, got %v, %v", result, err)
+	}
+
+	current.Data["a"] = "edited"
+	if err := ingressRequest.Update(current); err != nil {
+		t.Fatalf("failure updating configmap: %v", err)
+	}
+	if result, err := ingressRequest.Apply(desired()); err != nil || result != controllerutil.OperationResultUpdated {
+		t.Errorf("expected the drift to be reverted, got %v, %v", result, err)
+	}
+	if err := ingressRequest.Get(ConfigName, "ibm-common-services", current); err != nil {
+		t.Fatalf("failure getting configmap: %v", err)
+	}
+	if current.Data["a"] != "1" {
+		t.Errorf("expected the edited field to be reverted, got %v", current.Data)
+	}
+}
@@ -0,0 +1,154 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package handler
+
+import (
+	"fmt"
+
+	apps "k8s.io/api/apps/v1"
+	autoscaling "k8s.io/api/autoscaling/v2beta2"
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog"
+
+	operatorv1alpha1 "github.com/IBM/ibm-management-ingress-operator/api/v1alpha1"
+)
+
+var (
+	HorizontalPodAutoscalerV2GVK      = schema.GroupVersionKind{Group: "autoscaling", Version: "v2", Kind: "HorizontalPodAutoscaler"}
+	HorizontalPodAutoscalerV2Beta2GVK = autoscaling.SchemeGroupVersion.WithKind("HorizontalPodAutoscaler")
+)
+
+// DiscoverAutoscalerGVK returns the HorizontalPodAutoscaler API served by the cluster, preferring
+// autoscaling/v2. Both versions have the same schema for the fields set by the operator.
+func DiscoverAutoscalerGVK(mapper meta.RESTMapper) schema.GroupVersionKind {
+	return discoverGVK(mapper, HorizontalPodAutoscalerV2GVK, HorizontalPodAutoscalerV2Beta2GVK)
+}
+
+// discoverGVK returns the first of gvks which is served by the cluster, the last one when none is.
+func discoverGVK(mapper meta.RESTMapper, gvks ...schema.GroupVersionKind) schema.GroupVersionKind {
+	for _, gvk := range gvks {
+		if _, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version); err == nil {
+			return gvk
+		} else if !meta.IsNoMatchError(err) {
+			klog.Errorf("Failure discovering %s: %v", gvk.GroupVersion(), err)
+		}
+	}
+
+	fallback := gvks[len(gvks)-1]
+	klog.Errorf("No %s API found, using %s", fallback.Kind, fallback.GroupVersion())
+	return fallback
+}
+
+// autoscalingEnabled reports whether a HorizontalPodAutoscaler owns the replicas of the deployment.
+func autoscalingEnabled(instance *operatorv1alpha1.ManagementIngress) bool {
+	return instance.Spec.Autoscaling != nil && instance.Spec.Autoscaling.Enabled
+}
+
+// NewHorizontalPodAutoscaler stubs an instance of a HorizontalPodAutoscaler of the management ingress
+// deployment name in the autoscaling API of gvk. The autoscaler has the name of the deployment.
+func NewHorizontalPodAutoscaler(gvk schema.GroupVersionKind, name, namespace string, settings *operatorv1alpha1.Autoscaling) (*unstructured.Unstructured, error) {
+	// Defaulted in case the defaulting webhook is not enabled.
+	settings = settings.DeepCopy()
+	settings.Default()
+
+	maxReplicas := settings.MaxReplicas
+	if maxReplicas < *settings.MinReplicas {
+		klog.Warningf("Using minReplicas %d as maxReplicas of HorizontalPodAutoscaler %s, maxReplicas %d is lower", *settings.MinReplicas, name, maxReplicas)
+		maxReplicas = *settings.MinReplicas
+	}
+
+	var metrics []autoscaling.MetricSpec
+	for _, target := range []struct {
+		resource    core.ResourceName
+		utilization *int32
+	}{
+		{core.ResourceCPU, settings.TargetCPUUtilizationPercentage},
+		{core.ResourceMemory, settings.TargetMemoryUtilizationPercentage},
+	} {
+		if target.utilization == nil {
+			continue
+		}
+		metrics = append(metrics, autoscaling.MetricSpec{
+			Type: autoscaling.ResourceMetricSourceType,
+			Resource: &autoscaling.ResourceMetricSource{
+				Name: target.resource,
+				Target: autoscaling.MetricTarget{
+					Type:               autoscaling.UtilizationMetricType,
+					AverageUtilization: target.utilization,
+				},
+			},
+		})
+	}
+
+	hpa := &autoscaling.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    GetCommonLabels(),
+		},
+		Spec: autoscaling.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscaling.CrossVersionObjectReference{
+				APIVersion: apps.SchemeGroupVersion.String(),
+				Kind:       "Deployment",
+				Name:       name,
+			},
+			MinReplicas: settings.MinReplicas,
+			MaxReplicas: maxReplicas,
+			Metrics:     metrics,
+			Behavior:    settings.Behavior,
+		},
+	}
+
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(hpa)
+	if err != nil {
+		return nil, err
+	}
+	obj := &unstructured.Unstructured{Object: content}
+	obj.SetGroupVersionKind(gvk)
+	delete(obj.Object, "status")
+	return obj, nil
+}
+
+// CreateOrUpdateAutoscaler applies the HorizontalPodAutoscaler of the management ingress deployment
+// when autoscaling is enabled, and removes it otherwise.
+func (ingressRequest *IngressRequest) CreateOrUpdateAutoscaler() error {
+	instance := ingressRequest.managementIngress
+	gvk := ingressRequest.getAutoscalerGVK()
+	name := ingressRequest.names().Deployment
+
+	if !autoscalingEnabled(instance) {
+		err := ingressRequest.Delete(newUnstructured(gvk, name, instance.Namespace, nil, nil))
+		if err != nil && !errors.IsNotFound(err) && !meta.IsNoMatchError(err) {
+			return fmt.Errorf("failure removing HorizontalPodAutoscaler %s: %v", name, err)
+		}
+		return nil
+	}
+
+	hpa, err := NewHorizontalPodAutoscaler(gvk, name, instance.Namespace, instance.Spec.Autoscaling)
+	if err != nil {
+		return fmt.Errorf("failure creating HorizontalPodAutoscaler %s: %v", name, err)
+	}
+	if _, err := ingressRequest.Apply(hpa); err != nil {
+		return fmt.Errorf("failure applying HorizontalPodAutoscaler for %q: %v", instance.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package handler
+
+import (
+	"testing"
+
+	autoscaling "k8s.io/api/autoscaling/v2beta2"
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+
+	operatorv1alpha1 "github.com/IBM/ibm-management-ingress-operator/api/v1alpha1"
+)
+
+func TestCreateOrUpdateAutoscaler(t *testing.T) {
+	ingressRequest := newFakeIngressRequest()
+	memory := int32(70)
+	ingressRequest.managementIngress.Spec.Autoscaling = &operatorv1alpha1.Autoscaling{
+		Enabled:                           true,
+		MaxReplicas:                       5,
+		TargetMemoryUtilizationPercentage: &memory,
+	}
+
+	if err := ingressRequest.CreateOrUpdateAutoscaler(); err != nil {
+		t.Fatalf("CreateOrUpdateAutoscaler returned unexpected error: %v", err)
+	}
+	hpa := &autoscaling.HorizontalPodAutoscaler{}
+	if err := ingressRequest.Get(AppName, "ibm-common-services", hpa); err != nil {
+		t.Fatalf("failure getting HorizontalPodAutoscaler: %v", err)
+	}
+	if hpa.Spec.ScaleTargetRef.Kind != "Deployment" || hpa.Spec.ScaleTargetRef.Name != AppName {
+		t.Errorf("expected the management ingress deployment to be scaled, got %v", hpa.Spec.ScaleTargetRef)
+	}
+	if *hpa.Spec.MinReplicas != operatorv1alpha1.DefaultReplicas || hpa.Spec.MaxReplicas != 5 {
+		t.Errorf("expected 1 to 5 replicas, got %d to %d", *hpa.Spec.MinReplicas, hpa.Spec.MaxReplicas)
+	}
+	// A memory target replaces the default CPU target.
+	if len(hpa.Spec.Metrics) != 1 || hpa.Spec.Metrics[0].Resource.Name != core.ResourceMemory {
+		t.Errorf("expected only the memory target, got %v", hpa.Spec.Metrics)
+	}
+
+	ingressRequest.managementIngress.Spec.Autoscaling.Enabled = false
+	if err := ingressRequest.CreateOrUpdateAutoscaler(); err != nil {
+		t.Fatalf("CreateOrUpdateAutoscaler returned unexpected error: %v", err)
+	}
+	if err := ingressRequest.Get(AppName, "ibm-common-services", hpa); !errors.IsNotFound(err) {
+		t.Errorf("expected the HorizontalPodAutoscaler to be removed, got %v", err)
+	}
+	// Nothing to remove.
+	if err := ingressRequest.CreateOrUpdateAutoscaler(); err != nil {
+		t.Errorf("CreateOrUpdateAutoscaler returned unexpected error: %v", err)
+	}
+}
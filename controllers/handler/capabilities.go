@@ -0,0 +1,109 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package handler
+
+import (
+	"context"
+	"fmt"
+
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/discovery"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// OpenShift is the cluster type of OpenShift clusters, any type other than CNCF is handled the same.
+	OpenShift string = "ocp"
+
+	RouteGroup        string = "route.openshift.io"
+	SecurityGroup     string = "security.openshift.io"
+	OperatorGroup     string = "operator.openshift.io"
+	CertManagerGroup  string = "cert-manager.io"
+	LegacyCertManager string = "certmanager.k8s.io"
+	GatewayGroup      string = "gateway.networking.k8s.io"
+
+	// The default IngressController and DNS of OpenShift host the routes and the services of the cluster.
+	IngressControllerNamespace string = "openshift-ingress-operator"
+	DefaultOpenShiftConfigName string = "default"
+)
+
+// Capabilities are the optional APIs served by the cluster, and the flavor of the cluster derived from them.
+type Capabilities struct {
+	// ClusterType is OpenShift when the cluster serves routes and the OpenShift operator APIs, CNCF otherwise.
+	// ibm-cpp-config overrides it, see ApplyCppConfig.
+	ClusterType string
+	// DomainName is the domain of the console on CNCF clusters, only set in ibm-cpp-config.
+	DomainName string
+
+	Routes                     bool
+	SecurityContextConstraints bool
+	OpenShiftOperators         bool
+	CertManager                bool
+	GatewayAPI                 bool
+}
+
+// DiscoverCapabilities returns the capabilities of the cluster serving groups.
+func DiscoverCapabilities(groups discovery.ServerGroupsInterface) (Capabilities, error) {
+	groupList, err := groups.ServerGroups()
+	if err != nil {
+		return Capabilities{}, fmt.Errorf("failure discovering API groups: %v", err)
+	}
+
+	served := map[string]bool{}
+	for _, group := range groupList.Groups {
+		served[group.Name] = true
+	}
+
+	capabilities := Capabilities{
+		ClusterType:                CNCF,
+		Routes:                     served[RouteGroup],
+		SecurityContextConstraints: served[SecurityGroup],
+		OpenShiftOperators:         served[OperatorGroup],
+		CertManager:                served[CertManagerGroup] || served[LegacyCertManager],
+		GatewayAPI:                 served[GatewayGroup],
+	}
+	// The routes of OpenShift clusters are hosted in the domain of the default IngressController.
+	if capabilities.Routes && capabilities.OpenShiftOperators {
+		capabilities.ClusterType = OpenShift
+	}
+	return capabilities, nil
+}
+
+// ApplyCppConfig returns the cluster type and domain name set in cppConfig, which may be nil. The cluster type
+// defaults to clusterType.
+func ApplyCppConfig(clusterType string, cppConfig *core.ConfigMap) (string, string) {
+	if cppConfig == nil {
+		return clusterType, ""
+	}
+	if overridden := cppConfig.Data[KubernetesClusterType]; len(overridden) > 0 {
+		clusterType = overridden
+	}
+	return clusterType, cppConfig.Data[CppConfigDomainName]
+}
+
+// GetCppConfig returns the optional ibm-cpp-config config map of the namespace, nil when it does not exist.
+func GetCppConfig(reader client.Reader, namespace string) (*core.ConfigMap, error) {
+	cppConfig := &core.ConfigMap{}
+	if err := reader.Get(context.TODO(), types.NamespacedName{Name: CppConfigName, Namespace: namespace}, cppConfig); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failure getting %s config map in namespace %s: %v", CppConfigName, namespace, err)
+	}
+	return cppConfig, nil
+}
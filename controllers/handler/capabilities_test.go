@@ -0,0 +1,104 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package handler
+
+import (
+	"testing"
+
+	core "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+type fakeServerGroups []string
+
+func (groups fakeServerGroups) ServerGroups() (*metav1.APIGroupList, error) {
+	groupList := &metav1.APIGroupList{}
+	for _, group := range groups {
+		groupList.Groups = append(groupList.Groups, metav1.APIGroup{Name: group})
+	}
+	return groupList, nil
+}
+
+func TestDiscoverCapabilities(t *testing.T) {
+	openShift := fakeServerGroups{"apps", RouteGroup, SecurityGroup, OperatorGroup, CertManagerGroup}
+	cncf := fakeServerGroups{"apps", LegacyCertManager, GatewayGroup}
+
+	for _, tc := range []struct {
+		name        string
+		groups      fakeServerGroups
+		clusterType string
+	}{
+		{"openshift", openShift, OpenShift},
+		{"cncf", cncf, CNCF},
+		{"routes without OpenShift operators", fakeServerGroups{RouteGroup, CertManagerGroup}, CNCF},
+	} {
+		capabilities, err := DiscoverCapabilities(tc.groups)
+		if err != nil {
+			t.Fatalf("%s: DiscoverCapabilities returned unexpected error: %v", tc.name, err)
+		}
+		if capabilities.ClusterType != tc.clusterType || len(capabilities.DomainName) > 0 {
+			t.Errorf("%s: expected cluster type %s without domain, got %+v", tc.name, tc.clusterType, capabilities)
+		}
+		if !capabilities.CertManager {
+			t.Errorf("%s: expected cert-manager to be discovered, got %+v", tc.name, capabilities)
+		}
+	}
+
+	capabilities, _ := DiscoverCapabilities(cncf)
+	if !capabilities.GatewayAPI || capabilities.Routes || capabilities.SecurityContextConstraints {
+		t.Errorf("expected only the Gateway API, got %+v", capabilities)
+	}
+}
+
+func TestGetCppConfig(t *testing.T) {
+	s := runtime.NewScheme()
+	if err := core.AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+	cppConfig, err := GetCppConfig(fake.NewFakeClientWithScheme(s), "ibm-common-services")
+	if err != nil || cppConfig != nil {
+		t.Errorf("expected no config map without error, got %v, %v", cppConfig, err)
+	}
+
+	existing := &core.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: CppConfigName, Namespace: "ibm-common-services"},
+		Data:       map[string]string{KubernetesClusterType: CNCF},
+	}
+	cppConfig, err = GetCppConfig(fake.NewFakeClientWithScheme(s, existing), "ibm-common-services")
+	if err != nil || cppConfig == nil || cppConfig.Data[KubernetesClusterType] != CNCF {
+		t.Errorf("expected the %s config map, got %v, %v", CppConfigName, cppConfig, err)
+	}
+}
+
+func TestApplyCppConfig(t *testing.T) {
+	if clusterType, domainName := ApplyCppConfig(OpenShift, nil); clusterType != OpenShift || domainName != "" {
+		t.Errorf("expected the discovered cluster type without config map, got %s, %q", clusterType, domainName)
+	}
+
+	cppConfig := &core.ConfigMap{Data: map[string]string{CppConfigDomainName: "example.com"}}
+	if clusterType, domainName := ApplyCppConfig(CNCF, cppConfig); clusterType != CNCF || domainName != "example.com" {
+		t.Errorf("expected the discovered cluster type and the domain of the config map, got %s, %q", clusterType, domainName)
+	}
+
+	// A changed domain is applied to the same discovered cluster type.
+	cppConfig.Data[CppConfigDomainName] = "example.org"
+	cppConfig.Data[KubernetesClusterType] = OpenShift
+	if clusterType, domainName := ApplyCppConfig(CNCF, cppConfig); clusterType != OpenShift || domainName != "example.org" {
+		t.Errorf("expected the cluster type and domain of the config map, got %s, %q", clusterType, domainName)
+	}
+}
@@ -17,22 +17,48 @@ package handler
 
 import (
 	"fmt"
-	"reflect"
 	"strings"
 	"time"
 
 	certmanager "github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
-	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/klog"
-	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
 	operatorv1alpha1 "github.com/IBM/ibm-management-ingress-operator/api/v1alpha1"
 )
 
-//NewCertificate stubs an instance of Certificate
-func NewCertificate(name, namespace, secret string, hosts, ips []string, issuer *operatorv1alpha1.CertIssuer) *certmanager.Certificate {
+var (
+	// CertificateV1GVK is served by cert-manager v0.11 and later.
+	CertificateV1GVK = schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "Certificate"}
+	// CertificateV1Alpha1GVK is the legacy API served by cert-manager v0.10 and earlier.
+	CertificateV1Alpha1GVK = certmanager.SchemeGroupVersion.WithKind("Certificate")
+)
+
+// DiscoverCertificateGVK returns the cert-manager Certificate API served by the cluster, preferring
+// cert-manager.io/v1. It falls back to certmanager.k8s.io/v1alpha1 when neither API is served.
+func DiscoverCertificateGVK(mapper meta.RESTMapper) schema.GroupVersionKind {
+	for _, gvk := range []schema.GroupVersionKind{CertificateV1GVK, CertificateV1Alpha1GVK} {
+		if _, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version); err == nil {
+			return gvk
+		} else if !meta.IsNoMatchError(err) {
+			klog.Errorf("Failure discovering %s: %v", gvk.GroupVersion(), err)
+		}
+	}
+
+	klog.Errorf("No cert-manager Certificate API found, using %s", CertificateV1Alpha1GVK.GroupVersion())
+	return CertificateV1Alpha1GVK
+}
+
+// NewCertificate stubs an instance of Certificate in the cert-manager API of gvk. The lifetime,
+// common name and private key are taken from settings, which may be nil for the defaults.
+func NewCertificate(gvk schema.GroupVersionKind, name, namespace, secret string, hosts, ips []string, issuer *operatorv1alpha1.CertIssuer, settings *operatorv1alpha1.Cert) (*unstructured.Unstructured, error) {
+	if gvk == CertificateV1GVK {
+		return newCertificateV1(name, namespace, secret, hosts, ips, issuer, settings), nil
+	}
 
 	labels := GetCommonLabels()
 
@@ -47,9 +73,9 @@ func NewCertificate(name, namespace, secret string, hosts, ips []string, issuer
 			Labels:    labels,
 		},
 		Spec: certmanager.CertificateSpec{
-			CommonName:  AppName,
-			Duration:    &metav1.Duration{Duration: 8760 * time.Hour},
-			RenewBefore: &metav1.Duration{Duration: 24 * time.Hour},
+			CommonName:  certCommonName(settings),
+			Duration:    &metav1.Duration{Duration: certDuration(settings)},
+			RenewBefore: &metav1.Duration{Duration: certRenewBefore(settings)},
 			SecretName:  secret,
 			IssuerRef: certmanager.ObjectReference{
 				Kind: string(issuer.Kind),
@@ -64,7 +90,100 @@ func NewCertificate(name, namespace, secret string, hosts, ips []string, issuer
 		certificate.Spec.IPAddresses = ips
 	}
 
-	return certificate
+	if !operatorv1alpha1.IsCertManagerGroup(issuer.Group) {
+		certificate.Spec.IssuerRef.Group = issuer.Group
+	}
+
+	if settings != nil && settings.PrivateKey != nil {
+		certificate.Spec.KeyAlgorithm = certmanager.KeyAlgorithm(strings.ToLower(string(settings.PrivateKey.Algorithm)))
+		certificate.Spec.KeySize = settings.PrivateKey.Size
+		if settings.PrivateKey.RotationPolicy == operatorv1alpha1.RotationPolicyAlways {
+			klog.Infof("Private key rotation policy %s of certificate: %s is not supported by %s, the private key is kept.",
+				operatorv1alpha1.RotationPolicyAlways, name, CertificateV1Alpha1GVK.GroupVersion())
+		}
+	}
+
+	obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(certificate)
+	if err != nil {
+		return nil, fmt.Errorf("failure converting certificate %s: %v", name, err)
+	}
+	cert := &unstructured.Unstructured{Object: obj}
+	// Only the spec is compared on update, the empty status would never match.
+	delete(cert.Object, "status")
+
+	return cert, nil
+}
+
+// newCertificateV1 stubs a cert-manager.io/v1 Certificate. Its secret gets the common labels,
+// so that it is visible to the filtered cache of the operator.
+func newCertificateV1(name, namespace, secret string, hosts, ips []string, issuer *operatorv1alpha1.CertIssuer, settings *operatorv1alpha1.Cert) *unstructured.Unstructured {
+	spec := map[string]interface{}{
+		"commonName":  certCommonName(settings),
+		"duration":    certDuration(settings).String(),
+		"renewBefore": certRenewBefore(settings).String(),
+		"secretName":  secret,
+		"secretTemplate": map[string]interface{}{
+			"labels": toStringInterfaceMap(GetCommonLabels()),
+		},
+		"issuerRef": map[string]interface{}{
+			"group": issuerGroup(issuer, CertificateV1GVK),
+			"kind":  string(issuer.Kind),
+			"name":  issuer.Name,
+		},
+		"dnsNames": toInterfaceSlice(hosts),
+		"usages":   []interface{}{"digital signature", "key encipherment", "server auth"},
+	}
+
+	if len(ips) > 0 {
+		spec["ipAddresses"] = toInterfaceSlice(ips)
+	}
+
+	if settings != nil && settings.PrivateKey != nil {
+		privateKey := map[string]interface{}{}
+		if len(settings.PrivateKey.Algorithm) > 0 {
+			privateKey["algorithm"] = string(settings.PrivateKey.Algorithm)
+		}
+		if settings.PrivateKey.Size > 0 {
+			privateKey["size"] = int64(settings.PrivateKey.Size)
+		}
+		if len(settings.PrivateKey.RotationPolicy) > 0 {
+			privateKey["rotationPolicy"] = string(settings.PrivateKey.RotationPolicy)
+		}
+		if len(privateKey) > 0 {
+			spec["privateKey"] = privateKey
+		}
+	}
+
+	return newUnstructured(CertificateV1GVK, name, namespace, map[string]string{}, spec)
+}
+
+func certCommonName(settings *operatorv1alpha1.Cert) string {
+	if settings != nil && len(settings.CommonName) > 0 {
+		return settings.CommonName
+	}
+	return AppName
+}
+
+func certDuration(settings *operatorv1alpha1.Cert) time.Duration {
+	if settings != nil && settings.Duration != nil {
+		return settings.Duration.Duration
+	}
+	return operatorv1alpha1.DefaultCertDuration
+}
+
+func certRenewBefore(settings *operatorv1alpha1.Cert) time.Duration {
+	if settings != nil && settings.RenewBefore != nil {
+		return settings.RenewBefore.Duration
+	}
+	return operatorv1alpha1.DefaultCertRenewBefore
+}
+
+func toInterfaceSlice(values []string) []interface{} {
+	result := make([]interface{}, 0, len(values))
+	for _, v := range values {
+		result = append(result, v)
+	}
+	return result
 }
 
 func getDefaultDNSNames(service, namespace string) []string {
@@ -75,102 +194,101 @@ func getDefaultDNSNames(service, namespace string) []string {
 	return []string{dns1, dns2, dns3}
 }
 
+// certSettings returns spec.cert, empty when the CR was not defaulted by the webhook.
+func (ingressRequest *IngressRequest) certSettings() *operatorv1alpha1.Cert {
+	if cert := ingressRequest.managementIngress.Spec.Cert; cert != nil {
+		return cert
+	}
+	return &operatorv1alpha1.Cert{}
+}
+
 func (ingressRequest *IngressRequest) CreateOrUpdateCertificates() error {
 	// Create certificate for management ingress
-	defaultDNS := getDefaultDNSNames(ServiceName, ingressRequest.managementIngress.ObjectMeta.Namespace)
-	DNS := ingressRequest.managementIngress.Spec.Cert.DNSNames
-
-	issuer := ingressRequest.managementIngress.Spec.Cert.NamespacedIssuer
-	if issuer == (operatorv1alpha1.CertIssuer{}) {
-		klog.Info("empty issuer in managementIngress CR, using default issuer name and kind to create the certificate...")
-		issuer = operatorv1alpha1.CertIssuer{
-			Name: DefaultCAIssuerName,
-			Kind: operatorv1alpha1.IssuerKind(DefaultCAIssuerKind),
+	names := ingressRequest.names()
+	defaultDNS := getDefaultDNSNames(names.Service, ingressRequest.managementIngress.ObjectMeta.Namespace)
+	settings := ingressRequest.certSettings()
+	DNS := settings.DNSNames
+
+	// The issuer is only needed when cert-manager issues at least one of the certificates.
+	spec := ingressRequest.managementIngress.Spec
+	issuer := ingressRequest.getIssuer()
+	if settings.TLSSecretRef == nil || (!spec.IgnoreRouteCert && settings.RouteSecretRef == nil) {
+		if err := ingressRequest.checkIssuerReady(issuer); err != nil {
+			return err
 		}
+		ingressRequest.managementIngress.Status.Issuer = issuer
+	} else {
+		ingressRequest.managementIngress.Status.Issuer = nil
+		delete(ingressRequest.managementIngress.Status.Conditions, operatorv1alpha1.IssuerPhase)
 	}
 
-	cert := NewCertificate(
-		CertName,
-		ingressRequest.managementIngress.ObjectMeta.Namespace,
-		TLSSecretName,
-		append(defaultDNS, DNS...),
-		ingressRequest.managementIngress.Spec.Cert.IPAddresses,
-		&issuer,
-	)
-
-	if err := ingressRequest.CreateOrUpdateCert(cert); err != nil {
+	if err := ingressRequest.createOrUpdateServiceCert(append(defaultDNS, DNS...), issuer); err != nil {
 		return err
 	}
 
 	// Determine if we should watch or ignore the route-cert certificate
 	if ingressRequest.managementIngress.Spec.IgnoreRouteCert {
-		klog.Infof("Not watching certificate: %s, IgnoreRouteCert is true.", RouteCert)
+		klog.Infof("Not watching certificate: %s, IgnoreRouteCert is true.", names.RouteCertificate)
 		return nil
 	}
 
+	hosts := []string{ingressRequest.managementIngress.Status.Host}
+	if secretRef := settings.RouteSecretRef; secretRef != nil {
+		return ingressRequest.syncUserCertificate(secretRef.Name, names.RouteCertificate, hosts)
+	}
+
 	// Create TLS certificate for management ingress route
-	routeCert := NewCertificate(
-		RouteCert,
+	routeCert, err := NewCertificate(
+		ingressRequest.getCertificateGVK(),
+		names.RouteCertificate,
 		ingressRequest.managementIngress.ObjectMeta.Namespace,
-		RouteSecret,
-		[]string{ingressRequest.managementIngress.Status.Host},
+		names.RouteSecret,
+		hosts,
 		[]string{},
-		&issuer,
+		issuer,
+		settings,
 	)
+	if err != nil {
+		return err
+	}
 
 	return ingressRequest.CreateOrUpdateCert(routeCert)
-
 }
 
-func (ingressRequest *IngressRequest) CreateOrUpdateCert(cert *certmanager.Certificate) error {
-	if err := controllerutil.SetControllerReference(ingressRequest.managementIngress, cert, ingressRequest.scheme); err != nil {
-		klog.Errorf("Error setting controller reference on Certificate: %v", err)
+// createOrUpdateServiceCert creates the certificate of the management ingress service, or validates
+// the user provided secret. The service is reached as <service>.<namespace>.svc by the routes.
+func (ingressRequest *IngressRequest) createOrUpdateServiceCert(dnsNames []string, issuer *operatorv1alpha1.CertIssuer) error {
+	names := ingressRequest.names()
+	settings := ingressRequest.certSettings()
+	if secretRef := settings.TLSSecretRef; secretRef != nil {
+		serviceHost := strings.Join([]string{names.Service, ingressRequest.managementIngress.Namespace, "svc"}, ".")
+		return ingressRequest.syncUserCertificate(secretRef.Name, names.Certificate, []string{serviceHost})
 	}
 
-	stop := WaitForTimeout(10 * time.Minute)
-	if err := waitForCert(ingressRequest, cert, stop); err != nil {
-		ingressRequest.recorder.Eventf(ingressRequest.managementIngress, "Warning", "CreatedOrUpdatedCertificate", "Failed to create or update certificate %q", cert.ObjectMeta.Name)
-		return fmt.Errorf("failure creating certificate: %s %v", cert.ObjectMeta.Name, err)
+	cert, err := NewCertificate(
+		ingressRequest.getCertificateGVK(),
+		names.Certificate,
+		ingressRequest.managementIngress.ObjectMeta.Namespace,
+		names.TLSSecret,
+		dnsNames,
+		settings.IPAddresses,
+		issuer,
+		settings,
+	)
+	if err != nil {
+		return err
 	}
 
-	klog.Infof("Created or updated certificate: %s.", cert.ObjectMeta.Name)
-	ingressRequest.recorder.Eventf(ingressRequest.managementIngress, "Normal", "CreatedCertificate", "Successfully created certificate %q", cert.ObjectMeta.Name)
-
-	return nil
+	return ingressRequest.CreateOrUpdateCert(cert)
 }
 
-func waitForCert(r *IngressRequest, cert *certmanager.Certificate, stopCh <-chan struct{}) error {
-
-	err := wait.PollImmediateUntil(2*time.Second, func() (done bool, err error) {
-		klog.V(4).Infof("Try to create certificate: %v", cert)
-		if err := r.Create(cert); err != nil {
-			if errors.IsAlreadyExists(err) {
-				klog.Infof("Trying to update certificate: %s as it already existed.", cert.ObjectMeta.Name)
-				current := &certmanager.Certificate{}
-				if err = r.Get(cert.ObjectMeta.Name, r.managementIngress.ObjectMeta.Namespace, current); err != nil {
-					return false, fmt.Errorf("failure getting certificate: %q for %q: %v", cert.ObjectMeta.Name, r.managementIngress.Name, err)
-				}
-				if equal := reflect.DeepEqual(current.Spec, cert.Spec); equal {
-					klog.Infof("No change found from certificate: %s, skip updating current certificate.", cert.ObjectMeta.Name)
-					return true, nil
-				}
-				klog.Infof("Found change for certificate: %s, trying to update it.", cert.ObjectMeta.Name)
-				current.Spec = cert.Spec
-				err = r.Update(current)
-				if err != nil {
-					r.recorder.Eventf(r.managementIngress, "Warning", "UpdatedCertificate", "Failed to update certificate: %s", cert.ObjectMeta.Name)
-					return false, fmt.Errorf("failure updating certificate: %q for %q: %v", cert.ObjectMeta.Name, r.managementIngress.Name, err)
-				}
-				r.recorder.Eventf(r.managementIngress, "Normal", "UpdatedCertificate", "Successfully updated certificate %q", cert.ObjectMeta.Name)
-				return true, nil
-			}
-
-			klog.V(4).Infof("Failed to create or update certificate: %+v, retrying again ...", err)
-			return false, nil
-		}
-
-		return true, nil
-	}, stopCh)
+// CreateOrUpdateCert applies the certificate. cert-manager issues the secret asynchronously,
+// the phases depending on it wait for the secret separately.
+func (ingressRequest *IngressRequest) CreateOrUpdateCert(cert *unstructured.Unstructured) error {
+	if _, err := ingressRequest.Apply(cert); err != nil {
+		return fmt.Errorf("failure creating certificate: %s %v", cert.GetName(), err)
+	}
 
-	return err
+	klog.V(4).Infof("Applied certificate: %s.", cert.GetName())
+	return nil
 }
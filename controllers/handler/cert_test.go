@@ -1,5 +1,5 @@
 //
-// Copyright 2020 IBM Corporation
+// Copyright 2021 IBM Corporation
 //
 // Licensed under the Apache License, Version 2.0 (the "License");
 // you may not use this file except in compliance with the License.
@@ -17,6 +17,14 @@ package handler
 
 import (
 	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	operatorv1alpha1 "github.com/IBM/ibm-management-ingress-operator/api/v1alpha1"
+	"github.com/IBM/ibm-management-ingress-operator/utils"
 )
 
 func TestCreateOrUpdateCertificates(t *testing.T) {
@@ -26,3 +34,91 @@ func TestCreateOrUpdateCertificates(t *testing.T) {
 func TestRemoveCertificate(t *testing.T) {
 
 }
+
+func TestNewCertificate(t *testing.T) {
+	issuer := &operatorv1alpha1.CertIssuer{Name: operatorv1alpha1.DefaultCAIssuerName, Kind: operatorv1alpha1.DefaultCAIssuerKind}
+	hosts := []string{ServiceName, "cp-console.example.com"}
+
+	for _, tc := range []struct {
+		gvk         schema.GroupVersionKind
+		apiVersion  string
+		issuerGroup string
+	}{
+		{gvk: CertificateV1Alpha1GVK, apiVersion: "certmanager.k8s.io/v1alpha1", issuerGroup: ""},
+		{gvk: CertificateV1GVK, apiVersion: "cert-manager.io/v1", issuerGroup: "cert-manager.io"},
+	} {
+		cert, err := NewCertificate(tc.gvk, CertName, "ibm-common-services", TLSSecretName, hosts, []string{"10.0.0.1"}, issuer, nil)
+		if err != nil {
+			t.Fatalf("NewCertificate returned unexpected error for %s: %v", tc.apiVersion, err)
+		}
+		if cert.GetAPIVersion() != tc.apiVersion || cert.GetKind() != "Certificate" {
+			t.Errorf("expected %s Certificate, got %s %s", tc.apiVersion, cert.GetAPIVersion(), cert.GetKind())
+		}
+		if secretName, _, _ := unstructured.NestedString(cert.Object, "spec", "secretName"); secretName != TLSSecretName {
+			t.Errorf("expected secret %s for %s, got %q", TLSSecretName, tc.apiVersion, secretName)
+		}
+		if duration, _, _ := unstructured.NestedString(cert.Object, "spec", "duration"); duration != "8760h0m0s" {
+			t.Errorf("unexpected duration %q for %s", duration, tc.apiVersion)
+		}
+		if dnsNames, _, _ := unstructured.NestedStringSlice(cert.Object, "spec", "dnsNames"); len(dnsNames) != len(hosts) {
+			t.Errorf("expected dns names %v for %s, got %v", hosts, tc.apiVersion, dnsNames)
+		}
+		if group, _, _ := unstructured.NestedString(cert.Object, "spec", "issuerRef", "group"); group != tc.issuerGroup {
+			t.Errorf("expected issuer group %q for %s, got %q", tc.issuerGroup, tc.apiVersion, group)
+		}
+	}
+
+	cert, _ := NewCertificate(CertificateV1GVK, CertName, "ibm-common-services", TLSSecretName, hosts, nil, issuer, nil)
+	if labels, _, _ := unstructured.NestedStringMap(cert.Object, "spec", "secretTemplate", "labels"); labels["app"] != AppName {
+		t.Errorf("expected the common labels on the secret template, got %v", labels)
+	}
+	if _, found, _ := unstructured.NestedSlice(cert.Object, "spec", "ipAddresses"); found {
+		t.Errorf("expected no ip addresses")
+	}
+}
+
+func TestNewCertificateSettings(t *testing.T) {
+	issuer := &operatorv1alpha1.CertIssuer{Name: operatorv1alpha1.DefaultCAIssuerName, Kind: operatorv1alpha1.DefaultCAIssuerKind}
+	settings := &operatorv1alpha1.Cert{
+		CommonName:  "console.example.com",
+		Duration:    &metav1.Duration{Duration: 90 * 24 * time.Hour},
+		RenewBefore: &metav1.Duration{Duration: 30 * 24 * time.Hour},
+		PrivateKey: &operatorv1alpha1.CertPrivateKey{
+			Algorithm:      operatorv1alpha1.ECDSAKeyAlgorithm,
+			Size:           256,
+			RotationPolicy: operatorv1alpha1.RotationPolicyAlways,
+		},
+	}
+
+	cert, err := NewCertificate(CertificateV1GVK, RouteCert, "ibm-common-services", RouteSecret, []string{"console.example.com"}, nil, issuer, settings)
+	if err != nil {
+		t.Fatalf("NewCertificate returned unexpected error: %v", err)
+	}
+	expected := map[string]interface{}{
+		"commonName":  "console.example.com",
+		"duration":    "2160h0m0s",
+		"renewBefore": "720h0m0s",
+		"privateKey": map[string]interface{}{
+			"algorithm":      "ECDSA",
+			"size":           int64(256),
+			"rotationPolicy": "Always",
+		},
+	}
+	if !utils.ContainsFields(cert.Object["spec"], expected) {
+		t.Errorf("expected spec to contain %v, got %v", expected, cert.Object["spec"])
+	}
+
+	legacy, err := NewCertificate(CertificateV1Alpha1GVK, RouteCert, "ibm-common-services", RouteSecret, []string{"console.example.com"}, nil, issuer, settings)
+	if err != nil {
+		t.Fatalf("NewCertificate returned unexpected error: %v", err)
+	}
+	expected = map[string]interface{}{
+		"commonName":   "console.example.com",
+		"duration":     "2160h0m0s",
+		"keyAlgorithm": "ecdsa",
+		"keySize":      int64(256),
+	}
+	if !utils.ContainsFields(legacy.Object["spec"], expected) {
+		t.Errorf("expected legacy spec to contain %v, got %v", expected, legacy.Object["spec"])
+	}
+}
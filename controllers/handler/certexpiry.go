@@ -0,0 +1,226 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package handler
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	core "k8s.io/api/core/v1"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	operatorv1alpha1 "github.com/IBM/ibm-management-ingress-operator/api/v1alpha1"
+)
+
+const (
+	// CertificateCheckInterval is how often the certificates are checked when nothing else triggers a reconcile.
+	CertificateCheckInterval = time.Hour
+	// CertExpiryWarningPeriod is how long before expiry a user provided certificate or a CA is reported as expiring.
+	CertExpiryWarningPeriod = 30 * 24 * time.Hour
+
+	ReasonCertificatesValid   string = "CertificatesValid"
+	ReasonCertificateExpiring string = "CertificateExpiring"
+	ReasonCertificateInvalid  string = "CertificateInvalid"
+)
+
+var (
+	certificateNotAfter = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "management_ingress_certificate_not_after_seconds",
+			Help: "Expiry time of the certificate in a secret used by management ingress, in seconds since the epoch.",
+		},
+		[]string{"namespace", "managementingress", "secret"},
+	)
+	certificateDaysRemaining = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "management_ingress_certificate_days_remaining",
+			Help: "Days until the certificate in a secret used by management ingress expires, negative once expired.",
+		},
+		[]string{"namespace", "managementingress", "secret"},
+	)
+)
+
+func init() {
+	// Served on the metrics endpoint of the manager, see --metrics-addr.
+	metrics.Registry.MustRegister(certificateNotAfter, certificateDaysRemaining)
+}
+
+// monitoredCertificate is a secret whose certificate is checked for expiry.
+type monitoredCertificate struct {
+	secretName string
+	namespace  string
+	// warningPeriod is how long before expiry the certificate is reported as expiring.
+	warningPeriod time.Duration
+	// caOnly is set for secrets which only hold a CA in ca.crt.
+	caOnly bool
+}
+
+// monitoredCertificates returns the secrets used by management ingress. Certificates issued by
+// cert-manager are renewed RenewBefore their expiry, so they are only reported once the renewal is overdue.
+func (ingressRequest *IngressRequest) monitoredCertificates() []monitoredCertificate {
+	ns := ingressRequest.managementIngress.Namespace
+	cert := ingressRequest.managementIngress.Spec.Cert
+
+	tlsWarningPeriod := certRenewBefore(cert)
+	if cert != nil && cert.TLSSecretRef != nil {
+		tlsWarningPeriod = CertExpiryWarningPeriod
+	}
+	routeWarningPeriod := certRenewBefore(cert)
+	if ingressRequest.managementIngress.Spec.IgnoreRouteCert || (cert != nil && cert.RouteSecretRef != nil) {
+		routeWarningPeriod = CertExpiryWarningPeriod
+	}
+
+	return []monitoredCertificate{
+		{secretName: ingressRequest.tlsSecretName(), namespace: ns, warningPeriod: tlsWarningPeriod},
+		{secretName: ingressRequest.routeSecretName(), namespace: ns, warningPeriod: routeWarningPeriod},
+		{secretName: ClusterSecretName, namespace: os.Getenv(PODNAMESPACE), warningPeriod: CertExpiryWarningPeriod, caOnly: true},
+	}
+}
+
+// CheckCertificateExpiry exports the expiry of the management ingress certificates as metrics, and reports
+// certificates which expire soon or do not verify against their CA with Warning events and the
+// CertificateExpiry condition. It returns when the certificates should be checked again.
+func (ingressRequest *IngressRequest) CheckCertificateExpiry() time.Duration {
+	instance := ingressRequest.managementIngress
+	now := time.Now()
+	requeueAfter := CertificateCheckInterval
+
+	var problems []string
+	reason := ReasonCertificateExpiring
+	for _, monitored := range ingressRequest.monitoredCertificates() {
+		secret := &core.Secret{}
+		if err := ingressRequest.Get(monitored.secretName, monitored.namespace, secret); err != nil {
+			// Missing secrets are reported by the reconcile phases which depend on them.
+			klog.V(4).Infof("Not checking certificate expiry of secret %s/%s: %v", monitored.namespace, monitored.secretName, err)
+			continue
+		}
+
+		notAfter, err := checkCertificateSecret(secret, monitored.caOnly, now)
+		if err != nil {
+			reason = ReasonCertificateInvalid
+			problems = append(problems, fmt.Sprintf("secret %s/%s: %v", monitored.namespace, monitored.secretName, err))
+			ingressRequest.recorder.Eventf(instance, "Warning", ReasonCertificateInvalid, "Certificate in secret %q does not verify: %v", monitored.secretName, err)
+			continue
+		}
+
+		remaining := notAfter.Sub(now)
+		certificateNotAfter.WithLabelValues(instance.Namespace, instance.Name, monitored.secretName).Set(float64(notAfter.Unix()))
+		certificateDaysRemaining.WithLabelValues(instance.Namespace, instance.Name, monitored.secretName).Set(remaining.Hours() / 24)
+
+		if remaining < monitored.warningPeriod {
+			problems = append(problems, fmt.Sprintf("secret %s/%s expires at %s", monitored.namespace, monitored.secretName, notAfter.UTC().Format(time.RFC3339)))
+			ingressRequest.recorder.Eventf(instance, "Warning", ReasonCertificateExpiring, "Certificate in secret %q expires at %s", monitored.secretName, notAfter.UTC().Format(time.RFC3339))
+		} else if untilWarning := remaining - monitored.warningPeriod; untilWarning < requeueAfter {
+			requeueAfter = untilWarning
+		}
+	}
+
+	if len(problems) > 0 {
+		ingressRequest.setCondition(operatorv1alpha1.CertificateExpiryPhase, operatorv1alpha1.CertificateExpiring, operatorv1alpha1.ConditionTrue, reason, strings.Join(problems, "; "))
+	} else {
+		ingressRequest.setCondition(operatorv1alpha1.CertificateExpiryPhase, operatorv1alpha1.CertificateExpiring, operatorv1alpha1.ConditionFalse, ReasonCertificatesValid, "")
+	}
+
+	if requeueAfter < time.Minute {
+		requeueAfter = time.Minute
+	}
+	return requeueAfter
+}
+
+// removeCertificateMetrics deletes the metrics of a deleted ManagementIngress.
+func (ingressRequest *IngressRequest) removeCertificateMetrics() {
+	instance := ingressRequest.managementIngress
+	for _, monitored := range ingressRequest.monitoredCertificates() {
+		certificateNotAfter.DeleteLabelValues(instance.Namespace, instance.Name, monitored.secretName)
+		certificateDaysRemaining.DeleteLabelValues(instance.Namespace, instance.Name, monitored.secretName)
+	}
+}
+
+// checkCertificateSecret returns the expiry of the certificate in a secret. The certificate in tls.crt
+// must verify against the CA of the secret, if it has one. Secrets with caOnly only hold a CA in ca.crt.
+func checkCertificateSecret(secret *core.Secret, caOnly bool, now time.Time) (time.Time, error) {
+	if caOnly {
+		certs, err := parseCertificates(secret.Data["ca.crt"])
+		if err != nil {
+			return time.Time{}, fmt.Errorf("failure parsing ca.crt: %v", err)
+		}
+		return certs[0].NotAfter, nil
+	}
+
+	certs, err := parseCertificates(secret.Data[core.TLSCertKey])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failure parsing %s: %v", core.TLSCertKey, err)
+	}
+	leaf := certs[0]
+
+	ca := getCACert(secret)
+	if len(ca) == 0 {
+		return leaf.NotAfter, nil
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(ca) {
+		return time.Time{}, fmt.Errorf("failure parsing ca.crt")
+	}
+	// An expired leaf is reported as expiring, not as a chain which does not verify.
+	verifyTime := now
+	if verifyTime.After(leaf.NotAfter) {
+		verifyTime = leaf.NotAfter
+	}
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	if _, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   verifyTime,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}); err != nil {
+		return time.Time{}, err
+	}
+
+	return leaf.NotAfter, nil
+}
+
+// parseCertificates parses the PEM encoded certificates in data, the first one is the leaf.
+func parseCertificates(data []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no PEM encoded certificate found")
+	}
+	return certs, nil
+}
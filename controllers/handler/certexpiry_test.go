@@ -0,0 +1,55 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package handler
+
+import (
+	"testing"
+	"time"
+
+	core "k8s.io/api/core/v1"
+)
+
+func TestCheckCertificateSecret(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	host := "cp-console.apps.example.com"
+	caPEM, _, ca, caKey := newTestCertificate(t, nil, now.Add(-time.Hour), now.Add(365*24*time.Hour), nil, nil)
+	otherCAPEM, _, _, _ := newTestCertificate(t, nil, now.Add(-time.Hour), now.Add(365*24*time.Hour), nil, nil)
+	leafPEM, _, _, _ := newTestCertificate(t, []string{host}, now.Add(-time.Hour), now.Add(12*time.Hour), ca, caKey)
+
+	notAfter, err := checkCertificateSecret(&core.Secret{Data: map[string][]byte{"tls.crt": leafPEM, "ca.crt": caPEM}}, false, now)
+	if err != nil {
+		t.Fatalf("expected the chain to verify, got %v", err)
+	}
+	if !notAfter.Equal(now.Add(12 * time.Hour)) {
+		t.Errorf("expected expiry of the leaf %v, got %v", now.Add(12*time.Hour), notAfter)
+	}
+
+	if _, err := checkCertificateSecret(&core.Secret{Data: map[string][]byte{"tls.crt": leafPEM, "ca.crt": otherCAPEM}}, false, now); err == nil {
+		t.Errorf("expected a certificate signed by another CA not to verify")
+	}
+
+	// An expired certificate still reports its expiry instead of a verification error.
+	if notAfter, err := checkCertificateSecret(&core.Secret{Data: map[string][]byte{"tls.crt": leafPEM, "ca.crt": caPEM}}, false, now.Add(24*time.Hour)); err != nil || !notAfter.Before(now.Add(24*time.Hour)) {
+		t.Errorf("expected the expiry of the expired certificate, got %v, %v", notAfter, err)
+	}
+
+	if notAfter, err := checkCertificateSecret(&core.Secret{Data: map[string][]byte{"ca.crt": caPEM}}, true, now); err != nil || !notAfter.Equal(now.Add(365*24*time.Hour)) {
+		t.Errorf("expected the expiry of the CA, got %v, %v", notAfter, err)
+	}
+	if _, err := checkCertificateSecret(&core.Secret{Data: map[string][]byte{}}, true, now); err == nil {
+		t.Errorf("expected an error for a secret without a CA")
+	}
+}
@@ -0,0 +1,191 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package handler
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	scc "github.com/openshift/api/security/v1"
+	core "k8s.io/api/core/v1"
+	rbac "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog"
+
+	operatorv1alpha1 "github.com/IBM/ibm-management-ingress-operator/api/v1alpha1"
+	"github.com/IBM/ibm-management-ingress-operator/utils"
+)
+
+// Cleanup removes the objects of a deleted ManagementIngress which owner references cannot
+// garbage collect: the cluster scoped RBAC and SCC, and the cluster CA secret in the operator
+// namespace. They are shared by all ManagementIngress instances, so they are kept as long as
+// another instance exists, only the service account of the namespace is removed from them.
+// The configmaps shared by the instances of the namespace are handed over to the next maintainer.
+func Cleanup(ingressRequest *IngressRequest, clusterType string) error {
+	instance := ingressRequest.managementIngress
+	ingressRequest.removeCertificateMetrics()
+
+	if err := ingressRequest.handOverSharedObjects(); err != nil {
+		return err
+	}
+
+	others, err := ingressRequest.otherInstances()
+	if err != nil {
+		return fmt.Errorf("failure listing managementingress: %v", err)
+	}
+	if len(others) > 0 {
+		klog.Infof("Keeping shared resources of managementingress: %s/%s, still used by %v", instance.Namespace, instance.Name, others)
+		for _, other := range others {
+			if strings.HasPrefix(other, instance.Namespace+"/") {
+				return nil
+			}
+		}
+		// The service account of this namespace is no longer used by any instance.
+		return ingressRequest.removeNamespaceAccess(clusterType)
+	}
+
+	if err := ingressRequest.RemoveClusterRoleBinding(AppName); err != nil {
+		return err
+	}
+	if err := ingressRequest.RemoveClusterRole(AppName); err != nil {
+		return err
+	}
+	if clusterType != CNCF {
+		if err := ingressRequest.RemoveSecurityContextConstraint(SCCName); err != nil {
+			return err
+		}
+	}
+	if err := ingressRequest.removeClusterCACert(os.Getenv(PODNAMESPACE)); err != nil {
+		return err
+	}
+
+	ingressRequest.recorder.Eventf(instance, "Normal", "CleanedUp", "Successfully removed cluster scoped resources of %q", instance.Name)
+	return nil
+}
+
+// otherInstances returns the ManagementIngress instances, other than the one being deleted, which are not being deleted.
+func (ingressRequest *IngressRequest) otherInstances() ([]string, error) {
+	ingressList := &operatorv1alpha1.ManagementIngressList{}
+	if err := ingressRequest.client.List(context.TODO(), ingressList); err != nil {
+		return nil, err
+	}
+
+	others := []string{}
+	for _, item := range ingressList.Items {
+		if item.UID == ingressRequest.managementIngress.UID || !item.DeletionTimestamp.IsZero() {
+			continue
+		}
+		others = append(others, item.Namespace+"/"+item.Name)
+	}
+	return others, nil
+}
+
+// handOverSharedObjects makes the next maintainer of the namespace the owner of the shared configmaps
+// of the CR, which are garbage collected with the CR otherwise. The owner change also triggers a reconcile
+// of the next maintainer, which then maintains ibmcloud-cluster-ca-cert as well.
+func (ingressRequest *IngressRequest) handOverSharedObjects() error {
+	instances, err := ingressRequest.namespaceInstances()
+	if err != nil {
+		return fmt.Errorf("failure listing managementingress: %v", err)
+	}
+	others := []operatorv1alpha1.ManagementIngress{}
+	for _, item := range instances {
+		if item.UID != ingressRequest.managementIngress.UID {
+			others = append(others, item)
+		}
+	}
+
+	maintainer := selectMaintainer(others)
+	if maintainer == nil {
+		return nil
+	}
+	return ingressRequest.ownSharedConfigMaps(maintainer)
+}
+
+// removeClusterCACert deletes ibmcloud-cluster-ca-cert, which lives in the operator namespace and so
+// cannot be owned by a ManagementIngress in another namespace.
+func (ingressRequest *IngressRequest) removeClusterCACert(namespace string) error {
+	if len(namespace) == 0 {
+		return nil
+	}
+
+	secret := &core.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ClusterSecretName,
+			Namespace: namespace,
+		},
+	}
+
+	klog.Infof("Removing secret: %s/%s", namespace, ClusterSecretName)
+	err := ingressRequest.Delete(secret)
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failure deleting secret %s/%s: %v", namespace, ClusterSecretName, err)
+	}
+
+	return nil
+}
+
+// removeNamespaceAccess removes the service account of the namespace of the CR from the shared
+// ClusterRoleBinding and SecurityContextConstraint.
+func (ingressRequest *IngressRequest) removeNamespaceAccess(clusterType string) error {
+	namespace := ingressRequest.managementIngress.Namespace
+
+	binding := &rbac.ClusterRoleBinding{}
+	if err := ingressRequest.GetUncached(AppName, "", binding); err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failure getting ClusterRoleBinding %s: %v", AppName, err)
+	} else if err == nil {
+		subjects := []rbac.Subject{}
+		for _, subject := range binding.Subjects {
+			if subject.Kind == "ServiceAccount" && subject.Name == ServiceAccountName && subject.Namespace == namespace {
+				continue
+			}
+			subjects = append(subjects, subject)
+		}
+		if len(subjects) != len(binding.Subjects) {
+			klog.Infof("Removing service account of namespace %s from ClusterRoleBinding: %s", namespace, AppName)
+			binding.Subjects = subjects
+			if err := ingressRequest.Update(binding); err != nil {
+				return fmt.Errorf("failure updating ClusterRoleBinding %s: %v", AppName, err)
+			}
+		}
+	}
+
+	if clusterType == CNCF {
+		return nil
+	}
+
+	constraint := &scc.SecurityContextConstraints{}
+	if err := ingressRequest.GetUncached(SCCName, "", constraint); err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("failure getting SecurityContextConstraint %s: %v", SCCName, err)
+	}
+	user := strings.Join([]string{"system:serviceaccount", namespace, ServiceAccountName}, ":")
+	if !utils.ContainsString(constraint.Users, user) {
+		return nil
+	}
+	klog.Infof("Removing user %s from SecurityContextConstraint: %s", user, SCCName)
+	constraint.Users = utils.RemoveString(constraint.Users, user)
+	if err := ingressRequest.Update(constraint); err != nil {
+		return fmt.Errorf("failure updating SecurityContextConstraint %s: %v", SCCName, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,149 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package handler
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	core "k8s.io/api/core/v1"
+	rbac "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	operatorv1alpha1 "github.com/IBM/ibm-management-ingress-operator/api/v1alpha1"
+)
+
+// newFakeIngressRequest returns an IngressRequest for the CR default/ibm-common-services, backed by a fake client with objs.
+func newFakeIngressRequest(objs ...runtime.Object) *IngressRequest {
+	s := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(s)
+	_ = operatorv1alpha1.AddToScheme(s)
+
+	instance := &operatorv1alpha1.ManagementIngress{
+		ObjectMeta: metav1.ObjectMeta{Name: "default", Namespace: "ibm-common-services", UID: "deleted"},
+	}
+
+	c := &applyClient{Client: fake.NewFakeClientWithScheme(s, append([]runtime.Object{instance}, objs...)...)}
+	return NewIngressHandler(instance, c, c, record.NewFakeRecorder(10), s)
+}
+
+func newCleanupRequest(objs ...runtime.Object) *IngressRequest {
+	shared := []runtime.Object{
+		&rbac.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: AppName}},
+		&rbac.ClusterRoleBinding{ObjectMeta: metav1.ObjectMeta{Name: AppName}},
+		&core.Secret{ObjectMeta: metav1.ObjectMeta{Name: ClusterSecretName, Namespace: "operators"}},
+	}
+	return newFakeIngressRequest(append(shared, objs...)...)
+}
+
+func TestCleanupRemovesSharedResources(t *testing.T) {
+	os.Setenv(PODNAMESPACE, "operators")
+	defer os.Unsetenv(PODNAMESPACE)
+
+	ingressRequest := newCleanupRequest()
+	if err := Cleanup(ingressRequest, CNCF); err != nil {
+		t.Fatalf("Cleanup returned unexpected error: %v", err)
+	}
+
+	checks := map[string]runtime.Object{
+		"clusterrole":        &rbac.ClusterRole{},
+		"clusterrolebinding": &rbac.ClusterRoleBinding{},
+	}
+	for kind, obj := range checks {
+		if err := ingressRequest.client.Get(context.TODO(), types.NamespacedName{Name: AppName}, obj); !errors.IsNotFound(err) {
+			t.Errorf("expected %s to be removed, got %v", kind, err)
+		}
+	}
+	secret := &core.Secret{}
+	if err := ingressRequest.client.Get(context.TODO(), types.NamespacedName{Name: ClusterSecretName, Namespace: "operators"}, secret); !errors.IsNotFound(err) {
+		t.Errorf("expected secret %s to be removed, got %v", ClusterSecretName, err)
+	}
+}
+
+func TestCleanupKeepsResourcesOfOtherInstances(t *testing.T) {
+	os.Setenv(PODNAMESPACE, "operators")
+	defer os.Unsetenv(PODNAMESPACE)
+
+	other := &operatorv1alpha1.ManagementIngress{
+		ObjectMeta: metav1.ObjectMeta{Name: "default", Namespace: "tenant", UID: "other"},
+	}
+	ingressRequest := newCleanupRequest(other)
+	if err := Cleanup(ingressRequest, CNCF); err != nil {
+		t.Fatalf("Cleanup returned unexpected error: %v", err)
+	}
+
+	if err := ingressRequest.client.Get(context.TODO(), types.NamespacedName{Name: AppName}, &rbac.ClusterRole{}); err != nil {
+		t.Errorf("expected clusterrole to be kept while another instance exists, got %v", err)
+	}
+}
+
+func TestSelectMaintainer(t *testing.T) {
+	now := metav1.Now()
+	older := metav1.NewTime(now.Add(-time.Hour))
+	instances := []operatorv1alpha1.ManagementIngress{
+		{ObjectMeta: metav1.ObjectMeta{Name: "tenant-b", CreationTimestamp: now}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "tenant-c", CreationTimestamp: older}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "tenant-a", CreationTimestamp: older}},
+	}
+	if maintainer := selectMaintainer(instances); maintainer == nil || maintainer.Name != "tenant-a" {
+		t.Errorf("expected the oldest instance tenant-a to maintain the shared objects, got %v", maintainer)
+	}
+
+	instances = append(instances, operatorv1alpha1.ManagementIngress{ObjectMeta: metav1.ObjectMeta{Name: operatorv1alpha1.DefaultName, CreationTimestamp: now}})
+	if maintainer := selectMaintainer(instances); maintainer == nil || maintainer.Name != operatorv1alpha1.DefaultName {
+		t.Errorf("expected the default instance to maintain the shared objects, got %v", maintainer)
+	}
+
+	if maintainer := selectMaintainer(nil); maintainer != nil {
+		t.Errorf("expected no maintainer without instances, got %v", maintainer)
+	}
+}
+
+func TestCleanupHandsOverSharedConfigMaps(t *testing.T) {
+	os.Setenv(PODNAMESPACE, "operators")
+	defer os.Unsetenv(PODNAMESPACE)
+
+	deleted := &operatorv1alpha1.ManagementIngress{ObjectMeta: metav1.ObjectMeta{Name: "default", Namespace: "ibm-common-services", UID: "deleted"}}
+	bindInfo := NewConfigMap(BindInfoConfigMap, "ibm-common-services", nil)
+	bindInfo.OwnerReferences = []metav1.OwnerReference{*metav1.NewControllerRef(deleted, operatorv1alpha1.GroupVersion.WithKind("ManagementIngress"))}
+	successor := &operatorv1alpha1.ManagementIngress{
+		ObjectMeta: metav1.ObjectMeta{Name: "tenant", Namespace: "ibm-common-services", UID: "successor"},
+	}
+
+	ingressRequest := newCleanupRequest(successor, bindInfo)
+	if maintainer, err := ingressRequest.isMaintainer(); err != nil || !maintainer {
+		t.Errorf("expected the default instance to be the maintainer, got %v, %v", maintainer, err)
+	}
+	if err := Cleanup(ingressRequest, CNCF); err != nil {
+		t.Fatalf("Cleanup returned unexpected error: %v", err)
+	}
+
+	cm := &core.ConfigMap{}
+	if err := ingressRequest.client.Get(context.TODO(), types.NamespacedName{Name: BindInfoConfigMap, Namespace: "ibm-common-services"}, cm); err != nil {
+		t.Fatalf("expected configmap %s to exist, got %v", BindInfoConfigMap, err)
+	}
+	if !metav1.IsControlledBy(cm, successor) || len(cm.OwnerReferences) != 1 {
+		t.Errorf("expected configmap %s to be handed over to %s, got owners %v", BindInfoConfigMap, successor.Name, cm.OwnerReferences)
+	}
+}
@@ -16,23 +16,18 @@
 package handler
 
 import (
-	"context"
 	"fmt"
 	"os"
-	"reflect"
 	"strings"
-	"time"
 
-	"gopkg.in/yaml.v2"
-	apps "k8s.io/api/apps/v1"
 	core "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/klog"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
-	"github.com/IBM/ibm-management-ingress-operator/utils"
+	operatorv1alpha1 "github.com/IBM/ibm-management-ingress-operator/api/v1alpha1"
+	"github.com/IBM/ibm-management-ingress-operator/clusterinfo"
 )
 
 //NewConfigMap stubs an instance of Configmap
@@ -54,122 +49,36 @@ func NewConfigMap(name string, namespace string, data map[string]string) *core.C
 	}
 }
 
-// for configmap ibmcloud-cluster-info, need to check whether it's already existed, if so, update it, else create it
-func updateClusterInfo(ingr *IngressRequest, cm *core.ConfigMap) error {
-	if err := controllerutil.SetControllerReference(ingr.managementIngress, cm, ingr.scheme); err != nil {
-		klog.Errorf("Error setting controller reference on Configmap: %v", err)
-	}
-
-	cfg, err := ingr.GetConfigmap(ClusterConfigName, ingr.managementIngress.ObjectMeta.Namespace)
-	if err != nil {
-		if errors.IsNotFound(err) {
-			// create configmap
-			err = ingr.Create(cm)
-			if err != nil {
-				ingr.recorder.Eventf(ingr.managementIngress, "Warning", "CreatedConfigmap", "Failed to create configmap: %s", cm.ObjectMeta.Name)
-				return fmt.Errorf("failure creating configmap: %v", err)
-			}
-			klog.Infof("Created configmap %s.", cm.ObjectMeta.Name)
-			ingr.recorder.Eventf(ingr.managementIngress, "Normal", "CreatedConfigmap", "Successfully created configmap: %s", cm.ObjectMeta.Name)
-			return nil
-		}
-	} else {
-		klog.Infof("Trying to update configmap: %s as it already existed.", cfg.ObjectMeta.Name)
-		if reflect.DeepEqual(cm.Data, cfg.Data) {
-			klog.Infof("No change found from the configmap: %s, skip updating current configmap.", cm.ObjectMeta.Name)
-			return nil
-		}
-
-		klog.Infof("Found change for configmap %s, trying to update it.", cfg.ObjectMeta.Name)
-		cfg.Data = cm.Data
-		if err := ingr.Update(cfg); err != nil {
-			ingr.recorder.Eventf(ingr.managementIngress, "Warning", "UpdatedConfigmap", "Failed to update configmap: %s", cm.ObjectMeta.Name)
-			return fmt.Errorf("failure updating Configmap %s: %v", cfg.ObjectMeta.Name, err)
-		}
-		ingr.recorder.Eventf(ingr.managementIngress, "Normal", "UpdatedConfigmap", "Successfully updated configmap: %s", cm.ObjectMeta.Name)
-	}
-
+// syncConfigmap applies the configmap. The management ingress pods are rolled out when the content of
+// their config changes, see podTemplateChecksums.
+func syncConfigmap(ingr *IngressRequest, cm *core.ConfigMap) error {
+	_, err := ingr.Apply(cm)
 	return err
 }
 
-func syncConfigmap(ingr *IngressRequest, cm *core.ConfigMap, ingressConfig bool) error {
-	if err := controllerutil.SetControllerReference(ingr.managementIngress, cm, ingr.scheme); err != nil {
-		klog.Errorf("Error setting controller reference on Configmap: %v", err)
-	}
-
-	err := ingr.Create(cm)
-	if err != nil {
-		if !errors.IsAlreadyExists(err) {
-			ingr.recorder.Eventf(ingr.managementIngress, "Warning", "CreatedConfigmap", "Failed to create configmap: %s", cm.ObjectMeta.Name)
-			return fmt.Errorf("failure creating configmap: %v", err)
-		}
-
-		klog.Infof("Trying to update configmap: %s as it already existed.", cm.ObjectMeta.Name)
-		current := &core.ConfigMap{}
-		// Update config
-		if err = ingr.Get(cm.ObjectMeta.Name, ingr.managementIngress.ObjectMeta.Namespace, current); err != nil {
-			return fmt.Errorf("failure getting Configmap: %q: %v", cm.ObjectMeta.Name, err)
-		}
-
-		// no data change, just return
-		if reflect.DeepEqual(cm.Data, current.Data) {
-			klog.Infof("No change found from the configmap: %s, skip updating current configmap.", cm.ObjectMeta.Name)
-			return nil
-		}
-
-		//json, _ := json.Marshal(cm)
-		klog.Infof("Found change for configmap %s, trying to update it.", cm.ObjectMeta.Name)
-		current.Data = cm.Data
-
-		// Apply the latest change to configmap
-		if err = ingr.Update(current); err != nil {
-			return fmt.Errorf("failure updating Configmap: %v: %v", cm.ObjectMeta.Name, err)
-		}
-
-		// Restart Deployment because management-ingress-config is updated.
-		if ingressConfig {
-			ds := &apps.Deployment{}
-			if err = ingr.Get(AppName, ingr.managementIngress.ObjectMeta.Namespace, ds); err != nil {
-				if !errors.IsNotFound(err) {
-					klog.Errorf("Failure getting Deployment: %q for %q after config change: %v ", AppName, ingr.managementIngress.Name, err)
-				}
-				return nil
-			}
-
-			annotations := ds.Spec.Template.ObjectMeta.Annotations
-			// Update annotation to force restart of ds pods
-			annotations = utils.AppendAnnotations(
-				annotations,
-				map[string]string{
-					ConfigUpdateAnnotationKey: time.Now().Format(time.RFC850),
-				},
-			)
-
-			klog.Infof("Restarting management ingress Deployment after config change.")
-			ds.Spec.Template.ObjectMeta.Annotations = annotations
-			if err := ingr.Update(ds); err != nil {
-				klog.Errorf("Failure updating Deployment: %q after config change: %v ", AppName, err)
-			}
-		}
-	} else {
-		klog.Infof("Created Configmap: %s.", cm.ObjectMeta.Name)
-		ingr.recorder.Eventf(ingr.managementIngress, "Normal", "CreatedConfigmap", "Successfully created or updated configmap %q", cm.ObjectMeta.Name)
-	}
-
-	return nil
-}
-
 func (ingressRequest *IngressRequest) CreateOrUpdateConfigMap(clusterType string, domainName string) error {
 
 	// Create management ingress config
+	configData, unknownKeys := ingressRequest.ingressConfigData()
+	ingressRequest.checkIngressConfig(unknownKeys)
+	configName := ingressRequest.names().ConfigMap
 	config := NewConfigMap(
-		ConfigName,
+		configName,
 		ingressRequest.managementIngress.Namespace,
-		ingressRequest.managementIngress.Spec.Config,
+		configData,
 	)
 
-	if err := syncConfigmap(ingressRequest, config, true); err != nil {
-		return fmt.Errorf("failure creating or updating management ingress config for %q: %v", ConfigName, err)
+	if err := syncConfigmap(ingressRequest, config); err != nil {
+		return fmt.Errorf("failure creating or updating management ingress config for %q: %v", configName, err)
+	}
+
+	// The bind info and the cluster info are shared by all instances, the maintainer maintains them.
+	maintainer, err := ingressRequest.isMaintainer()
+	if err != nil || !maintainer {
+		return err
+	}
+	if err := ingressRequest.ownSharedConfigMaps(ingressRequest.managementIngress); err != nil {
+		return err
 	}
 
 	// Create bindinfo
@@ -182,7 +91,7 @@ func (ingressRequest *IngressRequest) CreateOrUpdateConfigMap(clusterType string
 		},
 	)
 
-	if err := syncConfigmap(ingressRequest, bindInfo, false); err != nil {
+	if err := syncConfigmap(ingressRequest, bindInfo); err != nil {
 		return fmt.Errorf("failure creating bind info for %q: %v", ingressRequest.managementIngress.Name, err)
 	}
 
@@ -194,6 +103,39 @@ func (ingressRequest *IngressRequest) CreateOrUpdateConfigMap(clusterType string
 	return nil
 }
 
+// ownSharedConfigMaps makes owner the controller of the bind info and the cluster info, so they are
+// not garbage collected with their previous maintainer.
+func (ingressRequest *IngressRequest) ownSharedConfigMaps(owner *operatorv1alpha1.ManagementIngress) error {
+	for _, name := range []string{BindInfoConfigMap, ClusterConfigName} {
+		cm := &core.ConfigMap{}
+		if err := ingressRequest.Get(name, owner.Namespace, cm); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return fmt.Errorf("failure getting configmap %s: %v", name, err)
+		}
+		if metav1.IsControlledBy(cm, owner) {
+			continue
+		}
+
+		references := []metav1.OwnerReference{}
+		for _, reference := range cm.OwnerReferences {
+			if reference.Controller == nil || !*reference.Controller {
+				references = append(references, reference)
+			}
+		}
+		cm.OwnerReferences = references
+		if err := controllerutil.SetControllerReference(owner, cm, ingressRequest.scheme); err != nil {
+			return fmt.Errorf("failure setting controller reference on configmap %s: %v", name, err)
+		}
+		klog.Infof("Handing over configmap %s/%s to managementingress %s", owner.Namespace, name, owner.Name)
+		if err := ingressRequest.Update(cm); err != nil {
+			return fmt.Errorf("failure updating configmap %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
 // create configmap ibmcloud-cluster-info
 func populateCloudClusterInfo(ingressRequest *IngressRequest, clusterType string, domainName string) error {
 
@@ -220,94 +162,77 @@ func populateCloudClusterInfo(ingressRequest *IngressRequest, clusterType string
 	ep := "https://" + ServiceName + "." + ns + ".svc:443"
 
 	if clusterType == CNCF {
-		cncfDomainName := strings.Join([]string{ConsoleRouteName, domainName}, ".")
-		clustercfg := NewConfigMap(
-			ClusterConfigName,
-			ingressRequest.managementIngress.Namespace,
-			map[string]string{
-				ClusterAddr:     cncfDomainName,
-				ClusterCADomain: cncfDomainName,
-				ClusterEP:       ep,
-				ClusterName:     cname,
-				RouteHTTPPort:   rhttpPort,
-				RouteHTTPSPort:  rhttpsPort,
-				CSVersion:       ver,
-				ProxyAddress:    cncfDomainName,
-				ProxyHTTPPort:   "80",
-				ProxyHTTPSPort:  "443",
-			},
-		)
-		if err := updateClusterInfo(ingressRequest, clustercfg); err != nil {
-			return fmt.Errorf("failure creating cluster info for %q: %v", ingressRequest.managementIngress.Name, err)
+		// The console has no address yet, the cluster info is written once ibm-cpp-config sets the domain.
+		if len(domainName) == 0 {
+			klog.Warningf("Not populating cluster info for %q without %s in %s config map", ingressRequest.managementIngress.Name, CppConfigDomainName, CppConfigName)
+			return nil
 		}
-		return nil
+		cncfDomainName := strings.Join([]string{ConsoleRouteName, domainName}, ".")
+		return syncClusterInfo(ingressRequest, map[string]string{
+			ClusterAddr:     cncfDomainName,
+			ClusterCADomain: cncfDomainName,
+			ClusterEP:       ep,
+			ClusterName:     cname,
+			RouteHTTPPort:   rhttpPort,
+			RouteHTTPSPort:  rhttpsPort,
+			CSVersion:       ver,
+			ProxyAddress:    cncfDomainName,
+			ProxyHTTPPort:   "80",
+			ProxyHTTPSPort:  "443",
+		})
 	}
 	baseDomain, err := ingressRequest.GetRouteAppDomain()
 	if err != nil {
 		return fmt.Errorf("failure getting route base domain %q: %v", ingressRequest.managementIngress.Name, err)
 	}
 
-	// get api server address and port from configmap console-config in namespace openshift-console
-	console := &core.ConfigMap{}
 	clusterClient, err := createOrGetClusterClient()
 	if err != nil {
 		return fmt.Errorf("failure creating or getting cluster client: %v", err)
 	}
-	err = clusterClient.Get(context.TODO(), types.NamespacedName{Name: ConsoleCfg, Namespace: ConsoleNS}, console)
-	if err != nil {
-		return err
-	}
-
-	var result map[interface{}]interface{}
-	var apiaddr string
-	if err = yaml.Unmarshal([]byte(console.Data[ConsoleCfgYaml]), &result); err != nil {
-		return err
-	}
-
-	for k, v := range result {
-		if k.(string) == ConsoleClusterInfo {
-			cinfo := v.(map[interface{}]interface{})
-			for k1, v1 := range cinfo {
-				if k1.(string) == ConsoleMasterURL {
-					apiaddr = v1.(string)
-					apiaddr = strings.TrimPrefix(apiaddr, "https://")
-					break
-				}
-			}
-			break
-		}
-	}
+	apiServerHost, apiServerPort, found := ingressRequest.discoverAPIServer(defaultAPIServerProviders(clusterClient))
 
 	proxyRouteHost, err := ingressRequest.GetProxyRouteHost()
 	if err != nil {
 		return fmt.Errorf("failure getting proxy route host: %v", err)
 	}
 
-	pos := strings.LastIndex(apiaddr, ":")
+	clusterInfo := map[string]string{
+		ClusterAddr:     ingressRequest.managementIngress.Status.Host,
+		ClusterCADomain: ingressRequest.managementIngress.Status.Host,
+		ClusterEP:       ep,
+		ClusterName:     cname,
+		RouteHTTPPort:   rhttpPort,
+		RouteHTTPSPort:  rhttpsPort,
+		RouteBaseDomain: baseDomain,
+		CSVersion:       ver,
+		ProxyAddress:    proxyRouteHost,
+		ProxyHTTPPort:   "80",
+		ProxyHTTPSPort:  "443",
+	}
+	// The API server is left out rather than guessed, the APIServerUnknown condition tells why.
+	if found {
+		clusterInfo[ClusterAPIServerHost] = apiServerHost
+		clusterInfo[ClusterAPIServerPort] = apiServerPort
+	}
+	return syncClusterInfo(ingressRequest, clusterInfo)
+}
+
+// syncClusterInfo validates the cluster info before writing it, so its readers can rely on the schema of
+// the clusterinfo package.
+func syncClusterInfo(ingressRequest *IngressRequest, data map[string]string) error {
+	info, err := clusterinfo.FromData(data)
+	if err != nil {
+		return fmt.Errorf("failure validating cluster info for %q: %v", ingressRequest.managementIngress.Name, err)
+	}
 
 	clustercfg := NewConfigMap(
 		ClusterConfigName,
 		ingressRequest.managementIngress.Namespace,
-		map[string]string{
-			ClusterAddr:          ingressRequest.managementIngress.Status.Host,
-			ClusterCADomain:      ingressRequest.managementIngress.Status.Host,
-			ClusterEP:            ep,
-			ClusterName:          cname,
-			RouteHTTPPort:        rhttpPort,
-			RouteHTTPSPort:       rhttpsPort,
-			RouteBaseDomain:      baseDomain,
-			CSVersion:            ver,
-			ClusterAPIServerHost: apiaddr[0:pos],
-			ClusterAPIServerPort: apiaddr[pos+1:],
-			ProxyAddress:         proxyRouteHost,
-			ProxyHTTPPort:        "80",
-			ProxyHTTPSPort:       "443",
-		},
+		info.Data(),
 	)
-
-	if err := updateClusterInfo(ingressRequest, clustercfg); err != nil {
+	if err := syncConfigmap(ingressRequest, clustercfg); err != nil {
 		return fmt.Errorf("failure creating cluster info for %q: %v", ingressRequest.managementIngress.Name, err)
 	}
-
 	return nil
 }
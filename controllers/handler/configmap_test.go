@@ -16,7 +16,11 @@
 package handler
 
 import (
+	"context"
+	"os"
 	"testing"
+
+	"github.com/IBM/ibm-management-ingress-operator/clusterinfo"
 )
 
 func TestCreateOrUpdateConfigmap_Create(t *testing.T) {
@@ -30,3 +34,34 @@ func TestCreateOrUpdateConfigmap_Update(t *testing.T) {
 func TestRemoveConfigmap(t *testing.T) {
 
 }
+
+func TestPopulateCloudClusterInfo(t *testing.T) {
+	os.Setenv(PODNAMESPACE, "ibm-common-services")
+	defer os.Unsetenv(PODNAMESPACE)
+
+	ingressRequest := newFakeIngressRequest()
+	if err := populateCloudClusterInfo(ingressRequest, CNCF, "example.com"); err != nil {
+		t.Fatalf("populateCloudClusterInfo returned unexpected error: %v", err)
+	}
+	info, err := clusterinfo.Get(context.TODO(), ingressRequest.client, "ibm-common-services")
+	if err != nil {
+		t.Fatalf("failure reading cluster info: %v", err)
+	}
+	if info.SchemaVersion != clusterinfo.SchemaVersion || info.ClusterAddress != "cp-console.example.com" || info.ProxyHTTPSPort != 443 {
+		t.Errorf("expected the cluster info of the CNCF domain, got %+v", info)
+	}
+
+	// The cluster info is not written without the domain of the console.
+	ingressRequest = newFakeIngressRequest()
+	if err := populateCloudClusterInfo(ingressRequest, CNCF, ""); err != nil {
+		t.Fatalf("populateCloudClusterInfo returned unexpected error: %v", err)
+	}
+	if _, err := clusterinfo.Get(context.TODO(), ingressRequest.client, "ibm-common-services"); err == nil {
+		t.Error("expected no cluster info without domain name")
+	}
+
+	ingressRequest = newFakeIngressRequest()
+	if err := populateCloudClusterInfo(ingressRequest, CNCF, "example..com"); err == nil {
+		t.Error("expected an error with an invalid domain name")
+	}
+}
@@ -15,6 +15,8 @@
 //
 package handler
 
+import "github.com/IBM/ibm-management-ingress-operator/clusterinfo"
+
 const (
 	AppName            string = "management-ingress"
 	ServiceName        string = "icp-management-ingress"
@@ -27,12 +29,12 @@ const (
 	RouteCert          string = "route-cert"
 	RouteSecret        string = "route-tls-secret"
 	// csPriorityClassName       string = "cs-priority-class"
-	ConfigUpdateAnnotationKey string = "management-ingress.operator.k8s.io/config-updated"
-	SCCName                   string = "management-ingress-scc"
-	BindInfoConfigMap         string = "management-ingress-info"
-
-	DefaultCAIssuerName string = "cs-ca-issuer"
-	DefaultCAIssuerKind string = "Issuer"
+	SpecHashAnnotationKey       string = "management-ingress.operator.k8s.io/spec-hash"
+	ConfigChecksumAnnotationKey string = "management-ingress.operator.k8s.io/config-checksum"
+	TLSChecksumAnnotationKey    string = "management-ingress.operator.k8s.io/tls-checksum"
+	AuthChecksumAnnotationKey   string = "management-ingress.operator.k8s.io/auth-checksum"
+	SCCName                     string = "management-ingress-scc"
+	BindInfoConfigMap           string = "management-ingress-info"
 
 	// name for namespace scope configmap
 	NamespaceScopeConfigMap string = "namespace-scope"
@@ -47,45 +49,56 @@ const (
 	ProductMetric string = "FREE"
 
 	// ClusterConfigName ... ibmcloud-cluster-info
-	ClusterConfigName string = "ibmcloud-cluster-info"
-	ClusterAddr       string = "cluster_address"
-	ClusterCADomain   string = "cluster_ca_domain"
-	RouteBaseDomain   string = "openshift_router_base_domain"
-	ClusterEP         string = "cluster_endpoint"
+	ClusterConfigName string = clusterinfo.ConfigMapName
+	ClusterAddr       string = clusterinfo.ClusterAddressKey
+	ClusterCADomain   string = clusterinfo.ClusterCADomainKey
+	RouteBaseDomain   string = clusterinfo.RouterBaseDomainKey
+	ClusterEP         string = clusterinfo.ClusterEndpointKey
 
-	RouteHTTPPort      string = "cluster_router_http_port"
+	RouteHTTPPort      string = clusterinfo.RouterHTTPPortKey
 	RouteHTTPPortValue string = "80"
 	RouteHTTPPortEnv   string = "ROUTE_HTTP_PORT"
 
-	RouteHTTPSPort      string = "cluster_router_https_port"
+	RouteHTTPSPort      string = clusterinfo.RouterHTTPSPortKey
 	RouteHTTPSPortValue string = "443"
 	RouteHTTPSPortEnv   string = "ROUTE_HTTPS_PORT"
 
-	ClusterName      string = "cluster_name"
+	ClusterName      string = clusterinfo.ClusterNameKey
 	ClusterNameValue string = "mycluster"
 	ClusterNameEnv   string = "CLUSTER_NAME"
 
-	CSVersion      string = "version"
+	CSVersion      string = clusterinfo.VersionKey
 	CSVersionValue string = "3.8.0"
 	CSVersionEnv   string = "VERSION"
 
 	PODNAMESPACE string = "POD_NAMESPACE"
 
-	ClusterSecretName string = "ibmcloud-cluster-ca-cert"
+	// Default operand image, overridden by the image fields of the CR
+	ImageEnv        string = "ICP_MANAGEMENT_INGRESS_IMAGE"
+	VersionLabelKey string = "app.kubernetes.io/version"
+
+	ClusterSecretName string = clusterinfo.CASecretName
 
-	ClusterAPIServerHost string = "cluster_kube_apiserver_host"
-	ClusterAPIServerPort string = "cluster_kube_apiserver_port"
+	// Finalizer which removes the objects that cannot be garbage collected through owner references
+	FinalizerName string = "managementingress.operator.ibm.com/cleanup"
+
+	ClusterAPIServerHost string = clusterinfo.APIServerHostKey
+	ClusterAPIServerPort string = clusterinfo.APIServerPortKey
 	ConsoleCfg           string = "console-config"
 	ConsoleNS            string = "openshift-console"
 	ConsoleCfgYaml       string = "console-config.yaml"
 	ConsoleClusterInfo   string = "clusterInfo"
 	ConsoleMasterURL     string = "masterPublicURL"
-	ProxyAddress         string = "proxy_address"
-	ProxyHTTPPort        string = "proxy_ingress_http_port"
-	ProxyHTTPSPort       string = "proxy_ingress_https_port"
+	ProxyAddress         string = clusterinfo.ProxyAddressKey
+	ProxyHTTPPort        string = clusterinfo.ProxyHTTPPortKey
+	ProxyHTTPSPort       string = clusterinfo.ProxyHTTPSPortKey
 	ProxyRouteName       string = "cp-proxy"
 	ProxyServiceName     string = "nginx-ingress-controller"
 
+	// Objects of the exposures other than Route
+	LoadBalancerServiceName string = "icp-management-ingress-lb"
+	BackendCAConfigMapName  string = "management-ingress-backend-ca"
+
 	// ibm-cpp-config config map
 	CppConfigName         string = "ibm-cpp-config"
 	KubernetesClusterType string = "kubernetes_cluster_type"
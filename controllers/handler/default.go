@@ -17,15 +17,6 @@ package handler
 
 import (
 	rbac "k8s.io/api/rbac/v1"
-	"k8s.io/apimachinery/pkg/api/resource"
-)
-
-var (
-	defaultMemoryRequest resource.Quantity = resource.MustParse("300Mi")
-	defaultCPURequest    resource.Quantity = resource.MustParse("50m")
-
-	defaultMemoryLimit resource.Quantity = resource.MustParse("512Mi")
-	defaultCPULimit    resource.Quantity = resource.MustParse("200m")
 )
 
 var defaultRules = []rbac.PolicyRule{
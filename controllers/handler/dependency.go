@@ -0,0 +1,67 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	core "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+const (
+	// DependencyRequeueInterval is how long to wait before checking a dependency that is not ready yet.
+	DependencyRequeueInterval = 10 * time.Second
+	// DependencyTimeout is how long a dependency may stay unready before the operand is reported as failed.
+	DependencyTimeout = 10 * time.Minute
+)
+
+// DependencyNotReadyError is returned by a reconcile phase when an object it depends on,
+// e.g. a secret written by cert-manager, does not exist yet. It is not a failure: the
+// reconcile stops and is requeued until the dependency shows up.
+type DependencyNotReadyError struct {
+	Kind      string
+	Name      string
+	Namespace string
+}
+
+func (e *DependencyNotReadyError) Error() string {
+	return fmt.Sprintf("waiting for %s %s/%s", e.Kind, e.Namespace, e.Name)
+}
+
+// IsDependencyNotReady reports whether err, or any error it wraps, is a DependencyNotReadyError.
+func IsDependencyNotReady(err error) bool {
+	var notReady *DependencyNotReadyError
+	return errors.As(err, &notReady)
+}
+
+// getDependencySecret gets a secret in the namespace of the CR which is created by someone else.
+// A missing secret is reported as a DependencyNotReadyError.
+func (ingressRequest *IngressRequest) getDependencySecret(name string) (*core.Secret, error) {
+	ns := ingressRequest.managementIngress.ObjectMeta.Namespace
+	secret := &core.Secret{}
+
+	if err := ingressRequest.Get(name, ns, secret); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, &DependencyNotReadyError{Kind: "Secret", Name: name, Namespace: ns}
+		}
+		return nil, fmt.Errorf("failure getting secret %s: %v", name, err)
+	}
+
+	return secret, nil
+}
@@ -17,6 +17,8 @@ package handler
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/json"
 	"fmt"
 	"net"
 	"os"
@@ -30,9 +32,10 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/klog"
-	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
+	operatorv1alpha1 "github.com/IBM/ibm-management-ingress-operator/api/v1alpha1"
 	"github.com/IBM/ibm-management-ingress-operator/utils"
 )
 
@@ -42,10 +45,12 @@ const (
 )
 
 //NewDeployment stubs an instance of a deployment
-func NewDeployment(name string, namespace string, replicas int32, podSpec core.PodSpec) *apps.Deployment {
+func NewDeployment(name string, namespace string, labels map[string]string, replicas int32, podSpec core.PodSpec) *apps.Deployment {
 
-	labels := GetCommonLabels()
-	podLabels := GetCommonLabels()
+	podLabels := map[string]string{}
+	for k, v := range labels {
+		podLabels[k] = v
+	}
 	commAnnotations := GetCommonAnnotations()
 	podAnnotations := map[string]string{
 		"scheduler.alpha.kubernetes.io/critical-pod": "",
@@ -88,24 +93,16 @@ func NewDeployment(name string, namespace string, replicas int32, podSpec core.P
 	}
 }
 
-func newPodSpec(img, clusterDomain string, resources *core.ResourceRequirements, nodeSelector map[string]string,
-	tolerations []core.Toleration, allowedHostHeader string, fipsEnabled bool) core.PodSpec {
+func newPodSpec(img, clusterDomain, tlsSecret string, resources *core.ResourceRequirements, nodeSelector map[string]string,
+	tolerations []core.Toleration, allowedHostHeader string, fipsEnabled bool, imagePullSecrets []core.LocalObjectReference,
+	scheduling *operatorv1alpha1.Scheduling, names operatorv1alpha1.ObjectNames) core.PodSpec {
 	namespace, found := os.LookupEnv("WATCH_NAMESPACE")
 	if !found {
 		klog.Error("failure getting watch namespace")
 		os.Exit(1)
 	}
 	if resources == nil {
-		resources = &core.ResourceRequirements{
-			Limits: core.ResourceList{
-				core.ResourceMemory: defaultMemoryLimit,
-				core.ResourceCPU:    defaultCPULimit,
-			},
-			Requests: core.ResourceList{
-				core.ResourceMemory: defaultMemoryRequest,
-				core.ResourceCPU:    defaultCPURequest,
-			},
-		}
+		resources = operatorv1alpha1.DefaultResources()
 	}
 
 	container := core.Container{
@@ -130,8 +127,8 @@ func newPodSpec(img, clusterDomain string, resources *core.ResourceRequirements,
 
 	container.Command = []string{
 		"/icp-management-ingress",
-		"--default-ssl-certificate=$(POD_NAMESPACE)/icp-management-ingress-tls-secret",
-		"--configmap=$(POD_NAMESPACE)/" + ConfigName,
+		"--default-ssl-certificate=$(POD_NAMESPACE)/" + tlsSecret,
+		"--configmap=$(POD_NAMESPACE)/" + names.ConfigMap,
 		"--http-port=8080",
 		"--https-port=8443",
 	}
@@ -220,75 +217,17 @@ func newPodSpec(img, clusterDomain string, resources *core.ResourceRequirements,
 		},
 	)
 
-	matchExpressions := getCommonMatchExpressions()
-	podAffinityTerm := core.PodAffinityTerm{
-		LabelSelector: &metav1.LabelSelector{
-			MatchExpressions: matchExpressions,
-		},
-		TopologyKey: "kubernetes.io/hostname",
-	}
-
-	weightedPodAffinityTerm := core.WeightedPodAffinityTerm{
-		PodAffinityTerm: podAffinityTerm,
-		Weight:          100,
-	}
-
-	podAntiAffinity := &core.PodAntiAffinity{
-		PreferredDuringSchedulingIgnoredDuringExecution: []core.WeightedPodAffinityTerm{weightedPodAffinityTerm},
-	}
-
-	podAffinity := &core.NodeAffinity{
-		RequiredDuringSchedulingIgnoredDuringExecution: &core.NodeSelector{
-			NodeSelectorTerms: []core.NodeSelectorTerm{
-				{
-					MatchExpressions: []core.NodeSelectorRequirement{
-						{
-							Key:      "kubernetes.io/arch",
-							Operator: core.NodeSelectorOpIn,
-							Values: []string{
-								"amd64",
-								"ppc64le",
-								"s390x",
-							},
-						},
-					},
-				},
-			},
-		},
-	}
-
-	affinity := &core.Affinity{NodeAffinity: podAffinity, PodAntiAffinity: podAntiAffinity}
-
-	spreadConstraints := []core.TopologySpreadConstraint{
-		{
-			MaxSkew:           1,
-			TopologyKey:       "topology.kubernetes.io/zone",
-			WhenUnsatisfiable: core.ScheduleAnyway,
-			LabelSelector: &metav1.LabelSelector{
-				MatchLabels: map[string]string{
-					"app": AppName,
-				},
-			},
-		},
-		{
-			MaxSkew:           1,
-			TopologyKey:       "topology.kubernetes.io/region",
-			WhenUnsatisfiable: core.ScheduleAnyway,
-			LabelSelector: &metav1.LabelSelector{
-				MatchLabels: map[string]string{
-					"app": AppName,
-				},
-			},
-		},
-	}
-
 	podSpec := core.PodSpec{
 		Containers:                []core.Container{container},
 		ServiceAccountName:        ServiceAccountName,
 		NodeSelector:              nodeSelector,
 		Tolerations:               tolerations,
-		Affinity:                  affinity,
-		TopologySpreadConstraints: spreadConstraints,
+		Affinity:                  newAffinity(scheduling, getInstanceSelector(names)),
+		TopologySpreadConstraints: newTopologySpreadConstraints(scheduling, getInstanceSelector(names)),
+		ImagePullSecrets:          imagePullSecrets,
+	}
+	if scheduling != nil {
+		podSpec.PriorityClassName = scheduling.PriorityClassName
 	}
 
 	defaultMode := int32(0644)
@@ -297,14 +236,13 @@ func newPodSpec(img, clusterDomain string, resources *core.ResourceRequirements,
 			Name: "tls-secret",
 			VolumeSource: core.VolumeSource{
 				Secret: &core.SecretVolumeSource{
-					SecretName:  TLSSecretName,
+					SecretName:  tlsSecret,
 					DefaultMode: &defaultMode,
 				},
 			},
 		},
 	}
 
-	// podSpec.PriorityClassName = csPriorityClassName
 	podSpec.TerminationGracePeriodSeconds = utils.GetInt64(30)
 
 	return podSpec
@@ -338,7 +276,7 @@ func getClusterDomain(clusterType string) (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("failure creating or getting cluster client: %v", err)
 	}
-	if err := clusterClient.Get(context.TODO(), types.NamespacedName{Name: "default", Namespace: ""}, dns); err != nil {
+	if err := clusterClient.Get(context.TODO(), types.NamespacedName{Name: DefaultOpenShiftConfigName, Namespace: ""}, dns); err != nil {
 		return "", err
 	}
 
@@ -353,7 +291,14 @@ func getClusterDomain(clusterType string) (string, error) {
 }
 
 func (ingressRequest *IngressRequest) CreateOrUpdateDeployment(clusterType string) error {
-	image := os.Getenv("ICP_MANAGEMENT_INGRESS_IMAGE")
+	image, err := resolveImage(ingressRequest.managementIngress.Spec, os.Getenv(ImageEnv))
+	if err != nil {
+		return fmt.Errorf("failure resolving operand image: %v", err)
+	}
+	klog.Infof("Using image %s for management ingress.", image)
+	ingressRequest.managementIngress.Status.Image = image
+	names := ingressRequest.names()
+
 	var hostHeader string
 	if clusterType == CNCF {
 		dn := ingressRequest.managementIngress.Status.Host
@@ -364,10 +309,10 @@ func (ingressRequest *IngressRequest) CreateOrUpdateDeployment(clusterType strin
 		hostHeader = strings.Join([]string{
 			ingressRequest.managementIngress.Spec.AllowedHostHeader,
 			dn,
-			ServiceName,
+			names.Service,
 			IAMTokenService,
-			strings.Join([]string{ServiceName, ingressRequest.managementIngress.Namespace}, "."),
-			strings.Join([]string{ServiceName, ingressRequest.managementIngress.Namespace, "svc"}, "."),
+			strings.Join([]string{names.Service, ingressRequest.managementIngress.Namespace}, "."),
+			strings.Join([]string{names.Service, ingressRequest.managementIngress.Namespace, "svc"}, "."),
 			strings.Join([]string{IAMTokenService, ingressRequest.managementIngress.Namespace}, "."),
 			strings.Join([]string{IAMTokenService, ingressRequest.managementIngress.Namespace, "svc"}, "."),
 		}, " ")
@@ -375,10 +320,10 @@ func (ingressRequest *IngressRequest) CreateOrUpdateDeployment(clusterType strin
 		hostHeader = strings.Join([]string{
 			ingressRequest.managementIngress.Spec.AllowedHostHeader,
 			ingressRequest.managementIngress.Status.Host,
-			ServiceName,
+			names.Service,
 			IAMTokenService,
-			strings.Join([]string{ServiceName, ingressRequest.managementIngress.Namespace}, "."),
-			strings.Join([]string{ServiceName, ingressRequest.managementIngress.Namespace, "svc"}, "."),
+			strings.Join([]string{names.Service, ingressRequest.managementIngress.Namespace}, "."),
+			strings.Join([]string{names.Service, ingressRequest.managementIngress.Namespace, "svc"}, "."),
 			strings.Join([]string{IAMTokenService, ingressRequest.managementIngress.Namespace}, "."),
 			strings.Join([]string{IAMTokenService, ingressRequest.managementIngress.Namespace, "svc"}, "."),
 		}, " ")
@@ -393,61 +338,98 @@ func (ingressRequest *IngressRequest) CreateOrUpdateDeployment(clusterType strin
 	podSpec := newPodSpec(
 		image,
 		clusterDomain,
+		ingressRequest.tlsSecretName(),
 		ingressRequest.managementIngress.Spec.Resources,
 		ingressRequest.managementIngress.Spec.NodeSelector,
 		ingressRequest.managementIngress.Spec.Tolerations,
 		hostHeader,
 		ingressRequest.managementIngress.Spec.FIPSEnabled,
+		ingressRequest.managementIngress.Spec.ImagePullSecrets,
+		ingressRequest.managementIngress.Spec.Scheduling,
+		names,
 	)
 
-	// Set default Management Ingress replica is 1.
+	// Set default Management Ingress replica is 1, in case the defaulting webhook is not enabled.
 	if ingressRequest.managementIngress.Spec.Replicas == 0 {
-		ingressRequest.managementIngress.Spec.Replicas = 1
+		ingressRequest.managementIngress.Spec.Replicas = operatorv1alpha1.DefaultReplicas
 	}
 
 	ds := NewDeployment(
-		AppName,
+		names.Deployment,
 		ingressRequest.managementIngress.Namespace,
+		ingressRequest.getLabels(),
 		ingressRequest.managementIngress.Spec.Replicas,
 		podSpec)
-
-	if err := controllerutil.SetControllerReference(ingressRequest.managementIngress, ds, ingressRequest.scheme); err != nil {
-		klog.Errorf("Error setting controller reference on Deployment: %v", err)
+	// The autoscaler owns the replicas, applying them would scale the deployment back on every reconcile.
+	if autoscalingEnabled(ingressRequest.managementIngress) {
+		ds.Spec.Replicas = nil
+	}
+	availability := ingressRequest.getAvailability()
+	setRollout(ds, availability)
+
+	if version := ingressRequest.managementIngress.Spec.Version; len(version) > 0 {
+		if errs := validation.IsValidLabelValue(version); len(errs) == 0 {
+			ds.Spec.Template.ObjectMeta.Labels[VersionLabelKey] = version
+		} else {
+			klog.Warningf("Not labeling management ingress pods with version %q: %v", version, errs)
+		}
 	}
 
-	err = ingressRequest.Create(ds)
+	checksums, err := ingressRequest.podTemplateChecksums()
 	if err != nil {
-		if !errors.IsAlreadyExists(err) {
-			ingressRequest.recorder.Eventf(ingressRequest.managementIngress, "Warning", "UpdatedDeployment", "Failed to create deployment %q", AppName)
-			return fmt.Errorf("failure creating Deployment: %v", err)
-		}
+		return err
+	}
+	ds.Spec.Template.ObjectMeta.Annotations = utils.AppendAnnotations(ds.Spec.Template.ObjectMeta.Annotations, checksums)
 
-		klog.Infof("Trying to update Deployment: %s as it already existed.", AppName)
-		current := &apps.Deployment{}
-		if err = ingressRequest.Get(AppName, ingressRequest.managementIngress.ObjectMeta.Namespace, current); err != nil {
-			return fmt.Errorf("failure getting %q Deployment for %q: %v", AppName, ingressRequest.managementIngress.Name, err)
-		}
+	if _, err := ingressRequest.Apply(ds); err != nil {
+		return fmt.Errorf("failure applying %q Deployment for %q: %v", names.Deployment, ingressRequest.managementIngress.Name, err)
+	}
 
-		desired, different := utils.IsDeploymentDifferent(current, ds)
-		if !different {
-			klog.Infof("No change found from the deployment: %s, skip updating current deployment.", AppName)
-			return nil
-		}
-		klog.Infof("Found change for deployment: %s, trying to update it.", AppName)
-		err = ingressRequest.Update(desired)
-		if err != nil {
-			ingressRequest.recorder.Eventf(ingressRequest.managementIngress, "Warning", "UpdatedDeployment", "Failed to update deployment: %s", AppName)
-			return fmt.Errorf("failure updating %q Deployment for %q: %v", AppName, ingressRequest.managementIngress.Name, err)
-		}
+	return ingressRequest.createOrUpdatePodDisruptionBudget(availability)
+}
 
-		ingressRequest.recorder.Eventf(ingressRequest.managementIngress, "Normal", "UpdatedDeployment", "Successfully updated deployment %q", AppName)
-		return nil
-	}
+// podTemplateChecksums returns the checksums of the config and the secrets which the management ingress
+// pods read on startup. They annotate the pod template, so the pods are rolled out once when any of them
+// changes, and not at all when they stay the same.
+func (ingressRequest *IngressRequest) podTemplateChecksums() (map[string]string, error) {
+	ns := ingressRequest.managementIngress.Namespace
 
-	klog.Infof("Created Deployment: %s.", AppName)
-	ingressRequest.recorder.Eventf(ingressRequest.managementIngress, "Normal", "CreatedDeployment", "Successfully created deployment %q", AppName)
+	tlsSecret, err := ingressRequest.getDependencySecret(ingressRequest.tlsSecretName())
+	if err != nil {
+		return nil, err
+	}
+
+	// Only the keys referenced by the env of the pods. The pods do not start without them,
+	// so they are rolled out when they show up.
+	auth := map[string]string{}
+	authConfig := &core.ConfigMap{}
+	if err := ingressRequest.Get(PlatformAuthConfigmap, ns, authConfig); err == nil {
+		auth["OIDC_ISSUER_URL"] = authConfig.Data["OIDC_ISSUER_URL"]
+	} else if !errors.IsNotFound(err) {
+		return nil, fmt.Errorf("failure getting configmap %s: %v", PlatformAuthConfigmap, err)
+	}
+	authSecret := &core.Secret{}
+	if err := ingressRequest.Get(PlatformAuthSecret, ns, authSecret); err == nil {
+		auth["WLP_CLIENT_ID"] = string(authSecret.Data["WLP_CLIENT_ID"])
+	} else if !errors.IsNotFound(err) {
+		return nil, fmt.Errorf("failure getting secret %s: %v", PlatformAuthSecret, err)
+	}
+
+	configData, _ := ingressRequest.ingressConfigData()
+	return map[string]string{
+		ConfigChecksumAnnotationKey: checksum(configData),
+		TLSChecksumAnnotationKey:    checksum(tlsSecret.Data),
+		AuthChecksumAnnotationKey:   checksum(auth),
+	}, nil
+}
 
-	return nil
+// checksum returns the SHA-256 of the JSON encoding of data, which sorts the keys of maps.
+func checksum(data interface{}) string {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		klog.Errorf("Error encoding data for checksum: %v", err)
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(encoded))
 }
 
 //GetDeploymentList lists DS in namespace with given selector
@@ -467,18 +449,15 @@ func (ingressRequest *IngressRequest) GetDeploymentList(selector map[string]stri
 	return list, err
 }
 
-func (ingressRequest *IngressRequest) GetDeploymentPods(selector map[string]string) (*core.PodList, error) {
-	list := &core.PodList{
-		TypeMeta: metav1.TypeMeta{
-			Kind:       "Pod",
-			APIVersion: apps.SchemeGroupVersion.String(),
-		},
+// GetDeploymentPods lists the pods of the CR from the API server. Pods are not in the cache of the
+// manager, which would otherwise watch every pod of the watched namespaces for the status of the CR.
+func (ingressRequest *IngressRequest) GetDeploymentPods() (*core.PodList, error) {
+	list := &core.PodList{}
+	selector, err := metav1.LabelSelectorAsSelector(getInstanceSelector(ingressRequest.names()))
+	if err != nil {
+		return nil, err
 	}
 
-	err := ingressRequest.List(
-		selector,
-		list,
-	)
-
+	err = ingressRequest.ListUncached(selector, list)
 	return list, err
 }
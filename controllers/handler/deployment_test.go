@@ -17,6 +17,9 @@ package handler
 
 import (
 	"testing"
+
+	core "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func TestCreateOrUpdateDeployment_Create(t *testing.T) {
@@ -30,3 +33,38 @@ func TestCreateOrUpdateDeployment_Update(t *testing.T) {
 func TestRemoveDeployment(t *testing.T) {
 
 }
+
+func TestPodTemplateChecksums(t *testing.T) {
+	if _, err := newFakeIngressRequest().podTemplateChecksums(); !IsDependencyNotReady(err) {
+		t.Errorf("expected to wait for the tls secret, got %v", err)
+	}
+
+	secret := &core.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: TLSSecretName, Namespace: "ibm-common-services"},
+		Data:       map[string][]byte{core.TLSCertKey: []byte("cert"), core.TLSPrivateKeyKey: []byte("key")},
+	}
+	ingressRequest := newFakeIngressRequest(secret)
+	checksums, err := ingressRequest.podTemplateChecksums()
+	if err != nil {
+		t.Fatalf("podTemplateChecksums returned unexpected error: %v", err)
+	}
+	if again, _ := ingressRequest.podTemplateChecksums(); again[TLSChecksumAnnotationKey] != checksums[TLSChecksumAnnotationKey] {
+		t.Errorf("expected stable checksums, got %v and %v", checksums, again)
+	}
+
+	secret.Data[core.TLSCertKey] = []byte("renewed")
+	if err := ingressRequest.Update(secret); err != nil {
+		t.Fatalf("failure updating secret: %v", err)
+	}
+	ingressRequest.managementIngress.Spec.Config = map[string]string{"ssl-ciphers": "HIGH"}
+	renewed, _ := ingressRequest.podTemplateChecksums()
+	if renewed[TLSChecksumAnnotationKey] == checksums[TLSChecksumAnnotationKey] {
+		t.Errorf("expected the tls checksum to change with the secret")
+	}
+	if renewed[ConfigChecksumAnnotationKey] == checksums[ConfigChecksumAnnotationKey] {
+		t.Errorf("expected the config checksum to change with the config")
+	}
+	if renewed[AuthChecksumAnnotationKey] != checksums[AuthChecksumAnnotationKey] {
+		t.Errorf("expected the auth checksum to stay the same")
+	}
+}
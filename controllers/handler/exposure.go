@@ -0,0 +1,462 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package handler
+
+import (
+	"fmt"
+	"strings"
+
+	route "github.com/openshift/api/route/v1"
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/klog"
+
+	operatorv1alpha1 "github.com/IBM/ibm-management-ingress-operator/api/v1alpha1"
+)
+
+var (
+	IngressGVK   = schema.GroupVersionKind{Group: "networking.k8s.io", Version: "v1", Kind: "Ingress"}
+	HTTPRouteGVK = schema.GroupVersionKind{Group: "gateway.networking.k8s.io", Version: "v1", Kind: "HTTPRoute"}
+	// TLSRoute and the v1alpha3 BackendTLSPolicy are only in the experimental channel of Gateway API.
+	TLSRouteGVK                 = schema.GroupVersionKind{Group: "gateway.networking.k8s.io", Version: "v1alpha2", Kind: "TLSRoute"}
+	BackendTLSPolicyV1GVK       = schema.GroupVersionKind{Group: "gateway.networking.k8s.io", Version: "v1", Kind: "BackendTLSPolicy"}
+	BackendTLSPolicyV1Alpha3GVK = schema.GroupVersionKind{Group: "gateway.networking.k8s.io", Version: "v1alpha3", Kind: "BackendTLSPolicy"}
+)
+
+const (
+	ReasonListenerCertificate string = "ListenerCertificate"
+	ReasonGatewayPassthrough  string = "GatewayPassthrough"
+	ReasonGatewayKindsServed  string = "GatewayKindsServed"
+	ReasonGatewayKindMissing  string = "GatewayKindMissing"
+)
+
+// ServedGVK returns the first of gvks which is served by the cluster, false when none is.
+func ServedGVK(mapper meta.RESTMapper, gvks ...schema.GroupVersionKind) (schema.GroupVersionKind, bool) {
+	for _, gvk := range gvks {
+		if _, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version); err == nil {
+			return gvk, true
+		}
+	}
+	return schema.GroupVersionKind{}, false
+}
+
+// GetExposureType returns the exposure of the CR. Without one, OpenShift clusters use routes
+// and other clusters expose nothing, as before the exposure was configurable.
+func GetExposureType(instance *operatorv1alpha1.ManagementIngress, clusterType string) operatorv1alpha1.ExposureType {
+	if exposure := instance.Spec.Exposure; exposure != nil && len(exposure.Type) > 0 {
+		return exposure.Type
+	}
+	if clusterType == CNCF {
+		return operatorv1alpha1.ExposureNone
+	}
+	return operatorv1alpha1.ExposureRoute
+}
+
+func (ingressRequest *IngressRequest) tlsTermination() operatorv1alpha1.TLSTermination {
+	exposure := ingressRequest.managementIngress.Spec.Exposure
+	if exposure != nil && len(exposure.TLSTermination) > 0 {
+		return exposure.TLSTermination
+	}
+	if exposure != nil && exposure.Type == operatorv1alpha1.ExposureLoadBalancer {
+		return operatorv1alpha1.TLSTerminationPassthrough
+	}
+	return operatorv1alpha1.TLSTerminationReencrypt
+}
+
+func (ingressRequest *IngressRequest) exposureAnnotations() map[string]string {
+	annotations := map[string]string{}
+	if exposure := ingressRequest.managementIngress.Spec.Exposure; exposure != nil {
+		for k, v := range exposure.Annotations {
+			annotations[k] = v
+		}
+	}
+	return annotations
+}
+
+// getExposureProxyHost returns the host of the proxy. On OpenShift it is derived from the router domain,
+// elsewhere from the domain name of the cluster.
+func (ingressRequest *IngressRequest) getExposureProxyHost(clusterType, domainName string) (string, error) {
+	if clusterType != CNCF {
+		return ingressRequest.GetProxyRouteHost()
+	}
+	if proxyHost := ingressRequest.managementIngress.Spec.ProxyRouteHost; len(proxyHost) > 0 {
+		return proxyHost, nil
+	}
+	return strings.Join([]string{ingressRequest.names().ProxyRoute, domainName}, "."), nil
+}
+
+// CreateOrUpdateExposure exposes the console and the proxy with the exposure of the CR.
+// Objects of an exposure used before are removed.
+func (ingressRequest *IngressRequest) CreateOrUpdateExposure(clusterType, domainName string) error {
+	instance := ingressRequest.managementIngress
+	exposure := GetExposureType(instance, clusterType)
+
+	if previous := instance.Status.Exposure; len(previous) > 0 && previous != exposure {
+		klog.Infof("Exposure of managementingress %s/%s changed from %s to %s", instance.Namespace, instance.Name, previous, exposure)
+		if err := ingressRequest.removeExposure(previous); err != nil {
+			return err
+		}
+	}
+
+	var err error
+	switch exposure {
+	case operatorv1alpha1.ExposureRoute:
+		if clusterType == CNCF {
+			err = fmt.Errorf("exposure %s is only supported on OpenShift", exposure)
+		} else {
+			err = ingressRequest.CreateOrUpdateRoute()
+		}
+	case operatorv1alpha1.ExposureIngress:
+		err = ingressRequest.createOrUpdateIngresses(clusterType, domainName)
+	case operatorv1alpha1.ExposureGateway:
+		err = ingressRequest.createOrUpdateGatewayRoutes(clusterType, domainName)
+	case operatorv1alpha1.ExposureLoadBalancer:
+		err = ingressRequest.createOrUpdateLoadBalancer()
+	case operatorv1alpha1.ExposureNone:
+	default:
+		err = fmt.Errorf("unknown exposure %s", exposure)
+	}
+	if err != nil {
+		return err
+	}
+
+	instance.Status.Exposure = exposure
+	return nil
+}
+
+// NewIngress stubs a networking.k8s.io/v1 Ingress. The annotations select passthrough or reencrypt
+// on ingress-nginx, the same as the termination of a route. For reencrypt, tlsSecret is served to the
+// clients and the CA of backendSecret verifies the service.
+func NewIngress(name, namespace, host, serviceName, ingressClassName, tlsSecret, backendSecret string, termination operatorv1alpha1.TLSTermination, annotations map[string]string) *unstructured.Unstructured {
+	annotations["nginx.ingress.kubernetes.io/backend-protocol"] = "HTTPS"
+	if termination == operatorv1alpha1.TLSTerminationPassthrough {
+		annotations["nginx.ingress.kubernetes.io/ssl-passthrough"] = "true"
+	} else {
+		// Verify management ingress with the CA of its own certificate, like the destination CA of a route.
+		annotations["nginx.ingress.kubernetes.io/proxy-ssl-secret"] = strings.Join([]string{namespace, backendSecret}, "/")
+		annotations["nginx.ingress.kubernetes.io/proxy-ssl-verify"] = "on"
+		annotations["nginx.ingress.kubernetes.io/proxy-ssl-name"] = strings.Join([]string{serviceName, namespace, "svc"}, ".")
+	}
+
+	spec := map[string]interface{}{
+		"rules": []interface{}{
+			map[string]interface{}{
+				"host": host,
+				"http": map[string]interface{}{
+					"paths": []interface{}{
+						map[string]interface{}{
+							"path":     "/",
+							"pathType": "Prefix",
+							"backend": map[string]interface{}{
+								"service": map[string]interface{}{
+									"name": serviceName,
+									"port": map[string]interface{}{"name": "https"},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	if len(ingressClassName) > 0 {
+		spec["ingressClassName"] = ingressClassName
+	}
+	if termination == operatorv1alpha1.TLSTerminationReencrypt && len(tlsSecret) > 0 {
+		spec["tls"] = []interface{}{
+			map[string]interface{}{
+				"hosts":      []interface{}{host},
+				"secretName": tlsSecret,
+			},
+		}
+	}
+
+	return newUnstructured(IngressGVK, name, namespace, annotations, spec)
+}
+
+func (ingressRequest *IngressRequest) createOrUpdateIngresses(clusterType, domainName string) error {
+	ns := ingressRequest.managementIngress.Namespace
+	names := ingressRequest.names()
+	ingressClassName := ingressRequest.managementIngress.Spec.Exposure.IngressClassName
+
+	consoleIngress := NewIngress(names.ConsoleRoute, ns, ingressRequest.managementIngress.Status.Host, names.Service,
+		ingressClassName, ingressRequest.routeSecretName(), ingressRequest.tlsSecretName(), ingressRequest.tlsTermination(), ingressRequest.exposureAnnotations())
+	if _, err := ingressRequest.Apply(consoleIngress); err != nil {
+		return err
+	}
+
+	// The proxy is always passed through, the same as the cp-proxy route.
+	proxyHost, err := ingressRequest.getExposureProxyHost(clusterType, domainName)
+	if err != nil {
+		return fmt.Errorf("failure getting proxy host: %v", err)
+	}
+	proxyIngress := NewIngress(names.ProxyRoute, ns, proxyHost, ProxyServiceName,
+		ingressClassName, "", "", operatorv1alpha1.TLSTerminationPassthrough, ingressRequest.exposureAnnotations())
+
+	_, err = ingressRequest.Apply(proxyIngress)
+	return err
+}
+
+// NewGatewayRoute stubs a Gateway API route to the https port of a service, an HTTPRoute for
+// reencrypt and a TLSRoute for passthrough.
+func NewGatewayRoute(name, namespace, host, serviceName string, gateway *operatorv1alpha1.GatewayReference, termination operatorv1alpha1.TLSTermination, annotations map[string]string) *unstructured.Unstructured {
+	parentRef := map[string]interface{}{"name": gateway.Name}
+	if len(gateway.Namespace) > 0 {
+		parentRef["namespace"] = gateway.Namespace
+	}
+	if len(gateway.SectionName) > 0 {
+		parentRef["sectionName"] = gateway.SectionName
+	}
+
+	spec := map[string]interface{}{
+		"parentRefs": []interface{}{parentRef},
+		"hostnames":  []interface{}{host},
+		"rules": []interface{}{
+			map[string]interface{}{
+				"backendRefs": []interface{}{
+					map[string]interface{}{"name": serviceName, "port": int64(443)},
+				},
+			},
+		},
+	}
+
+	gvk := HTTPRouteGVK
+	if termination == operatorv1alpha1.TLSTerminationPassthrough {
+		gvk = TLSRouteGVK
+	}
+	return newUnstructured(gvk, name, namespace, annotations, spec)
+}
+
+// NewBackendTLSPolicy stubs a policy which makes the gateway connect to the management ingress service
+// with TLS, verified with the CA in the caConfigMap. Both versions of the policy have the same schema
+// for the fields set by the operator.
+func NewBackendTLSPolicy(gvk schema.GroupVersionKind, name, namespace, serviceName, caConfigMap string) *unstructured.Unstructured {
+	spec := map[string]interface{}{
+		"targetRefs": []interface{}{
+			map[string]interface{}{"group": "", "kind": "Service", "name": serviceName},
+		},
+		"validation": map[string]interface{}{
+			"caCertificateRefs": []interface{}{
+				map[string]interface{}{"group": "", "kind": "ConfigMap", "name": caConfigMap},
+			},
+			"hostname": strings.Join([]string{serviceName, namespace, "svc"}, "."),
+		},
+	}
+	return newUnstructured(gvk, name, namespace, map[string]string{}, spec)
+}
+
+// servedGatewayGVK returns the first of gvks which is served by the cluster. Without a REST mapper the
+// first one is assumed to be served along with the Gateway API.
+func (ingressRequest *IngressRequest) servedGatewayGVK(gvks ...schema.GroupVersionKind) (schema.GroupVersionKind, bool) {
+	if ingressRequest.mapper == nil {
+		return gvks[0], ingressRequest.gatewayAPI
+	}
+	return ServedGVK(ingressRequest.mapper, gvks...)
+}
+
+// setGatewayKindsCondition reports the kinds of the Gateway exposure which are not served by the cluster,
+// e.g. those only in the experimental channel of Gateway API, and what is not exposed without them.
+func (ingressRequest *IngressRequest) setGatewayKindsCondition(missing schema.GroupVersionKind, impact string) {
+	if missing.Empty() {
+		ingressRequest.setCondition(operatorv1alpha1.GatewayAPIPhase, operatorv1alpha1.GatewayKindNotServed, operatorv1alpha1.ConditionFalse, ReasonGatewayKindsServed, "")
+		return
+	}
+	message := fmt.Sprintf("%s %s is not served on this cluster, %s", missing.GroupVersion(), missing.Kind, impact)
+	ingressRequest.setCondition(operatorv1alpha1.GatewayAPIPhase, operatorv1alpha1.GatewayKindNotServed, operatorv1alpha1.ConditionTrue, ReasonGatewayKindMissing, message)
+}
+
+func (ingressRequest *IngressRequest) createOrUpdateGatewayRoutes(clusterType, domainName string) error {
+	ns := ingressRequest.managementIngress.Namespace
+	names := ingressRequest.names()
+	gateway := ingressRequest.managementIngress.Spec.Exposure.Gateway
+	if gateway == nil || len(gateway.Name) == 0 {
+		return fmt.Errorf("exposure %s requires spec.exposure.gateway", operatorv1alpha1.ExposureGateway)
+	}
+	if !ingressRequest.gatewayAPI {
+		return fmt.Errorf("exposure %s requires the %s API, which is not served on this cluster", operatorv1alpha1.ExposureGateway, GatewayGroup)
+	}
+	termination := ingressRequest.tlsTermination()
+	ingressRequest.setListenerCondition(gateway, termination)
+
+	// The console route is required, an HTTPRoute with a BackendTLSPolicy for reencrypt or a TLSRoute for passthrough.
+	consoleGVKs := [][]schema.GroupVersionKind{{TLSRouteGVK}}
+	if termination == operatorv1alpha1.TLSTerminationReencrypt {
+		consoleGVKs = [][]schema.GroupVersionKind{{HTTPRouteGVK}, {BackendTLSPolicyV1GVK, BackendTLSPolicyV1Alpha3GVK}}
+	}
+	var policyGVK schema.GroupVersionKind
+	for _, gvks := range consoleGVKs {
+		gvk, served := ingressRequest.servedGatewayGVK(gvks...)
+		if !served {
+			impact := fmt.Sprintf("the console cannot be exposed with tlsTermination %s", termination)
+			ingressRequest.setGatewayKindsCondition(gvks[len(gvks)-1], impact)
+			return fmt.Errorf("exposure %s with tlsTermination %s requires %s, which is not served on this cluster", operatorv1alpha1.ExposureGateway, termination, gvks[0].Kind)
+		}
+		if gvk.Kind == BackendTLSPolicyV1GVK.Kind {
+			policyGVK = gvk
+		}
+	}
+
+	if termination == operatorv1alpha1.TLSTerminationReencrypt {
+		// Gateway API references the CA of a backend from a configmap.
+		ingressSecret, err := ingressRequest.getDependencySecret(ingressRequest.tlsSecretName())
+		if err != nil {
+			return err
+		}
+		caConfigMap := NewConfigMap(names.BackendCAConfigMap, ns, map[string]string{"ca.crt": string(getCACert(ingressSecret))})
+		if err := syncConfigmap(ingressRequest, caConfigMap); err != nil {
+			return err
+		}
+		if _, err := ingressRequest.Apply(NewBackendTLSPolicy(policyGVK, names.Deployment, ns, names.Service, names.BackendCAConfigMap)); err != nil {
+			return err
+		}
+	}
+
+	consoleRoute := NewGatewayRoute(names.ConsoleRoute, ns, ingressRequest.managementIngress.Status.Host, names.Service,
+		gateway, termination, ingressRequest.exposureAnnotations())
+	if _, err := ingressRequest.Apply(consoleRoute); err != nil {
+		return err
+	}
+
+	// The proxy is always passed through, it is left out without TLSRoute rather than failing the exposure.
+	if _, served := ingressRequest.servedGatewayGVK(TLSRouteGVK); !served {
+		klog.Warningf("Not exposing the proxy of managementingress %s/%s, %s is not served", ns, ingressRequest.managementIngress.Name, TLSRouteGVK.Kind)
+		ingressRequest.setGatewayKindsCondition(TLSRouteGVK, "the proxy is not exposed")
+		return nil
+	}
+	ingressRequest.setGatewayKindsCondition(schema.GroupVersionKind{}, "")
+
+	proxyHost, err := ingressRequest.getExposureProxyHost(clusterType, domainName)
+	if err != nil {
+		return fmt.Errorf("failure getting proxy host: %v", err)
+	}
+	proxyRoute := NewGatewayRoute(names.ProxyRoute, ns, proxyHost, ProxyServiceName,
+		gateway, operatorv1alpha1.TLSTerminationPassthrough, ingressRequest.exposureAnnotations())
+
+	_, err = ingressRequest.Apply(proxyRoute)
+	return err
+}
+
+// setListenerCondition reports the certificate of the console route, which the listener of the gateway
+// serves when it terminates TLS. The operator does not manage the gateway, so it is up to its owner to
+// reference the secret, with a ReferenceGrant when the gateway is in another namespace.
+func (ingressRequest *IngressRequest) setListenerCondition(gateway *operatorv1alpha1.GatewayReference, termination operatorv1alpha1.TLSTermination) {
+	instance := ingressRequest.managementIngress
+	if termination == operatorv1alpha1.TLSTerminationPassthrough {
+		ingressRequest.setCondition(operatorv1alpha1.GatewayListenerPhase, operatorv1alpha1.ListenerCertificateRequired, operatorv1alpha1.ConditionFalse, ReasonGatewayPassthrough, "")
+		return
+	}
+
+	gatewayNamespace := gateway.Namespace
+	if len(gatewayNamespace) == 0 {
+		gatewayNamespace = instance.Namespace
+	}
+	message := fmt.Sprintf("the listener of gateway %s/%s for host %s must reference secret %s/%s in its certificateRefs",
+		gatewayNamespace, gateway.Name, instance.Status.Host, instance.Namespace, ingressRequest.routeSecretName())
+	ingressRequest.setCondition(operatorv1alpha1.GatewayListenerPhase, operatorv1alpha1.ListenerCertificateRequired, operatorv1alpha1.ConditionTrue, ReasonListenerCertificate, message)
+}
+
+// createOrUpdateLoadBalancer exposes the https port of management ingress with a LoadBalancer service.
+// The load balancer passes the TLS connection through, so only the console is exposed.
+func (ingressRequest *IngressRequest) createOrUpdateLoadBalancer() error {
+	if ingressRequest.tlsTermination() != operatorv1alpha1.TLSTerminationPassthrough {
+		return fmt.Errorf("exposure %s only supports tlsTermination %s", operatorv1alpha1.ExposureLoadBalancer, operatorv1alpha1.TLSTerminationPassthrough)
+	}
+
+	service := NewService(
+		ingressRequest.names().LoadBalancerService,
+		ingressRequest.managementIngress.Namespace,
+		ingressRequest.getLabels(),
+		[]core.ServicePort{
+			{
+				Name:     "https",
+				Port:     443,
+				Protocol: core.ProtocolTCP,
+				TargetPort: intstr.IntOrString{
+					Type:   intstr.String,
+					StrVal: "https",
+				},
+			},
+		})
+	service.ObjectMeta.Annotations = ingressRequest.exposureAnnotations()
+	service.Spec.Type = core.ServiceTypeLoadBalancer
+
+	_, err := ingressRequest.Apply(service)
+	return err
+}
+
+// removeExposure deletes the objects created for an exposure which is no longer used.
+func (ingressRequest *IngressRequest) removeExposure(exposure operatorv1alpha1.ExposureType) error {
+	ns := ingressRequest.managementIngress.Namespace
+	names := ingressRequest.names()
+
+	var objects []runtime.Object
+	switch exposure {
+	case operatorv1alpha1.ExposureRoute:
+		for _, name := range []string{names.ConsoleRoute, names.ProxyRoute} {
+			objects = append(objects, &route.Route{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns}})
+		}
+	case operatorv1alpha1.ExposureIngress:
+		for _, name := range []string{names.ConsoleRoute, names.ProxyRoute} {
+			objects = append(objects, newUnstructured(IngressGVK, name, ns, nil, nil))
+		}
+	case operatorv1alpha1.ExposureGateway:
+		for _, name := range []string{names.ConsoleRoute, names.ProxyRoute} {
+			objects = append(objects, newUnstructured(HTTPRouteGVK, name, ns, nil, nil), newUnstructured(TLSRouteGVK, name, ns, nil, nil))
+		}
+		objects = append(objects, newUnstructured(BackendTLSPolicyV1GVK, names.Deployment, ns, nil, nil),
+			newUnstructured(BackendTLSPolicyV1Alpha3GVK, names.Deployment, ns, nil, nil),
+			&core.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: names.BackendCAConfigMap, Namespace: ns}})
+		delete(ingressRequest.managementIngress.Status.Conditions, operatorv1alpha1.GatewayListenerPhase)
+		delete(ingressRequest.managementIngress.Status.Conditions, operatorv1alpha1.GatewayAPIPhase)
+	case operatorv1alpha1.ExposureLoadBalancer:
+		objects = append(objects, &core.Service{ObjectMeta: metav1.ObjectMeta{Name: names.LoadBalancerService, Namespace: ns}})
+	}
+
+	for _, obj := range objects {
+		err := ingressRequest.Delete(obj)
+		if err != nil && !errors.IsNotFound(err) && !meta.IsNoMatchError(err) {
+			return fmt.Errorf("failure removing %s exposure: %v", exposure, err)
+		}
+	}
+	return nil
+}
+
+func newUnstructured(gvk schema.GroupVersionKind, name, namespace string, annotations map[string]string, spec map[string]interface{}) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(gvk)
+	obj.SetName(name)
+	obj.SetNamespace(namespace)
+	if spec != nil {
+		obj.SetLabels(GetCommonLabels())
+		obj.SetAnnotations(annotations)
+		obj.Object["spec"] = spec
+	}
+	return obj
+}
+
+func toStringInterfaceMap(m map[string]string) map[string]interface{} {
+	result := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		result[k] = v
+	}
+	return result
+}
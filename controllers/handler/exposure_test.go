@@ -0,0 +1,166 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package handler
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	operatorv1alpha1 "github.com/IBM/ibm-management-ingress-operator/api/v1alpha1"
+)
+
+func TestGetExposureType(t *testing.T) {
+	instance := &operatorv1alpha1.ManagementIngress{}
+	if exposure := GetExposureType(instance, "cncf"); exposure != operatorv1alpha1.ExposureNone {
+		t.Errorf("expected None on cncf, got %s", exposure)
+	}
+	if exposure := GetExposureType(instance, "ocp"); exposure != operatorv1alpha1.ExposureRoute {
+		t.Errorf("expected Route on OpenShift, got %s", exposure)
+	}
+
+	instance.Spec.Exposure = &operatorv1alpha1.Exposure{Type: operatorv1alpha1.ExposureIngress}
+	if exposure := GetExposureType(instance, "cncf"); exposure != operatorv1alpha1.ExposureIngress {
+		t.Errorf("expected Ingress from the spec, got %s", exposure)
+	}
+}
+
+func TestNewIngress(t *testing.T) {
+	ingress := NewIngress(ConsoleRouteName, "ibm-common-services", "cp-console.example.com", ServiceName,
+		"nginx", RouteSecret, TLSSecretName, operatorv1alpha1.TLSTerminationReencrypt, map[string]string{})
+
+	if ingress.GroupVersionKind() != IngressGVK {
+		t.Errorf("unexpected kind %v", ingress.GroupVersionKind())
+	}
+	if ingress.GetAnnotations()["nginx.ingress.kubernetes.io/proxy-ssl-verify"] != "on" {
+		t.Errorf("expected reencrypt ingress to verify the backend, got annotations %v", ingress.GetAnnotations())
+	}
+	if className, _, _ := unstructured.NestedString(ingress.Object, "spec", "ingressClassName"); className != "nginx" {
+		t.Errorf("expected ingress class nginx, got %q", className)
+	}
+	if tls, _, _ := unstructured.NestedSlice(ingress.Object, "spec", "tls"); len(tls) != 1 {
+		t.Errorf("expected one tls entry for reencrypt, got %v", tls)
+	}
+
+	passthrough := NewIngress(ProxyRouteName, "ibm-common-services", "cp-proxy.example.com", ProxyServiceName,
+		"", "", "", operatorv1alpha1.TLSTerminationPassthrough, map[string]string{})
+	if passthrough.GetAnnotations()["nginx.ingress.kubernetes.io/ssl-passthrough"] != "true" {
+		t.Errorf("expected passthrough annotation, got %v", passthrough.GetAnnotations())
+	}
+	if _, found, _ := unstructured.NestedSlice(passthrough.Object, "spec", "tls"); found {
+		t.Errorf("expected no tls entry for passthrough")
+	}
+}
+
+func TestNewGatewayRoute(t *testing.T) {
+	gateway := &operatorv1alpha1.GatewayReference{Name: "public", Namespace: "gateways"}
+
+	route := NewGatewayRoute(ConsoleRouteName, "ibm-common-services", "cp-console.example.com", ServiceName,
+		gateway, operatorv1alpha1.TLSTerminationReencrypt, map[string]string{})
+	if route.GroupVersionKind() != HTTPRouteGVK {
+		t.Errorf("expected HTTPRoute for reencrypt, got %v", route.GroupVersionKind())
+	}
+	parentRefs, _, _ := unstructured.NestedSlice(route.Object, "spec", "parentRefs")
+	if len(parentRefs) != 1 || parentRefs[0].(map[string]interface{})["namespace"] != "gateways" {
+		t.Errorf("unexpected parentRefs %v", parentRefs)
+	}
+
+	route = NewGatewayRoute(ProxyRouteName, "ibm-common-services", "cp-proxy.example.com", ProxyServiceName,
+		gateway, operatorv1alpha1.TLSTerminationPassthrough, map[string]string{})
+	if route.GroupVersionKind() != TLSRouteGVK {
+		t.Errorf("expected TLSRoute for passthrough, got %v", route.GroupVersionKind())
+	}
+}
+
+func TestSetListenerCondition(t *testing.T) {
+	ingressRequest := newFakeIngressRequest()
+	ingressRequest.managementIngress.Status.Host = "cp-console.example.com"
+	gateway := &operatorv1alpha1.GatewayReference{Name: "public", Namespace: "gateways"}
+
+	ingressRequest.setListenerCondition(gateway, operatorv1alpha1.TLSTerminationReencrypt)
+	conditions := ingressRequest.managementIngress.Status.Conditions[operatorv1alpha1.GatewayListenerPhase]
+	if len(conditions) != 1 || conditions[0].Status != operatorv1alpha1.ConditionTrue ||
+		!strings.Contains(conditions[0].Message, "ibm-common-services/"+RouteSecret) {
+		t.Errorf("expected the ListenerCertificateRequired condition to name the route secret, got %v", conditions)
+	}
+
+	ingressRequest.setListenerCondition(gateway, operatorv1alpha1.TLSTerminationPassthrough)
+	conditions = ingressRequest.managementIngress.Status.Conditions[operatorv1alpha1.GatewayListenerPhase]
+	if len(conditions) != 1 || conditions[0].Status != operatorv1alpha1.ConditionFalse {
+		t.Errorf("expected no listener certificate for passthrough, got %v", conditions)
+	}
+
+	if err := ingressRequest.removeExposure(operatorv1alpha1.ExposureGateway); err != nil {
+		t.Fatalf("removeExposure returned unexpected error: %v", err)
+	}
+	if _, found := ingressRequest.managementIngress.Status.Conditions[operatorv1alpha1.GatewayListenerPhase]; found {
+		t.Errorf("expected the listener condition to be removed with the Gateway exposure")
+	}
+}
+
+func TestCreateOrUpdateExposureWithoutGatewayAPI(t *testing.T) {
+	ingressRequest := newFakeIngressRequest()
+	ingressRequest.managementIngress.Spec.Exposure = &operatorv1alpha1.Exposure{
+		Type:    operatorv1alpha1.ExposureGateway,
+		Gateway: &operatorv1alpha1.GatewayReference{Name: "public"},
+	}
+
+	err := ingressRequest.CreateOrUpdateExposure(CNCF, "example.com")
+	if err == nil || !strings.Contains(err.Error(), GatewayGroup) {
+		t.Errorf("expected an error about the Gateway API, got %v", err)
+	}
+	if ingressRequest.managementIngress.Status.Exposure == operatorv1alpha1.ExposureGateway {
+		t.Errorf("expected the Gateway exposure not to be recorded")
+	}
+}
+
+func TestCreateOrUpdateExposureWithoutTLSRoute(t *testing.T) {
+	// A cluster with the standard channel of Gateway API, which has no TLSRoute.
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(HTTPRouteGVK, meta.RESTScopeNamespace)
+	mapper.Add(BackendTLSPolicyV1GVK, meta.RESTScopeNamespace)
+	if gvk, served := ServedGVK(mapper, BackendTLSPolicyV1Alpha3GVK, BackendTLSPolicyV1GVK); !served || gvk != BackendTLSPolicyV1GVK {
+		t.Errorf("expected BackendTLSPolicy v1 to be served, got %v %v", gvk, served)
+	}
+
+	ingressRequest := newFakeIngressRequest()
+	ingressRequest.SetRESTMapper(mapper)
+	ingressRequest.SetGatewayAPI(true)
+	ingressRequest.managementIngress.Spec.Exposure = &operatorv1alpha1.Exposure{
+		Type:           operatorv1alpha1.ExposureGateway,
+		Gateway:        &operatorv1alpha1.GatewayReference{Name: "public"},
+		TLSTermination: operatorv1alpha1.TLSTerminationPassthrough,
+	}
+
+	err := ingressRequest.CreateOrUpdateExposure(CNCF, "example.com")
+	if err == nil || !strings.Contains(err.Error(), TLSRouteGVK.Kind) {
+		t.Errorf("expected an error about TLSRoute, got %v", err)
+	}
+	conditions := ingressRequest.managementIngress.Status.Conditions[operatorv1alpha1.GatewayAPIPhase]
+	if len(conditions) != 1 || conditions[0].Type != operatorv1alpha1.GatewayKindNotServed ||
+		conditions[0].Status != operatorv1alpha1.ConditionTrue || !strings.Contains(conditions[0].Message, "v1alpha2 TLSRoute") {
+		t.Errorf("expected the GatewayKindNotServed condition to name TLSRoute, got %v", conditions)
+	}
+
+	if err := ingressRequest.removeExposure(operatorv1alpha1.ExposureGateway); err != nil {
+		t.Fatalf("removeExposure returned unexpected error: %v", err)
+	}
+	if _, found := ingressRequest.managementIngress.Status.Conditions[operatorv1alpha1.GatewayAPIPhase]; found {
+		t.Errorf("expected the Gateway API condition to be removed with the Gateway exposure")
+	}
+}
@@ -0,0 +1,127 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package handler
+
+import (
+	"fmt"
+	"strings"
+
+	operatorv1alpha1 "github.com/IBM/ibm-management-ingress-operator/api/v1alpha1"
+)
+
+// imageReference is a container image split into the parts which can be overridden from the CR.
+type imageReference struct {
+	// registry is everything before the last path element, e.g. icr.io/cpopen/cpfs
+	registry string
+	name     string
+	tag      string
+	digest   string
+}
+
+func parseImage(image string) imageReference {
+	ref := imageReference{}
+
+	if pos := strings.Index(image, "@"); pos >= 0 {
+		ref.digest = image[pos+1:]
+		image = image[:pos]
+	}
+	// A colon after the last slash separates the tag, otherwise it belongs to the registry port.
+	if pos := strings.LastIndex(image, ":"); pos > strings.LastIndex(image, "/") {
+		ref.tag = image[pos+1:]
+		image = image[:pos]
+	}
+	if pos := strings.LastIndex(image, "/"); pos >= 0 {
+		ref.registry = image[:pos]
+		ref.name = image[pos+1:]
+	} else {
+		ref.name = image
+	}
+
+	return ref
+}
+
+func (ref imageReference) String() string {
+	image := ref.name
+	if len(ref.registry) > 0 {
+		image = strings.Join([]string{ref.registry, ref.name}, "/")
+	}
+	if len(ref.tag) > 0 {
+		image = image + ":" + ref.tag
+	}
+	if len(ref.digest) > 0 {
+		image = image + "@" + ref.digest
+	}
+	return image
+}
+
+// hasRegistryHost reports whether the first path element of a repository is a registry host.
+func hasRegistryHost(repository string) bool {
+	pos := strings.Index(repository, "/")
+	if pos < 0 {
+		return false
+	}
+	host := repository[:pos]
+	return strings.ContainsAny(host, ".:") || host == "localhost"
+}
+
+// resolveImage composes the operand image from the image fields of the CR, using defaultImage
+// for every part which is not set in the CR.
+func resolveImage(spec operatorv1alpha1.ManagementIngressSpec, defaultImage string) (string, error) {
+	ref := parseImage(strings.TrimSpace(defaultImage))
+
+	if registry := strings.TrimSuffix(strings.TrimSpace(spec.ImageRegistry), "/"); len(registry) > 0 {
+		ref.registry = registry
+	}
+
+	if repository := strings.TrimSpace(spec.Image.Repository); len(repository) > 0 {
+		if hasRegistryHost(repository) {
+			override := parseImage(repository)
+			ref.registry = override.registry
+			ref.name = override.name
+		} else {
+			ref.name = repository
+		}
+	}
+
+	if tag := strings.TrimSpace(spec.Image.Tag); len(tag) > 0 {
+		if strings.HasPrefix(tag, "sha256:") {
+			ref.tag = ""
+			ref.digest = tag
+		} else {
+			ref.tag = tag
+			ref.digest = ""
+		}
+	}
+
+	if digest := strings.TrimSpace(spec.Image.Digest); len(digest) > 0 {
+		if !strings.Contains(digest, ":") {
+			return "", fmt.Errorf("invalid image digest %q, expected <algorithm>:<hex>", digest)
+		}
+		ref.digest = digest
+		if len(spec.Image.Tag) == 0 {
+			ref.tag = ""
+		}
+	}
+
+	if len(ref.name) == 0 {
+		return "", fmt.Errorf("no operand image found, set spec.image.repository or the %s environment variable", ImageEnv)
+	}
+	if len(ref.tag) == 0 && len(ref.digest) == 0 {
+		return "", fmt.Errorf("no tag or digest found for operand image %s", ref.String())
+	}
+
+	return ref.String(), nil
+}
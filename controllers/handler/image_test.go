@@ -0,0 +1,122 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package handler
+
+import (
+	"testing"
+
+	operatorv1alpha1 "github.com/IBM/ibm-management-ingress-operator/api/v1alpha1"
+)
+
+func TestResolveImage(t *testing.T) {
+	const envImage = "icr.io/cpopen/cpfs/icp-management-ingress:2.6.4"
+
+	tests := []struct {
+		name     string
+		spec     operatorv1alpha1.ManagementIngressSpec
+		env      string
+		expected string
+		wantErr  bool
+	}{
+		{
+			name:     "env image only",
+			env:      envImage,
+			expected: envImage,
+		},
+		{
+			name:     "same registry as env",
+			spec:     operatorv1alpha1.ManagementIngressSpec{ImageRegistry: "icr.io/cpopen/cpfs"},
+			env:      envImage,
+			expected: envImage,
+		},
+		{
+			name:     "mirror registry with port",
+			spec:     operatorv1alpha1.ManagementIngressSpec{ImageRegistry: "mirror.local:5000/cpfs/"},
+			env:      envImage,
+			expected: "mirror.local:5000/cpfs/icp-management-ingress:2.6.4",
+		},
+		{
+			name: "repository and tag",
+			spec: operatorv1alpha1.ManagementIngressSpec{
+				ImageRegistry: "mirror.local:5000/cpfs",
+				Image:         operatorv1alpha1.OperandImage{Repository: "custom-ingress", Tag: "2.7.0"},
+			},
+			env:      envImage,
+			expected: "mirror.local:5000/cpfs/custom-ingress:2.7.0",
+		},
+		{
+			name: "fully qualified repository",
+			spec: operatorv1alpha1.ManagementIngressSpec{
+				ImageRegistry: "icr.io/cpopen/cpfs",
+				Image:         operatorv1alpha1.OperandImage{Repository: "quay.io/example/icp-management-ingress"},
+			},
+			env:      envImage,
+			expected: "quay.io/example/icp-management-ingress:2.6.4",
+		},
+		{
+			name: "digest replaces tag",
+			spec: operatorv1alpha1.ManagementIngressSpec{
+				Image: operatorv1alpha1.OperandImage{Digest: "sha256:abcdef"},
+			},
+			env:      envImage,
+			expected: "icr.io/cpopen/cpfs/icp-management-ingress@sha256:abcdef",
+		},
+		{
+			name: "digest in tag field",
+			spec: operatorv1alpha1.ManagementIngressSpec{
+				Image: operatorv1alpha1.OperandImage{Tag: "sha256:abcdef"},
+			},
+			env:      "icr.io/cpopen/cpfs/icp-management-ingress@sha256:012345",
+			expected: "icr.io/cpopen/cpfs/icp-management-ingress@sha256:abcdef",
+		},
+		{
+			name: "no env image",
+			spec: operatorv1alpha1.ManagementIngressSpec{
+				ImageRegistry: "mirror.local:5000",
+				Image:         operatorv1alpha1.OperandImage{Repository: "icp-management-ingress", Tag: "2.6.4"},
+			},
+			expected: "mirror.local:5000/icp-management-ingress:2.6.4",
+		},
+		{
+			name:    "nothing to resolve",
+			wantErr: true,
+		},
+		{
+			name: "missing tag",
+			spec: operatorv1alpha1.ManagementIngressSpec{
+				Image: operatorv1alpha1.OperandImage{Repository: "icp-management-ingress"},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		image, err := resolveImage(tt.spec, tt.env)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s: expected an error, got image %s", tt.name, image)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+			continue
+		}
+		if image != tt.expected {
+			t.Errorf("%s: got image %s, expected %s", tt.name, image, tt.expected)
+		}
+	}
+}
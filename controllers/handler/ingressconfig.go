@@ -0,0 +1,146 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package handler
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog"
+
+	operatorv1alpha1 "github.com/IBM/ibm-management-ingress-operator/api/v1alpha1"
+)
+
+const (
+	ReasonConfigValid       string = "ConfigValid"
+	ReasonUnknownConfigKeys string = "UnknownConfigKeys"
+)
+
+// Keys of the management-ingress-config configmap which are rendered from spec.ingressConfig.
+const (
+	proxyConnectTimeoutKey      = "proxy-connect-timeout"
+	proxyReadTimeoutKey         = "proxy-read-timeout"
+	proxySendTimeoutKey         = "proxy-send-timeout"
+	proxyBodySizeKey            = "proxy-body-size"
+	disableAccessLogKey         = "disable-access-log"
+	useGzipKey                  = "use-gzip"
+	gzipLevelKey                = "gzip-level"
+	workerProcessesKey          = "worker-processes"
+	maxWorkerConnectionsKey     = "max-worker-connections"
+	keepAliveKey                = "keep-alive"
+	largeClientHeaderBuffersKey = "large-client-header-buffers"
+	hstsKey                     = "hsts"
+	hstsMaxAgeKey               = "hsts-max-age"
+	serverTokensKey             = "server-tokens"
+)
+
+// supportedConfigKeys are the keys of spec.config which management ingress supports.
+var supportedConfigKeys = []string{
+	proxyConnectTimeoutKey, proxyReadTimeoutKey, proxySendTimeoutKey, proxyBodySizeKey, disableAccessLogKey,
+	useGzipKey, gzipLevelKey, workerProcessesKey, maxWorkerConnectionsKey, keepAliveKey,
+	largeClientHeaderBuffersKey, hstsKey, hstsMaxAgeKey, serverTokensKey,
+}
+
+// ingressConfigData returns the data of the management-ingress-config configmap, spec.config with
+// spec.ingressConfig rendered over it, and the sorted keys of spec.config which are not supported.
+func (ingressRequest *IngressRequest) ingressConfigData() (map[string]string, []string) {
+	spec := ingressRequest.managementIngress.Spec
+
+	data := map[string]string{}
+	var unknown []string
+	for key, value := range spec.Config {
+		data[key] = value
+		found := false
+		for _, supported := range supportedConfigKeys {
+			found = found || key == supported
+		}
+		if !found {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+
+	if spec.IngressConfig != nil {
+		for key, value := range renderIngressConfig(spec.IngressConfig) {
+			if current, found := data[key]; found && current != value {
+				klog.V(4).Infof("Using %s=%s of spec.ingressConfig instead of %s of spec.config", key, value, current)
+			}
+			data[key] = value
+		}
+	}
+	return data, unknown
+}
+
+// checkIngressConfig reports the keys of spec.config which are not supported with the UnknownConfigKeys
+// condition. They are still copied into the configmap.
+func (ingressRequest *IngressRequest) checkIngressConfig(unknown []string) {
+	if len(unknown) == 0 {
+		ingressRequest.setCondition(operatorv1alpha1.IngressConfigPhase, operatorv1alpha1.UnknownConfigKeys, operatorv1alpha1.ConditionFalse, ReasonConfigValid, "")
+		return
+	}
+
+	message := fmt.Sprintf("spec.config has keys which are not supported by management ingress: %s", strings.Join(unknown, ", "))
+	klog.Warningf("Managementingress %s/%s %s", ingressRequest.managementIngress.Namespace, ingressRequest.managementIngress.Name, message)
+	ingressRequest.setCondition(operatorv1alpha1.IngressConfigPhase, operatorv1alpha1.UnknownConfigKeys, operatorv1alpha1.ConditionTrue, ReasonUnknownConfigKeys, message)
+}
+
+// renderIngressConfig returns the configmap keys of the settings set in the ingress config. Unset
+// settings are not rendered, so the keys of spec.config or the defaults of management ingress apply.
+func renderIngressConfig(c *operatorv1alpha1.IngressConfig) map[string]string {
+	data := map[string]string{}
+	setSeconds := func(key string, d *metav1.Duration) {
+		if d != nil {
+			data[key] = strconv.FormatInt(int64(d.Duration/time.Second), 10)
+		}
+	}
+	setBool := func(key string, b *bool, negate bool) {
+		if b != nil {
+			data[key] = strconv.FormatBool(*b != negate)
+		}
+	}
+	setString := func(key, value string) {
+		if len(value) > 0 {
+			data[key] = value
+		}
+	}
+	setInt := func(key string, value int32) {
+		if value != 0 {
+			data[key] = strconv.Itoa(int(value))
+		}
+	}
+
+	setSeconds(proxyConnectTimeoutKey, c.ProxyConnectTimeout)
+	setSeconds(proxyReadTimeoutKey, c.ProxyReadTimeout)
+	setSeconds(proxySendTimeoutKey, c.ProxySendTimeout)
+	setSeconds(keepAliveKey, c.KeepAliveTimeout)
+	setSeconds(hstsMaxAgeKey, c.HSTSMaxAge)
+	// nginx takes the opposite of the access log setting.
+	setBool(disableAccessLogKey, c.AccessLog, true)
+	setBool(useGzipKey, c.Gzip, false)
+	setBool(hstsKey, c.HSTS, false)
+	setBool(serverTokensKey, c.ServerTokens, false)
+	setString(proxyBodySizeKey, c.ProxyBodySize)
+	setString(workerProcessesKey, c.WorkerProcesses)
+	setString(largeClientHeaderBuffersKey, c.LargeClientHeaderBuffers)
+	setInt(gzipLevelKey, c.GzipLevel)
+	setInt(maxWorkerConnectionsKey, c.MaxWorkerConnections)
+
+	return data
+}
@@ -0,0 +1,82 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package handler
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	operatorv1alpha1 "github.com/IBM/ibm-management-ingress-operator/api/v1alpha1"
+)
+
+func TestIngressConfigData(t *testing.T) {
+	ingressRequest := newFakeIngressRequest()
+	accessLog := false
+	ingressRequest.managementIngress.Spec.Config = map[string]string{
+		"disable-access-log": "false",
+		"ssl-ciphers":        "HIGH",
+		"custom-http-errors": "404",
+	}
+	ingressRequest.managementIngress.Spec.IngressConfig = &operatorv1alpha1.IngressConfig{
+		ProxyReadTimeout: &metav1.Duration{Duration: 5 * time.Minute},
+		AccessLog:        &accessLog,
+	}
+
+	data, unknown := ingressRequest.ingressConfigData()
+	// The typed settings take precedence, unset ones are not rendered.
+	for key, value := range map[string]string{
+		"proxy-read-timeout": "300",
+		"disable-access-log": "true",
+		"ssl-ciphers":        "HIGH",
+	} {
+		if data[key] != value {
+			t.Errorf("expected %s=%s, got %q", key, value, data[key])
+		}
+	}
+	for _, key := range []string{"proxy-connect-timeout", "hsts", "worker-processes", "server-tokens"} {
+		if value, found := data[key]; found {
+			t.Errorf("expected unset %s not to be rendered, got %q", key, value)
+		}
+	}
+	if expected := []string{"custom-http-errors", "ssl-ciphers"}; !reflect.DeepEqual(unknown, expected) {
+		t.Errorf("expected unknown keys %v, got %v", expected, unknown)
+	}
+
+	ingressRequest.checkIngressConfig(unknown)
+	condition := ingressRequest.managementIngress.Status.Conditions[operatorv1alpha1.IngressConfigPhase][0]
+	if condition.Type != operatorv1alpha1.UnknownConfigKeys || condition.Status != operatorv1alpha1.ConditionTrue {
+		t.Errorf("expected the UnknownConfigKeys condition, got %v", condition)
+	}
+
+	// A supported key of spec.config is kept when the typed config does not set it.
+	ingressRequest.managementIngress.Spec.IngressConfig = &operatorv1alpha1.IngressConfig{AccessLog: &accessLog}
+	ingressRequest.managementIngress.Spec.Config = map[string]string{"proxy-read-timeout": "300"}
+	data, _ = ingressRequest.ingressConfigData()
+	if expected := map[string]string{"proxy-read-timeout": "300", "disable-access-log": "true"}; !reflect.DeepEqual(data, expected) {
+		t.Errorf("expected %v, got %v", expected, data)
+	}
+
+	// Without the typed config the legacy keys are copied as is.
+	ingressRequest.managementIngress.Spec.IngressConfig = nil
+	ingressRequest.managementIngress.Spec.Config = map[string]string{"disable-access-log": "false"}
+	data, unknown = ingressRequest.ingressConfigData()
+	if !reflect.DeepEqual(data, map[string]string{"disable-access-log": "false"}) || len(unknown) != 0 {
+		t.Errorf("expected spec.config to be copied, got %v, unknown keys %v", data, unknown)
+	}
+}
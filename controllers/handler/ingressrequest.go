@@ -17,14 +17,14 @@ package handler
 
 import (
 	"context"
-	"time"
 
 	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
-	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/klog"
 	client "sigs.k8s.io/controller-runtime/pkg/client"
@@ -33,22 +33,81 @@ import (
 )
 
 type IngressRequest struct {
-	client            client.Client
+	client client.Client
+	// reader reads from the API server, for objects which are not in the cache of client
+	reader            client.Reader
 	managementIngress *operatorv1alpha1.ManagementIngress
 	recorder          record.EventRecorder
 	scheme            *runtime.Scheme
+	// certificateGVK is the cert-manager Certificate API served by the cluster
+	certificateGVK schema.GroupVersionKind
+	// autoscalerGVK is the HorizontalPodAutoscaler API served by the cluster
+	autoscalerGVK schema.GroupVersionKind
+	// podDisruptionBudgetGVK is the PodDisruptionBudget API served by the cluster
+	podDisruptionBudgetGVK schema.GroupVersionKind
+	// mapper resolves the API version and scope of external issuers, and the Gateway API kinds served
+	mapper meta.RESTMapper
+	// gatewayAPI tells whether the cluster serves the Gateway API
+	gatewayAPI bool
 }
 
-func NewIngressHandler(instance *operatorv1alpha1.ManagementIngress, c client.Client, r record.EventRecorder, s *runtime.Scheme) *IngressRequest {
+func NewIngressHandler(instance *operatorv1alpha1.ManagementIngress, c client.Client, reader client.Reader, r record.EventRecorder, s *runtime.Scheme) *IngressRequest {
 
 	return &IngressRequest{
 		managementIngress: instance,
 		client:            c,
+		reader:            reader,
 		recorder:          r,
 		scheme:            s,
 	}
 }
 
+// SetCertificateGVK sets the cert-manager Certificate API used for the certificates, see DiscoverCertificateGVK.
+func (ingressRequest *IngressRequest) SetCertificateGVK(gvk schema.GroupVersionKind) {
+	ingressRequest.certificateGVK = gvk
+}
+
+// SetAutoscalerGVK sets the HorizontalPodAutoscaler API used for autoscaling, see DiscoverAutoscalerGVK.
+func (ingressRequest *IngressRequest) SetAutoscalerGVK(gvk schema.GroupVersionKind) {
+	ingressRequest.autoscalerGVK = gvk
+}
+
+// SetPodDisruptionBudgetGVK sets the PodDisruptionBudget API used for the pods, see DiscoverPodDisruptionBudgetGVK.
+func (ingressRequest *IngressRequest) SetPodDisruptionBudgetGVK(gvk schema.GroupVersionKind) {
+	ingressRequest.podDisruptionBudgetGVK = gvk
+}
+
+// SetRESTMapper sets the mapper used to look up the kinds of external issuers and of the Gateway API.
+func (ingressRequest *IngressRequest) SetRESTMapper(mapper meta.RESTMapper) {
+	ingressRequest.mapper = mapper
+}
+
+// SetGatewayAPI sets whether the cluster serves the Gateway API, which a Gateway exposure requires.
+func (ingressRequest *IngressRequest) SetGatewayAPI(served bool) {
+	ingressRequest.gatewayAPI = served
+}
+
+func (ingressRequest *IngressRequest) getCertificateGVK() schema.GroupVersionKind {
+	if ingressRequest.certificateGVK.Empty() {
+		return CertificateV1Alpha1GVK
+	}
+	return ingressRequest.certificateGVK
+}
+
+func (ingressRequest *IngressRequest) getAutoscalerGVK() schema.GroupVersionKind {
+	if ingressRequest.autoscalerGVK.Empty() {
+		return HorizontalPodAutoscalerV2Beta2GVK
+	}
+	return ingressRequest.autoscalerGVK
+}
+
+func (ingressRequest *IngressRequest) getPodDisruptionBudgetGVK() schema.GroupVersionKind {
+	if ingressRequest.podDisruptionBudgetGVK.Empty() {
+		return PodDisruptionBudgetV1Beta1GVK
+	}
+	return ingressRequest.podDisruptionBudgetGVK
+}
+
 // func (ingressRequest *IngressRequest) isManaged() bool {
 // 	return ingressRequest.managementIngress.Spec.ManagementState == operatorv1alpha1.ManagementStateManaged
 // }
@@ -83,6 +142,15 @@ func (ingressRequest *IngressRequest) Get(objectName, objectNamespace string, ob
 	return ingressRequest.client.Get(context.TODO(), namespace, object)
 }
 
+// GetUncached reads the object from the API server. It is used for cluster scoped objects, which the
+// operator is only allowed to get by name, so they cannot be cached.
+func (ingressRequest *IngressRequest) GetUncached(objectName, objectNamespace string, object runtime.Object) error {
+	namespace := types.NamespacedName{Name: objectName, Namespace: objectNamespace}
+	klog.V(4).Infof("Getting uncached namespace: %v, object: %v", namespace, object)
+
+	return ingressRequest.reader.Get(context.TODO(), namespace, object)
+}
+
 func (ingressRequest *IngressRequest) List(selector map[string]string, object runtime.Object) error {
 	klog.V(4).Infof("Listing selector: %v, object: %v", selector, object)
 	labelSelector := labels.SelectorFromSet(selector)
@@ -94,19 +162,21 @@ func (ingressRequest *IngressRequest) List(selector map[string]string, object ru
 	)
 }
 
-func (ingressRequest *IngressRequest) GetConfigmap(name, namespace string) (*core.ConfigMap, error) {
-	cfg := &core.ConfigMap{}
+// ListUncached lists the objects of the namespace of the CR from the API server.
+func (ingressRequest *IngressRequest) ListUncached(selector labels.Selector, object runtime.Object) error {
+	klog.V(4).Infof("Listing uncached selector: %v, object: %v", selector, object)
 
-	err := wait.Poll(3*time.Second, 2*time.Second, func() (done bool, err error) {
-		err = ingressRequest.Get(name, namespace, cfg)
-		if err != nil {
-			return false, err
-		}
+	return ingressRequest.reader.List(
+		context.TODO(),
+		object,
+		&client.ListOptions{Namespace: ingressRequest.managementIngress.ObjectMeta.Namespace, LabelSelector: selector},
+	)
+}
 
-		return true, nil
-	})
+func (ingressRequest *IngressRequest) GetConfigmap(name, namespace string) (*core.ConfigMap, error) {
+	cfg := &core.ConfigMap{}
 
-	if err != nil {
+	if err := ingressRequest.Get(name, namespace, cfg); err != nil {
 		return nil, err
 	}
 
@@ -121,13 +191,13 @@ func (ingressRequest *IngressRequest) Delete(object runtime.Object) (err error)
 	return err
 }
 
+// GetCommonLabels returns the labels shared by the objects of all ManagementIngress instances, see GetInstanceLabels.
 func GetCommonLabels() map[string]string {
 	return map[string]string{
 		"app":                          AppName,
 		"component":                    AppName,
 		"app.kubernetes.io/component":  AppName,
 		"app.kubernetes.io/name":       AppName,
-		"app.kubernetes.io/instance":   ServiceName,
 		"app.kubernetes.io/managed-by": "",
 	}
 }
@@ -154,14 +224,3 @@ func GetCommonAnnotations() map[string]string {
 		"productMetric": ProductMetric,
 	}
 }
-
-// waitForTimeout returns a stop channel that closes when the specified timeout is reached
-func WaitForTimeout(timeout time.Duration) <-chan struct{} {
-	stopChWithTimeout := make(chan struct{})
-	go func() {
-		for range time.After(timeout) {
-		}
-		close(stopChWithTimeout)
-	}()
-	return stopChWithTimeout
-}
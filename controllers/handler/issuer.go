@@ -0,0 +1,138 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package handler
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog"
+
+	operatorv1alpha1 "github.com/IBM/ibm-management-ingress-operator/api/v1alpha1"
+)
+
+const (
+	ReasonIssuerForbidden string = "IssuerForbidden"
+	ReasonIssuerVerified  string = "IssuerVerified"
+)
+
+// getIssuer returns the issuer of the certificates. spec.cert.issuer takes precedence over
+// spec.cert.namespacedIssuer, the default CA issuer is used when the CR sets neither.
+func (ingressRequest *IngressRequest) getIssuer() *operatorv1alpha1.CertIssuer {
+	cert := ingressRequest.managementIngress.Spec.Cert
+
+	issuer := operatorv1alpha1.CertIssuer{
+		Name: operatorv1alpha1.DefaultCAIssuerName,
+		Kind: operatorv1alpha1.DefaultCAIssuerKind,
+	}
+	switch {
+	case cert == nil:
+	case len(cert.Issuer.Name) > 0:
+		if len(cert.NamespacedIssuer.Name) > 0 && cert.NamespacedIssuer != cert.Issuer {
+			klog.V(4).Infof("Using issuer %s %s, namespacedIssuer %s %s is ignored", cert.Issuer.Kind, cert.Issuer.Name, cert.NamespacedIssuer.Kind, cert.NamespacedIssuer.Name)
+		}
+		issuer = cert.Issuer
+	case len(cert.NamespacedIssuer.Name) > 0:
+		issuer = cert.NamespacedIssuer
+	}
+
+	if len(issuer.Kind) == 0 {
+		issuer.Kind = operatorv1alpha1.Issuer
+	}
+	return &issuer
+}
+
+// issuerGroup returns the API group of an issuer, for the issuers of cert-manager the group of the
+// Certificate API in use.
+func issuerGroup(issuer *operatorv1alpha1.CertIssuer, certificateGVK schema.GroupVersionKind) string {
+	if operatorv1alpha1.IsCertManagerGroup(issuer.Group) {
+		return certificateGVK.Group
+	}
+	return issuer.Group
+}
+
+// checkIssuerReady verifies that the issuer exists and its Ready condition is True, the certificates
+// would never be issued otherwise. A missing or unready issuer is reported as a DependencyNotReadyError.
+// Issuers which the operator is not allowed to read are not verified, which is reported by the
+// IssuerUnverified condition and a Warning event.
+// The operator reads external issuers through the ClusterRoles aggregated to
+// ibm-management-ingress-operator-issuer-reader, see config/rbac/issuer_reader_role.yaml.
+func (ingressRequest *IngressRequest) checkIssuerReady(issuer *operatorv1alpha1.CertIssuer) error {
+	certificateGVK := ingressRequest.getCertificateGVK()
+	gvk := schema.GroupVersionKind{Group: issuerGroup(issuer, certificateGVK), Kind: string(issuer.Kind)}
+
+	namespace := ingressRequest.managementIngress.Namespace
+	if operatorv1alpha1.IsCertManagerGroup(issuer.Group) {
+		gvk.Version = certificateGVK.Version
+		if issuer.Kind == operatorv1alpha1.ClusterIssuer {
+			namespace = ""
+		}
+	} else {
+		if ingressRequest.mapper == nil {
+			klog.Infof("Not verifying issuer %s %s, no REST mapper available", gvk.GroupKind(), issuer.Name)
+			return nil
+		}
+		mapping, err := ingressRequest.mapper.RESTMapping(gvk.GroupKind())
+		if err != nil {
+			if meta.IsNoMatchError(err) {
+				return fmt.Errorf("issuer kind %s is not served on this cluster", gvk.GroupKind())
+			}
+			return fmt.Errorf("failure discovering issuer kind %s: %v", gvk.GroupKind(), err)
+		}
+		gvk = mapping.GroupVersionKind
+		if mapping.Scope.Name() == meta.RESTScopeNameRoot {
+			namespace = ""
+		}
+	}
+
+	current := &unstructured.Unstructured{}
+	current.SetGroupVersionKind(gvk)
+	if err := ingressRequest.GetUncached(issuer.Name, namespace, current); err != nil {
+		if errors.IsNotFound(err) {
+			return &DependencyNotReadyError{Kind: gvk.Kind, Name: issuer.Name, Namespace: namespace}
+		}
+		if errors.IsForbidden(err) {
+			klog.Warningf("Not verifying issuer %s %s: %v", gvk.Kind, issuer.Name, err)
+			message := fmt.Sprintf("Not allowed to get %s %q, its readiness is not verified", gvk.GroupKind(), issuer.Name)
+			ingressRequest.recorder.Event(ingressRequest.managementIngress, "Warning", ReasonIssuerForbidden, message)
+			ingressRequest.setCondition(operatorv1alpha1.IssuerPhase, operatorv1alpha1.IssuerUnverified, operatorv1alpha1.ConditionTrue, ReasonIssuerForbidden, message)
+			return nil
+		}
+		return fmt.Errorf("failure getting issuer %s %s: %v", gvk.Kind, issuer.Name, err)
+	}
+	ingressRequest.setCondition(operatorv1alpha1.IssuerPhase, operatorv1alpha1.IssuerUnverified, operatorv1alpha1.ConditionFalse, ReasonIssuerVerified, "")
+
+	if !isConditionReady(current) {
+		return &DependencyNotReadyError{Kind: gvk.Kind, Name: issuer.Name, Namespace: namespace}
+	}
+	return nil
+}
+
+// isConditionReady reports whether the Ready condition of an object is True, the convention of
+// cert-manager and the external issuers.
+func isConditionReady(obj *unstructured.Unstructured) bool {
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if ok && condition["type"] == "Ready" {
+			return condition["status"] == "True"
+		}
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package handler
+
+import (
+	"context"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	operatorv1alpha1 "github.com/IBM/ibm-management-ingress-operator/api/v1alpha1"
+)
+
+func newTestIssuer(kind, name, namespace, ready string) *unstructured.Unstructured {
+	issuer := &unstructured.Unstructured{}
+	issuer.SetGroupVersionKind(CertificateV1GVK.GroupVersion().WithKind(kind))
+	issuer.SetName(name)
+	issuer.SetNamespace(namespace)
+	_ = unstructured.SetNestedSlice(issuer.Object, []interface{}{
+		map[string]interface{}{"type": "Ready", "status": ready},
+	}, "status", "conditions")
+	return issuer
+}
+
+func TestGetIssuer(t *testing.T) {
+	ingressRequest := newFakeIngressRequest()
+	instance := ingressRequest.managementIngress
+
+	expected := operatorv1alpha1.CertIssuer{Name: operatorv1alpha1.DefaultCAIssuerName, Kind: operatorv1alpha1.DefaultCAIssuerKind}
+	if issuer := ingressRequest.getIssuer(); *issuer != expected {
+		t.Errorf("expected the default issuer %v, got %v", expected, *issuer)
+	}
+
+	instance.Spec.Cert = &operatorv1alpha1.Cert{NamespacedIssuer: operatorv1alpha1.CertIssuer{Name: "namespaced", Kind: operatorv1alpha1.Issuer}}
+	if issuer := ingressRequest.getIssuer(); issuer.Name != "namespaced" {
+		t.Errorf("expected the namespaced issuer, got %v", *issuer)
+	}
+
+	vault := operatorv1alpha1.CertIssuer{Name: "vault", Kind: operatorv1alpha1.ClusterIssuer}
+	instance.Spec.Cert.Issuer = vault
+	if issuer := ingressRequest.getIssuer(); *issuer != vault {
+		t.Errorf("expected issuer to take precedence, got %v", *issuer)
+	}
+}
+
+func TestCheckIssuerReady(t *testing.T) {
+	ready := newTestIssuer("ClusterIssuer", "vault", "", "True")
+	notReady := newTestIssuer("Issuer", "pending", "ibm-common-services", "False")
+	ingressRequest := newFakeIngressRequest(ready, notReady)
+	ingressRequest.SetCertificateGVK(CertificateV1GVK)
+
+	if err := ingressRequest.checkIssuerReady(&operatorv1alpha1.CertIssuer{Name: "vault", Kind: operatorv1alpha1.ClusterIssuer}); err != nil {
+		t.Errorf("expected the ready ClusterIssuer to pass, got %v", err)
+	}
+	if err := ingressRequest.checkIssuerReady(&operatorv1alpha1.CertIssuer{Name: "pending", Kind: operatorv1alpha1.Issuer}); !IsDependencyNotReady(err) {
+		t.Errorf("expected an unready issuer to be waited for, got %v", err)
+	}
+	if err := ingressRequest.checkIssuerReady(&operatorv1alpha1.CertIssuer{Name: "missing", Kind: operatorv1alpha1.Issuer}); !IsDependencyNotReady(err) {
+		t.Errorf("expected a missing issuer to be waited for, got %v", err)
+	}
+}
+
+// forbiddenReader is a reader which is not allowed to get anything.
+type forbiddenReader struct {
+	client.Reader
+}
+
+func (forbiddenReader) Get(_ context.Context, key types.NamespacedName, _ runtime.Object) error {
+	return errors.NewForbidden(schema.GroupResource{Group: CertificateV1GVK.Group, Resource: "issuers"}, key.Name, nil)
+}
+
+func TestCheckIssuerForbidden(t *testing.T) {
+	ingressRequest := newFakeIngressRequest()
+	ingressRequest.SetCertificateGVK(CertificateV1GVK)
+	ingressRequest.reader = forbiddenReader{Reader: ingressRequest.reader}
+
+	if err := ingressRequest.checkIssuerReady(&operatorv1alpha1.CertIssuer{Name: "vault", Kind: operatorv1alpha1.Issuer}); err != nil {
+		t.Errorf("expected a forbidden issuer not to block the certificates, got %v", err)
+	}
+	conditions := ingressRequest.managementIngress.Status.Conditions[operatorv1alpha1.IssuerPhase]
+	if len(conditions) != 1 || conditions[0].Type != operatorv1alpha1.IssuerUnverified ||
+		conditions[0].Status != operatorv1alpha1.ConditionTrue || conditions[0].Reason != ReasonIssuerForbidden {
+		t.Errorf("expected the IssuerUnverified condition, got %v", conditions)
+	}
+}
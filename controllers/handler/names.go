@@ -0,0 +1,134 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package handler
+
+import (
+	"context"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	operatorv1alpha1 "github.com/IBM/ibm-management-ingress-operator/api/v1alpha1"
+)
+
+// InstanceLabelKey is the label which tells the objects of the ManagementIngress instances in a namespace apart.
+const InstanceLabelKey string = "app.kubernetes.io/instance"
+
+// NewObjectNames returns the names of the objects generated for the ManagementIngress named instanceName.
+// The objects of the default CR keep their legacy names, so they are adopted on upgrade.
+func NewObjectNames(instanceName string) operatorv1alpha1.ObjectNames {
+	names := operatorv1alpha1.ObjectNames{
+		Deployment:          AppName,
+		Service:             ServiceName,
+		ConfigMap:           ConfigName,
+		Certificate:         CertName,
+		TLSSecret:           TLSSecretName,
+		RouteCertificate:    RouteCert,
+		RouteSecret:         RouteSecret,
+		ConsoleRoute:        ConsoleRouteName,
+		ProxyRoute:          ProxyRouteName,
+		LoadBalancerService: LoadBalancerServiceName,
+		BackendCAConfigMap:  BackendCAConfigMapName,
+	}
+	if instanceName == operatorv1alpha1.DefaultName {
+		return names
+	}
+
+	prefix := instanceName + "-"
+	for _, name := range []*string{
+		&names.Deployment, &names.Service, &names.ConfigMap, &names.Certificate, &names.TLSSecret,
+		&names.RouteCertificate, &names.RouteSecret, &names.ConsoleRoute, &names.ProxyRoute,
+		&names.LoadBalancerService, &names.BackendCAConfigMap,
+	} {
+		*name = prefix + *name
+	}
+	return names
+}
+
+// names returns the names of the objects of the CR.
+func (ingressRequest *IngressRequest) names() operatorv1alpha1.ObjectNames {
+	return NewObjectNames(ingressRequest.managementIngress.Name)
+}
+
+// isMaintainer reports whether the CR maintains the objects shared by the instances of its namespace,
+// e.g. ibmcloud-cluster-info and ibmcloud-cluster-ca-cert, see selectMaintainer.
+func (ingressRequest *IngressRequest) isMaintainer() (bool, error) {
+	instances, err := ingressRequest.namespaceInstances()
+	if err != nil {
+		return false, fmt.Errorf("failure listing managementingress: %v", err)
+	}
+	maintainer := selectMaintainer(instances)
+	return maintainer != nil && maintainer.UID == ingressRequest.managementIngress.UID, nil
+}
+
+// namespaceInstances returns the ManagementIngress instances of the namespace of the CR which are not being deleted.
+func (ingressRequest *IngressRequest) namespaceInstances() ([]operatorv1alpha1.ManagementIngress, error) {
+	ingressList := &operatorv1alpha1.ManagementIngressList{}
+	if err := ingressRequest.client.List(context.TODO(), ingressList, client.InNamespace(ingressRequest.managementIngress.Namespace)); err != nil {
+		return nil, err
+	}
+
+	instances := []operatorv1alpha1.ManagementIngress{}
+	for _, item := range ingressList.Items {
+		if item.DeletionTimestamp.IsZero() {
+			instances = append(instances, item)
+		}
+	}
+	return instances, nil
+}
+
+// selectMaintainer returns the instance which maintains the shared objects: the default CR when it exists,
+// otherwise the oldest one, so there always is a maintainer while an instance exists.
+func selectMaintainer(instances []operatorv1alpha1.ManagementIngress) *operatorv1alpha1.ManagementIngress {
+	var maintainer *operatorv1alpha1.ManagementIngress
+	for i := range instances {
+		instance := &instances[i]
+		switch {
+		case instance.Name == operatorv1alpha1.DefaultName:
+			return instance
+		case maintainer == nil,
+			instance.CreationTimestamp.Before(&maintainer.CreationTimestamp),
+			instance.CreationTimestamp.Equal(&maintainer.CreationTimestamp) && instance.Name < maintainer.Name:
+			maintainer = instance
+		}
+	}
+	return maintainer
+}
+
+// GetInstanceLabels returns the common labels with the instance label of the objects of a CR, its service name
+// as for the default CR before several instances could coexist.
+func GetInstanceLabels(names operatorv1alpha1.ObjectNames) map[string]string {
+	labels := GetCommonLabels()
+	labels[InstanceLabelKey] = names.Service
+	return labels
+}
+
+// getLabels returns the labels of the objects of the CR, which also select its pods.
+func (ingressRequest *IngressRequest) getLabels() map[string]string {
+	return GetInstanceLabels(ingressRequest.names())
+}
+
+// getInstanceSelector returns the selector of the pods of a CR.
+func getInstanceSelector(names operatorv1alpha1.ObjectNames) *metav1.LabelSelector {
+	return &metav1.LabelSelector{
+		MatchExpressions: append(getCommonMatchExpressions(), metav1.LabelSelectorRequirement{
+			Key:      InstanceLabelKey,
+			Operator: metav1.LabelSelectorOpIn,
+			Values:   []string{names.Service},
+		}),
+	}
+}
@@ -0,0 +1,60 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package handler
+
+import (
+	"testing"
+
+	core "k8s.io/api/core/v1"
+
+	operatorv1alpha1 "github.com/IBM/ibm-management-ingress-operator/api/v1alpha1"
+)
+
+func TestNewObjectNames(t *testing.T) {
+	names := NewObjectNames(operatorv1alpha1.DefaultName)
+	if names.Deployment != AppName || names.Service != ServiceName || names.TLSSecret != TLSSecretName || names.ConsoleRoute != ConsoleRouteName {
+		t.Errorf("expected the legacy names for the default CR, got %v", names)
+	}
+
+	names = NewObjectNames("staging")
+	if names.Deployment != "staging-management-ingress" || names.Service != "staging-icp-management-ingress" ||
+		names.ConfigMap != "staging-management-ingress-config" || names.ConsoleRoute != "staging-cp-console" {
+		t.Errorf("expected the names of the staging CR to be prefixed, got %v", names)
+	}
+	if labels := GetInstanceLabels(names); labels[InstanceLabelKey] != names.Service {
+		t.Errorf("expected the instance label %s, got %v", names.Service, labels)
+	}
+}
+
+func TestCreateOrUpdateServicePerInstance(t *testing.T) {
+	for _, name := range []string{operatorv1alpha1.DefaultName, "staging"} {
+		ingressRequest := newFakeIngressRequest()
+		ingressRequest.managementIngress.Name = name
+		names := NewObjectNames(name)
+
+		if err := ingressRequest.CreateOrUpdateService(); err != nil {
+			t.Fatalf("CreateOrUpdateService returned unexpected error: %v", err)
+		}
+		service := &core.Service{}
+		if err := ingressRequest.Get(names.Service, "ibm-common-services", service); err != nil {
+			t.Fatalf("failure getting service %s: %v", names.Service, err)
+		}
+		if service.Spec.Selector[InstanceLabelKey] != names.Service || service.Labels[InstanceLabelKey] != names.Service {
+			t.Errorf("expected service %s to select and be labeled with instance %s, got selector %v and labels %v",
+				names.Service, names.Service, service.Spec.Selector, service.Labels)
+		}
+	}
+}
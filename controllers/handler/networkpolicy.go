@@ -0,0 +1,125 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package handler
+
+import (
+	"fmt"
+
+	core "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/klog"
+
+	operatorv1alpha1 "github.com/IBM/ibm-management-ingress-operator/api/v1alpha1"
+)
+
+var (
+	// openShiftIngressNamespaces are the namespaces of the OpenShift routers, also with host network.
+	openShiftIngressNamespaces = map[string]string{"network.openshift.io/policy-group": "ingress"}
+	// ingressNginxNamespaces is the namespace of the ingress-nginx controller.
+	ingressNginxNamespaces = map[string]string{"kubernetes.io/metadata.name": "ingress-nginx"}
+	// iamApps are the IAM services which call management ingress.
+	iamApps = []string{"platform-auth-service", "platform-identity-provider", "platform-identity-management"}
+)
+
+// networkPolicyEnabled reports whether the management ingress pods are restricted by a NetworkPolicy.
+func networkPolicyEnabled(instance *operatorv1alpha1.ManagementIngress) bool {
+	return instance.Spec.NetworkPolicy != nil && instance.Spec.NetworkPolicy.Enabled
+}
+
+// NewNetworkPolicy stubs an instance of the NetworkPolicy of the management ingress pods with labels, which
+// only lets the ingress namespaces, the IAM services and the extra peers reach the https and http ports.
+func NewNetworkPolicy(name, namespace string, labels map[string]string, ingressNamespaces *metav1.LabelSelector, extraPeers []networking.NetworkPolicyPeer) *networking.NetworkPolicy {
+	tcp := core.ProtocolTCP
+	https := intstr.FromInt(int(httpsPort))
+	http := intstr.FromInt(int(httpPort))
+
+	peers := []networking.NetworkPolicyPeer{
+		{NamespaceSelector: ingressNamespaces},
+		{PodSelector: &metav1.LabelSelector{
+			MatchExpressions: []metav1.LabelSelectorRequirement{
+				{Key: "app", Operator: metav1.LabelSelectorOpIn, Values: iamApps},
+			},
+		}},
+	}
+	peers = append(peers, extraPeers...)
+
+	return &networking.NetworkPolicy{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "NetworkPolicy",
+			APIVersion: networking.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    labels,
+		},
+		Spec: networking.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{
+				MatchLabels: labels,
+			},
+			PolicyTypes: []networking.PolicyType{networking.PolicyTypeIngress},
+			Ingress: []networking.NetworkPolicyIngressRule{
+				{
+					Ports: []networking.NetworkPolicyPort{
+						{Protocol: &tcp, Port: &https},
+						{Protocol: &tcp, Port: &http},
+					},
+					From: peers,
+				},
+			},
+		},
+	}
+}
+
+// CreateOrUpdateNetworkPolicy applies the NetworkPolicy of the management ingress pods when it is
+// enabled, and removes it otherwise.
+func (ingressRequest *IngressRequest) CreateOrUpdateNetworkPolicy(clusterType string) error {
+	instance := ingressRequest.managementIngress
+	name := ingressRequest.names().Deployment
+
+	if !networkPolicyEnabled(instance) {
+		err := ingressRequest.Delete(&networking.NetworkPolicy{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: instance.Namespace}})
+		if err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failure removing NetworkPolicy %s: %v", name, err)
+		}
+		return nil
+	}
+
+	settings := instance.Spec.NetworkPolicy
+	ingressNamespaces := settings.IngressNamespaceSelector
+	if ingressNamespaces == nil {
+		ingressNamespaces = &metav1.LabelSelector{MatchLabels: ingressNginxNamespaces}
+		if clusterType != CNCF {
+			ingressNamespaces = &metav1.LabelSelector{MatchLabels: openShiftIngressNamespaces}
+		}
+	}
+
+	switch exposure := GetExposureType(instance, clusterType); exposure {
+	case operatorv1alpha1.ExposureGateway, operatorv1alpha1.ExposureLoadBalancer:
+		if len(settings.ExtraPeers) == 0 {
+			klog.Warningf("NetworkPolicy %s allows no %s clients, set spec.networkPolicy.extraPeers to allow them", name, exposure)
+		}
+	}
+
+	policy := NewNetworkPolicy(name, instance.Namespace, ingressRequest.getLabels(), ingressNamespaces, settings.ExtraPeers)
+	if _, err := ingressRequest.Apply(policy); err != nil {
+		return fmt.Errorf("failure applying NetworkPolicy for %q: %v", instance.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package handler
+
+import (
+	"reflect"
+	"testing"
+
+	networking "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+
+	operatorv1alpha1 "github.com/IBM/ibm-management-ingress-operator/api/v1alpha1"
+)
+
+func TestCreateOrUpdateNetworkPolicy(t *testing.T) {
+	ingressRequest := newFakeIngressRequest()
+	policy := &networking.NetworkPolicy{}
+
+	// Opt-in.
+	if err := ingressRequest.CreateOrUpdateNetworkPolicy(CNCF); err != nil {
+		t.Fatalf("CreateOrUpdateNetworkPolicy returned unexpected error: %v", err)
+	}
+	if err := ingressRequest.Get(AppName, "ibm-common-services", policy); !errors.IsNotFound(err) {
+		t.Errorf("expected no NetworkPolicy, got %v", err)
+	}
+
+	extraPeer := networking.NetworkPolicyPeer{IPBlock: &networking.IPBlock{CIDR: "10.0.0.0/8"}}
+	for clusterType, ingressNamespaces := range map[string]map[string]string{
+		"openshift": openShiftIngressNamespaces,
+		CNCF:        ingressNginxNamespaces,
+	} {
+		ingressRequest, policy = newFakeIngressRequest(), &networking.NetworkPolicy{}
+		ingressRequest.managementIngress.Spec.NetworkPolicy = &operatorv1alpha1.NetworkPolicy{
+			Enabled:    true,
+			ExtraPeers: []networking.NetworkPolicyPeer{extraPeer},
+		}
+		if err := ingressRequest.CreateOrUpdateNetworkPolicy(clusterType); err != nil {
+			t.Fatalf("CreateOrUpdateNetworkPolicy returned unexpected error: %v", err)
+		}
+		if err := ingressRequest.Get(AppName, "ibm-common-services", policy); err != nil {
+			t.Fatalf("failure getting NetworkPolicy: %v", err)
+		}
+		rules := policy.Spec.Ingress
+		if len(rules) != 1 || len(rules[0].Ports) != 2 || len(rules[0].From) != 3 {
+			t.Fatalf("expected one rule for both ports from three peers, got %v", rules)
+		}
+		if !reflect.DeepEqual(rules[0].From[0].NamespaceSelector.MatchLabels, ingressNamespaces) {
+			t.Errorf("expected the %s ingress namespaces, got %v", clusterType, rules[0].From[0].NamespaceSelector)
+		}
+		if !reflect.DeepEqual(rules[0].From[2], extraPeer) {
+			t.Errorf("expected the extra peer, got %v", rules[0].From[2])
+		}
+	}
+
+	ingressRequest.managementIngress.Spec.NetworkPolicy.Enabled = false
+	if err := ingressRequest.CreateOrUpdateNetworkPolicy(CNCF); err != nil {
+		t.Fatalf("CreateOrUpdateNetworkPolicy returned unexpected error: %v", err)
+	}
+	if err := ingressRequest.Get(AppName, "ibm-common-services", policy); !errors.IsNotFound(err) {
+		t.Errorf("expected the NetworkPolicy to be removed, got %v", err)
+	}
+}
@@ -0,0 +1,169 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package handler
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	apps "k8s.io/api/apps/v1"
+	core "k8s.io/api/core/v1"
+	policy "k8s.io/api/policy/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/klog"
+
+	operatorv1alpha1 "github.com/IBM/ibm-management-ingress-operator/api/v1alpha1"
+	"github.com/IBM/ibm-management-ingress-operator/utils"
+)
+
+// terminationGracePeriod is how long nginx has to finish the open requests once the preStop delay is over.
+const terminationGracePeriod = 30 * time.Second
+
+var (
+	PodDisruptionBudgetV1GVK      = schema.GroupVersionKind{Group: "policy", Version: "v1", Kind: "PodDisruptionBudget"}
+	PodDisruptionBudgetV1Beta1GVK = policy.SchemeGroupVersion.WithKind("PodDisruptionBudget")
+)
+
+// DiscoverPodDisruptionBudgetGVK returns the PodDisruptionBudget API served by the cluster, preferring
+// policy/v1. Both versions have the same schema for the fields set by the operator.
+func DiscoverPodDisruptionBudgetGVK(mapper meta.RESTMapper) schema.GroupVersionKind {
+	return discoverGVK(mapper, PodDisruptionBudgetV1GVK, PodDisruptionBudgetV1Beta1GVK)
+}
+
+// getAvailability returns the availability settings of the CR, defaulted in case the defaulting
+// webhook is not enabled or the CR does not set them.
+func (ingressRequest *IngressRequest) getAvailability() *operatorv1alpha1.Availability {
+	availability := ingressRequest.managementIngress.Spec.Availability.DeepCopy()
+	if availability == nil {
+		availability = &operatorv1alpha1.Availability{}
+	}
+	availability.Default()
+
+	// The deployment would never make progress.
+	if availability.MaxSurge.String() == "0" && availability.MaxUnavailable.String() == "0" {
+		klog.Warningf("Using maxUnavailable 1 for management ingress rollouts, maxSurge and maxUnavailable are both 0")
+		maxUnavailable := intstr.FromInt(1)
+		availability.MaxUnavailable = &maxUnavailable
+	}
+	return availability
+}
+
+// setRollout sets the rolling update strategy of the deployment, and delays the termination of the
+// pods, so they are removed from the endpoints and load balancers before nginx stops.
+func setRollout(ds *apps.Deployment, availability *operatorv1alpha1.Availability) {
+	ds.Spec.Strategy = apps.DeploymentStrategy{
+		Type: apps.RollingUpdateDeploymentStrategyType,
+		RollingUpdate: &apps.RollingUpdateDeployment{
+			MaxSurge:       availability.MaxSurge,
+			MaxUnavailable: availability.MaxUnavailable,
+		},
+	}
+	ds.Spec.MinReadySeconds = *availability.MinReadySeconds
+
+	delay := availability.PreStopDelay.Duration
+	podSpec := &ds.Spec.Template.Spec
+	podSpec.TerminationGracePeriodSeconds = utils.GetInt64(int64((delay + terminationGracePeriod) / time.Second))
+	if delay <= 0 {
+		return
+	}
+	for i := range podSpec.Containers {
+		podSpec.Containers[i].Lifecycle = &core.Lifecycle{
+			PreStop: &core.Handler{
+				Exec: &core.ExecAction{Command: []string{"sleep", strconv.FormatInt(int64(delay/time.Second), 10)}},
+			},
+		}
+	}
+}
+
+// getMinReplicas returns the lowest number of replicas of the deployment.
+func (ingressRequest *IngressRequest) getMinReplicas() int32 {
+	spec := ingressRequest.managementIngress.Spec
+	if autoscalingEnabled(ingressRequest.managementIngress) {
+		autoscaling := spec.Autoscaling.DeepCopy()
+		autoscaling.Default()
+		return *autoscaling.MinReplicas
+	}
+	if spec.Replicas == 0 {
+		return operatorv1alpha1.DefaultReplicas
+	}
+	return spec.Replicas
+}
+
+// NewPodDisruptionBudget stubs an instance of a PodDisruptionBudget of the management ingress pods with labels
+// in the policy API of gvk. Either minAvailable or maxUnavailable is set.
+func NewPodDisruptionBudget(gvk schema.GroupVersionKind, name, namespace string, labels map[string]string, minAvailable, maxUnavailable *intstr.IntOrString) (*unstructured.Unstructured, error) {
+	pdb := &policy.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    labels,
+		},
+		Spec: policy.PodDisruptionBudgetSpec{
+			MinAvailable:   minAvailable,
+			MaxUnavailable: maxUnavailable,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: labels,
+			},
+		},
+	}
+
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(pdb)
+	if err != nil {
+		return nil, err
+	}
+	obj := &unstructured.Unstructured{Object: content}
+	obj.SetGroupVersionKind(gvk)
+	delete(obj.Object, "status")
+	return obj, nil
+}
+
+// createOrUpdatePodDisruptionBudget applies the PodDisruptionBudget of the CR. Without one, a budget
+// which allows one pod to be evicted at once is created when there is more than one replica, a single
+// replica cannot be protected without blocking node drains.
+func (ingressRequest *IngressRequest) createOrUpdatePodDisruptionBudget(availability *operatorv1alpha1.Availability) error {
+	instance := ingressRequest.managementIngress
+	gvk := ingressRequest.getPodDisruptionBudgetGVK()
+	name := ingressRequest.names().Deployment
+
+	budget := availability.PodDisruptionBudget
+	if budget == nil || (budget.MinAvailable == nil && budget.MaxUnavailable == nil) {
+		if ingressRequest.getMinReplicas() < 2 {
+			err := ingressRequest.Delete(newUnstructured(gvk, name, instance.Namespace, nil, nil))
+			if err != nil && !errors.IsNotFound(err) && !meta.IsNoMatchError(err) {
+				return fmt.Errorf("failure removing PodDisruptionBudget %s: %v", name, err)
+			}
+			return nil
+		}
+		maxUnavailable := intstr.FromInt(1)
+		budget = &operatorv1alpha1.PodDisruptionBudget{MaxUnavailable: &maxUnavailable}
+	}
+
+	pdb, err := NewPodDisruptionBudget(gvk, name, instance.Namespace, ingressRequest.getLabels(), budget.MinAvailable, budget.MaxUnavailable)
+	if err != nil {
+		return fmt.Errorf("failure creating PodDisruptionBudget %s: %v", name, err)
+	}
+	if _, err := ingressRequest.Apply(pdb); err != nil {
+		return fmt.Errorf("failure applying PodDisruptionBudget for %q: %v", instance.Name, err)
+	}
+	return nil
+}
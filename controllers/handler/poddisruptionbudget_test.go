@@ -0,0 +1,105 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package handler
+
+import (
+	"testing"
+
+	apps "k8s.io/api/apps/v1"
+	core "k8s.io/api/core/v1"
+	policy "k8s.io/api/policy/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	operatorv1alpha1 "github.com/IBM/ibm-management-ingress-operator/api/v1alpha1"
+)
+
+func TestSetRollout(t *testing.T) {
+	ingressRequest := newFakeIngressRequest()
+	ds := NewDeployment(AppName, "ibm-common-services", GetInstanceLabels(NewObjectNames(operatorv1alpha1.DefaultName)), 2, core.PodSpec{Containers: []core.Container{{Name: AppName}}})
+	setRollout(ds, ingressRequest.getAvailability())
+
+	rollingUpdate := ds.Spec.Strategy.RollingUpdate
+	if ds.Spec.Strategy.Type != apps.RollingUpdateDeploymentStrategyType || rollingUpdate.MaxSurge.IntValue() != 1 || rollingUpdate.MaxUnavailable.IntValue() != 0 {
+		t.Errorf("expected a rolling update without unavailable pods, got %v", ds.Spec.Strategy)
+	}
+	if ds.Spec.MinReadySeconds != operatorv1alpha1.DefaultMinReadySeconds {
+		t.Errorf("expected minReadySeconds %d, got %d", operatorv1alpha1.DefaultMinReadySeconds, ds.Spec.MinReadySeconds)
+	}
+	lifecycle := ds.Spec.Template.Spec.Containers[0].Lifecycle
+	if lifecycle == nil || lifecycle.PreStop.Exec.Command[1] != "10" {
+		t.Errorf("expected a 10s preStop delay, got %v", lifecycle)
+	}
+	if *ds.Spec.Template.Spec.TerminationGracePeriodSeconds != 40 {
+		t.Errorf("expected the grace period to cover the preStop delay, got %d", *ds.Spec.Template.Spec.TerminationGracePeriodSeconds)
+	}
+
+	// Both 0 would never roll out.
+	zero := intstr.FromInt(0)
+	ingressRequest.managementIngress.Spec.Availability = &operatorv1alpha1.Availability{
+		MaxSurge:     &zero,
+		PreStopDelay: &metav1.Duration{},
+	}
+	ds = NewDeployment(AppName, "ibm-common-services", GetInstanceLabels(NewObjectNames(operatorv1alpha1.DefaultName)), 2, core.PodSpec{Containers: []core.Container{{Name: AppName}}})
+	setRollout(ds, ingressRequest.getAvailability())
+	if ds.Spec.Strategy.RollingUpdate.MaxUnavailable.IntValue() != 1 {
+		t.Errorf("expected maxUnavailable 1, got %v", ds.Spec.Strategy.RollingUpdate)
+	}
+	if ds.Spec.Template.Spec.Containers[0].Lifecycle != nil {
+		t.Errorf("expected no preStop hook, got %v", ds.Spec.Template.Spec.Containers[0].Lifecycle)
+	}
+}
+
+func TestCreateOrUpdatePodDisruptionBudget(t *testing.T) {
+	ingressRequest := newFakeIngressRequest()
+	pdb := &policy.PodDisruptionBudget{}
+
+	// A single replica is not protected.
+	if err := ingressRequest.createOrUpdatePodDisruptionBudget(ingressRequest.getAvailability()); err != nil {
+		t.Fatalf("createOrUpdatePodDisruptionBudget returned unexpected error: %v", err)
+	}
+	if err := ingressRequest.Get(AppName, "ibm-common-services", pdb); !errors.IsNotFound(err) {
+		t.Errorf("expected no PodDisruptionBudget for a single replica, got %v", err)
+	}
+
+	ingressRequest.managementIngress.Spec.Replicas = 3
+	if err := ingressRequest.createOrUpdatePodDisruptionBudget(ingressRequest.getAvailability()); err != nil {
+		t.Fatalf("createOrUpdatePodDisruptionBudget returned unexpected error: %v", err)
+	}
+	if err := ingressRequest.Get(AppName, "ibm-common-services", pdb); err != nil {
+		t.Fatalf("failure getting PodDisruptionBudget: %v", err)
+	}
+	if pdb.Spec.MaxUnavailable == nil || pdb.Spec.MaxUnavailable.IntValue() != 1 || pdb.Spec.MinAvailable != nil {
+		t.Errorf("expected maxUnavailable 1, got %v", pdb.Spec)
+	}
+
+	minAvailable := intstr.FromString("50%")
+	ingressRequest.managementIngress.Spec.Availability = &operatorv1alpha1.Availability{
+		PodDisruptionBudget: &operatorv1alpha1.PodDisruptionBudget{MinAvailable: &minAvailable},
+	}
+	if err := ingressRequest.createOrUpdatePodDisruptionBudget(ingressRequest.getAvailability()); err != nil {
+		t.Fatalf("createOrUpdatePodDisruptionBudget returned unexpected error: %v", err)
+	}
+	pdb = &policy.PodDisruptionBudget{}
+	if err := ingressRequest.Get(AppName, "ibm-common-services", pdb); err != nil {
+		t.Fatalf("failure getting PodDisruptionBudget: %v", err)
+	}
+	// The apply of the fake client does not remove maxUnavailable, the API server does.
+	if pdb.Spec.MinAvailable == nil || pdb.Spec.MinAvailable.String() != "50%" {
+		t.Errorf("expected minAvailable 50%%, got %v", pdb.Spec)
+	}
+}
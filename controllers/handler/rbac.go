@@ -19,6 +19,7 @@ import (
 	"fmt"
 
 	rbac "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/klog"
@@ -107,35 +108,109 @@ func NewClusterRoleBinding(bindingName, roleName string, subjects []rbac.Subject
 	}
 }
 
-// CreateClusterRole creates a cluser role or returns error
-func (ingressRequest *IngressRequest) CreateClusterRole(name string, rules []rbac.PolicyRule) (*rbac.ClusterRole, error) {
+// CreateOrUpdateClusterRole creates a cluster role, or updates its rules when they drifted
+func (ingressRequest *IngressRequest) CreateOrUpdateClusterRole(name string, rules []rbac.PolicyRule) (*rbac.ClusterRole, error) {
 	clusterRole := &rbac.ClusterRole{
 		TypeMeta: metav1.TypeMeta{
 			Kind:       "ClusterRole",
 			APIVersion: rbac.SchemeGroupVersion.String(),
 		},
 		ObjectMeta: metav1.ObjectMeta{
-			Name: name,
+			Name:   name,
+			Labels: GetCommonLabels(),
 		},
 		Rules: rules,
 	}
 
-	err := ingressRequest.Create(clusterRole)
-	if err != nil && !errors.IsAlreadyExists(err) {
-		return nil, fmt.Errorf("failure creating '%s' clusterrole: %v", name, err)
+	current := &rbac.ClusterRole{}
+	err := ingressRequest.GetUncached(name, "", current)
+	if err != nil && !errors.IsNotFound(err) {
+		return nil, fmt.Errorf("failure getting '%s' clusterrole: %v", name, err)
+	}
+	if errors.IsNotFound(err) {
+		if err := ingressRequest.Create(clusterRole); err != nil && !errors.IsAlreadyExists(err) {
+			return nil, fmt.Errorf("failure creating '%s' clusterrole: %v", name, err)
+		}
+		klog.Infof("Created ClusterRole: %s", name)
+		return clusterRole, nil
+	}
+
+	if equality.Semantic.DeepEqual(current.Rules, rules) {
+		return current, nil
 	}
-	return clusterRole, nil
+
+	klog.Infof("Found change for ClusterRole: %s, trying to update it.", name)
+	current.Rules = rules
+	if err := ingressRequest.Update(current); err != nil {
+		return nil, fmt.Errorf("failure updating '%s' clusterrole: %v", name, err)
+	}
+	ingressRequest.recorder.Eventf(ingressRequest.managementIngress, "Normal", "UpdatedClusterRole", "Successfully updated ClusterRole %q", name)
+	return current, nil
 }
 
-func (ingressRequest *IngressRequest) CreateClusterRoleBinding(binding *rbac.ClusterRoleBinding) error {
+// CreateOrUpdateClusterRoleBinding creates a cluster role binding, or adds the subjects of the binding
+// to the existing one. Subjects of other namespaces are kept, each ManagementIngress instance adds its own.
+func (ingressRequest *IngressRequest) CreateOrUpdateClusterRoleBinding(binding *rbac.ClusterRoleBinding) error {
+	name := binding.ObjectMeta.Name
+
+	current := &rbac.ClusterRoleBinding{}
+	err := ingressRequest.GetUncached(name, "", current)
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failure getting ClusterRoleBinding %s: %v", name, err)
+	}
+	if errors.IsNotFound(err) {
+		if err := ingressRequest.Create(binding); err != nil && !errors.IsAlreadyExists(err) {
+			return fmt.Errorf("failure creating ClusterRoleBinding: %v", err)
+		}
+		klog.Infof("Created ClusterRoleBinding: %s", name)
+		return nil
+	}
+
+	// roleRef is immutable, the binding has to be recreated to point to another role.
+	if current.RoleRef != binding.RoleRef {
+		klog.Infof("Found change for roleRef of ClusterRoleBinding: %s, trying to recreate it.", name)
+		binding.Subjects = mergeSubjects(current.Subjects, binding.Subjects)
+		if err := ingressRequest.Delete(current); err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failure deleting ClusterRoleBinding %s: %v", name, err)
+		}
+		if err := ingressRequest.Create(binding); err != nil {
+			return fmt.Errorf("failure creating ClusterRoleBinding: %v", err)
+		}
+		return nil
+	}
 
-	err := ingressRequest.Create(binding)
-	if err != nil && !errors.IsAlreadyExists(err) {
-		return fmt.Errorf("failure creating ClusterRoleBinding: %v", err)
+	subjects := mergeSubjects(current.Subjects, binding.Subjects)
+	if equality.Semantic.DeepEqual(current.Subjects, subjects) {
+		return nil
 	}
+
+	klog.Infof("Found change for subjects of ClusterRoleBinding: %s, trying to update it.", name)
+	current.Subjects = subjects
+	if err := ingressRequest.Update(current); err != nil {
+		return fmt.Errorf("failure updating ClusterRoleBinding %s: %v", name, err)
+	}
+	ingressRequest.recorder.Eventf(ingressRequest.managementIngress, "Normal", "UpdatedClusterRoleBinding", "Successfully updated ClusterRoleBinding %q", name)
 	return nil
 }
 
+// mergeSubjects appends the subjects in add which are missing from current.
+func mergeSubjects(current, add []rbac.Subject) []rbac.Subject {
+	merged := append([]rbac.Subject{}, current...)
+	for _, subject := range add {
+		found := false
+		for _, existing := range current {
+			if existing.Kind == subject.Kind && existing.Name == subject.Name && existing.Namespace == subject.Namespace {
+				found = true
+				break
+			}
+		}
+		if !found {
+			merged = append(merged, subject)
+		}
+	}
+	return merged
+}
+
 //RemoveClusterRole removes a cluster role binding
 func (ingressRequest *IngressRequest) RemoveClusterRole(name string) error {
 
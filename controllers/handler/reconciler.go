@@ -19,96 +19,130 @@ import (
 	"fmt"
 	"os"
 	"strings"
-	"time"
 
 	"k8s.io/klog"
+	ctrl "sigs.k8s.io/controller-runtime"
 
 	operatorv1alpha1 "github.com/IBM/ibm-management-ingress-operator/api/v1alpha1"
 )
 
-func Reconcile(ingressRequest *IngressRequest, clusterType string, domainName string) (err error) {
+// reconcilePhase is one step of the reconcile. Phases run in order and the reconcile stops at the
+// first phase which fails or waits for a dependency, the next reconcile starts over from the top.
+type reconcilePhase struct {
+	name string
+	run  func() error
+}
+
+func Reconcile(ingressRequest *IngressRequest, clusterType string, domainName string) (result ctrl.Result, err error) {
 
 	// First time in reconcile set route host in status.
 	requestIngress := ingressRequest.managementIngress
+	originalStatus := requestIngress.Status.DeepCopy()
+
+	// Always record the outcome of this reconcile in the CR status. Certificates expire without any change
+	// to watch, so they are checked on every reconcile, also when a phase failed, and again periodically.
+	defer func() {
+		expiryRequeue := ingressRequest.CheckCertificateExpiry()
+		if statusErr := ingressRequest.updateStatus(originalStatus, err); statusErr != nil {
+			klog.Errorf("Failure updating status of managementingress: %s/%s: %v", requestIngress.Namespace, requestIngress.Name, statusErr)
+			if err == nil || IsDependencyNotReady(err) {
+				result = ctrl.Result{}
+				err = statusErr
+			}
+			return
+		}
+		// A dependency which is not ready is not an error, check again later.
+		if IsDependencyNotReady(err) {
+			klog.Infof("Managementingress: %s/%s is %v, requeue after %v", requestIngress.Namespace, requestIngress.Name, err, DependencyRequeueInterval)
+			result = ctrl.Result{RequeueAfter: DependencyRequeueInterval}
+			err = nil
+		}
+		if result.RequeueAfter == 0 || expiryRequeue < result.RequeueAfter {
+			result.RequeueAfter = expiryRequeue
+		}
+	}()
 
 	var host string
 	if clusterType == CNCF {
-		host = getHostOnCNCF(domainName)
+		host = getHostOnCNCF(ingressRequest.names().ConsoleRoute, domainName)
 	} else {
 		// Get route host
 		host, err = getRouteHost(ingressRequest)
 		if err != nil {
-			return err
+			return ctrl.Result{}, fmt.Errorf("unable to get route host for %q: %v", requestIngress.Name, err)
 		}
 	}
 
 	// see if Status.Host needs to be updated base on the routeHost value in the CR
-	if len(requestIngress.Status.Host) == 0 || requestIngress.Status.Host != host {
+	if requestIngress.Status.Host != host {
 		klog.Infof("Setting Status.Host to %s", host)
-		status := &operatorv1alpha1.ManagementIngressStatus{
-			Conditions: map[string]operatorv1alpha1.ConditionList{},
-			PodState:   operatorv1alpha1.PodStateMap{},
-			Host:       host,
-			State: operatorv1alpha1.OperandState{
-				Message: "Get router host for management ingress at " + time.Now().Format("2006-01-02 15:04:05"),
-				Status:  operatorv1alpha1.StatusDeploying,
-			},
-		}
-
-		// Update CR status
-		requestIngress.Status = *status
-		if err := ingressRequest.UpdateStatus(requestIngress); err != nil {
-			return err
-		}
+		requestIngress.Status.Host = host
 	}
-	fmt.Println("Reconciling cert")
-	// Reconcile cert
-	if err = ingressRequest.CreateOrUpdateCertificates(); err != nil {
-		return fmt.Errorf("unable  to create or update certificates for %q: %v", ingressRequest.managementIngress.Name, err)
+	names := ingressRequest.names()
+	requestIngress.Status.Objects = &names
+
+	phases := []reconcilePhase{
+		{operatorv1alpha1.ServiceAccountPhase, ingressRequest.CreateOrUpdateServiceAccount},
 	}
-	fmt.Println("Reconciling service")
-	// Reconcile service
-	if err = ingressRequest.CreateOrUpdateService(); err != nil {
-		return fmt.Errorf("unable  to create or update service for %q: %v", ingressRequest.managementIngress.Name, err)
+	if clusterType != CNCF {
+		// SecurityContextConstraints only exist on ocp clusters
+		phases = append(phases, reconcilePhase{operatorv1alpha1.SecurityContextConstraintPhase, ingressRequest.CreateOrUpdateSecurityContextConstraint})
 	}
-	fmt.Println("Reconciling configmap")
-	// Reconcile configmap
-	if clusterType == CNCF {
-		if err = ingressRequest.CreateOrUpdateConfigMap(clusterType, domainName); err != nil {
-			return fmt.Errorf("unable  to create or update configmap for %q: %v", ingressRequest.managementIngress.Name, err)
-		}
+	phases = append(phases, []reconcilePhase{
+		{operatorv1alpha1.CertificatePhase, ingressRequest.CreateOrUpdateCertificates},
+		{operatorv1alpha1.ServicePhase, ingressRequest.CreateOrUpdateService},
+		{operatorv1alpha1.NetworkPolicyPhase, func() error { return ingressRequest.CreateOrUpdateNetworkPolicy(clusterType) }},
+		{operatorv1alpha1.ConfigMapPhase, func() error {
+			if clusterType == CNCF {
+				return ingressRequest.CreateOrUpdateConfigMap(clusterType, domainName)
+			}
+			return ingressRequest.CreateOrUpdateConfigMap("", "")
+		}},
+	}...)
+	if GetExposureType(requestIngress, clusterType) == operatorv1alpha1.ExposureRoute {
+		// Reconcile route on ocp clusters
+		phases = append(phases, reconcilePhase{operatorv1alpha1.RoutePhase, func() error { return ingressRequest.CreateOrUpdateExposure(clusterType, domainName) }})
 	} else {
-		if err = ingressRequest.CreateOrUpdateConfigMap("", ""); err != nil {
-			return fmt.Errorf("unable  to create or update configmap for %q: %v", ingressRequest.managementIngress.Name, err)
+		// Without routes, ibmcloud-cluster-ca-cert is created from the ca cert of the "route-tls-secret" secret,
+		// the same secret as the ocp cluster
+		phases = append(phases, reconcilePhase{operatorv1alpha1.RoutePhase, func() error {
+			maintainer, err := ingressRequest.isMaintainer()
+			if err != nil || !maintainer {
+				return err
+			}
+			return createClusterCACertFromRouteSecret(ingressRequest)
+		}})
+		phases = append(phases, reconcilePhase{operatorv1alpha1.ExposurePhase, func() error { return ingressRequest.CreateOrUpdateExposure(clusterType, domainName) }})
+	}
+	phases = append(phases,
+		reconcilePhase{operatorv1alpha1.AutoscalingPhase, ingressRequest.CreateOrUpdateAutoscaler},
+		reconcilePhase{operatorv1alpha1.DeploymentPhase, func() error { return ingressRequest.CreateOrUpdateDeployment(clusterType) }},
+	)
+
+	for _, phase := range phases {
+		klog.Infof("Reconciling %s", strings.ToLower(phase.name))
+		if err = ingressRequest.recordPhase(phase.name, phase.run()); err != nil {
+			if IsDependencyNotReady(err) {
+				return ctrl.Result{}, err
+			}
+			return ctrl.Result{}, fmt.Errorf("unable to create or update %s for %q: %v", strings.ToLower(phase.name), requestIngress.Name, err)
 		}
 	}
 
-	// Reconcile route
-	if clusterType != CNCF {
-		fmt.Println("Reconciling route")
-		// Reconcile route on ocp clusters
-		if err = ingressRequest.CreateOrUpdateRoute(); err != nil {
-			return fmt.Errorf("unable  to create or update route for %q: %v", ingressRequest.managementIngress.Name, err)
-		}
-	} else {
-		// K cluster uses the same ca cert from the "route-tls-secret" secret as the ocp cluster
-		// only create ibmcloud-cluster-ca-cert on cncf cluster, no route needed to be created
-		stop := WaitForTimeout(10 * time.Minute)
-		secret, err := waitForSecret(ingressRequest, RouteSecret, stop)
-		if err != nil {
-			return err
-		}
+	return ctrl.Result{}, nil
+}
 
-		var caCert = secret.Data["ca.crt"]
-		// Create or update secret ibmcloud-cluster-ca-cert
-		if err := createClusterCACert(ingressRequest, ClusterSecretName, os.Getenv(PODNAMESPACE), caCert); err != nil {
-			return fmt.Errorf("failure creating or updating secret: %v", err)
-		}
+// createClusterCACertFromRouteSecret creates ibmcloud-cluster-ca-cert from the CA of the route secret.
+func createClusterCACertFromRouteSecret(ingressRequest *IngressRequest) error {
+	secret, err := ingressRequest.getDependencySecret(ingressRequest.routeSecretName())
+	if err != nil {
+		return err
 	}
 
-	// Reconcile deployment
-	if err = ingressRequest.CreateOrUpdateDeployment(clusterType); err != nil {
-		return fmt.Errorf("unable  to create or update deployment for %q: %v", ingressRequest.managementIngress.Name, err)
+	var caCert = getCACert(secret)
+	// Create or update secret ibmcloud-cluster-ca-cert
+	if err := createClusterCACert(ingressRequest, ClusterSecretName, os.Getenv(PODNAMESPACE), caCert); err != nil {
+		return fmt.Errorf("failure creating or updating secret: %v", err)
 	}
 
 	return nil
@@ -127,15 +161,16 @@ func getRouteHost(ing *IngressRequest) (string, error) {
 		return "", err
 	}
 
+	consoleRouteName := ing.names().ConsoleRoute
 	if ing.managementIngress.Spec.MultipleInstancesEnabled {
-		multipleInstanceRouteName := strings.Join([]string{ConsoleRouteName, ing.managementIngress.Namespace}, "-")
+		multipleInstanceRouteName := strings.Join([]string{consoleRouteName, ing.managementIngress.Namespace}, "-")
 		return strings.Join([]string{multipleInstanceRouteName, appDomain}, "."), nil
 	}
 
-	return strings.Join([]string{ConsoleRouteName, appDomain}, "."), nil
+	return strings.Join([]string{consoleRouteName, appDomain}, "."), nil
 }
 
 // Get the host for cncf env
-func getHostOnCNCF(domainName string) string {
-	return strings.Join([]string{ConsoleRouteName, domainName}, ".")
+func getHostOnCNCF(consoleRouteName, domainName string) string {
+	return strings.Join([]string{consoleRouteName, domainName}, ".")
 }
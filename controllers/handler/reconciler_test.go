@@ -17,8 +17,25 @@ package handler
 
 import (
 	"testing"
+
+	operatorv1alpha1 "github.com/IBM/ibm-management-ingress-operator/api/v1alpha1"
 )
 
 func TestReconcile(t *testing.T) {
 
 }
+
+func TestReconcileChecksCertificateExpiryWhileWaiting(t *testing.T) {
+	// The reconcile waits for the issuer of the certificates, which does not exist.
+	ingressRequest := newFakeIngressRequest()
+	result, err := Reconcile(ingressRequest, CNCF, "example.com")
+	if err != nil {
+		t.Fatalf("Reconcile returned unexpected error: %v", err)
+	}
+	if result.RequeueAfter != DependencyRequeueInterval {
+		t.Errorf("expected a requeue after %v for the issuer, got %v", DependencyRequeueInterval, result.RequeueAfter)
+	}
+	if _, found := ingressRequest.managementIngress.Status.Conditions[operatorv1alpha1.CertificateExpiryPhase]; !found {
+		t.Errorf("expected the certificate expiry to be checked, got conditions %v", ingressRequest.managementIngress.Status.Conditions)
+	}
+}
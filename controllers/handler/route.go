@@ -17,12 +17,9 @@ package handler
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"os"
-	"reflect"
 	"strings"
-	"time"
 
 	operatorv1 "github.com/openshift/api/operator/v1"
 	route "github.com/openshift/api/route/v1"
@@ -31,9 +28,11 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
-	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/klog"
-	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	operatorv1alpha1 "github.com/IBM/ibm-management-ingress-operator/api/v1alpha1"
+	"github.com/IBM/ibm-management-ingress-operator/clusterinfo"
+	"github.com/IBM/ibm-management-ingress-operator/utils"
 )
 
 //NewRoute stubs an instance of a Route
@@ -104,182 +103,66 @@ func NewSecret(name, namespace string, caCert []byte) *core.Secret {
 			Labels:    labels,
 		},
 		Data: map[string][]byte{
-			"ca.crt": caCert,
+			clusterinfo.CAKey: caCert,
 		},
 	}
 }
 
-func waitForRouteSecret(r *IngressRequest, name string, stopCh <-chan struct{}) (*core.Secret, error) {
-	klog.Infof("Waiting for secret: %s ...", name)
-	s := &core.Secret{}
-
-	err := wait.PollImmediateUntil(2*time.Second, func() (done bool, err error) {
-		if err := r.Get(name, r.managementIngress.ObjectMeta.Namespace, s); err != nil {
-			return false, nil
-		}
-
-		return true, nil
-	}, stopCh)
-
-	return s, err
-}
-
 // create ibmcloud-cluster-ca-cert
 func createClusterCACert(i *IngressRequest, secretName, ns string, caCert []byte) error {
-	// create ibmcloud-cluster-ca-cert
 	clusterSecret := NewSecret(secretName, ns, caCert)
 
-	if err := controllerutil.SetControllerReference(i.managementIngress, clusterSecret, i.scheme); err != nil {
-		klog.Errorf("Error setting controller reference on secret: %v", err)
-	}
-	err := i.Create(clusterSecret)
-	if err != nil {
-		if !errors.IsAlreadyExists(err) {
-			return fmt.Errorf("failure creating secret for %q: %v", i.managementIngress.ObjectMeta.Name, err)
-		}
-
-		klog.Infof("Trying to update secret: %s as it already existed.", secretName)
-		// Update config
-		current := &core.Secret{}
-		err := i.Get(secretName, ns, current)
-		if err != nil {
-			return fmt.Errorf("failure getting secret: %q  for %q: %v", secretName, i.managementIngress.Name, err)
-		}
-
-		// no data change, just return
-		if reflect.DeepEqual(clusterSecret.Data, current.Data) {
-			klog.Infof("No change found from the secret: %s, skip updating current secret.", secretName)
-			return nil
-		}
-
-		json, _ := json.Marshal(clusterSecret)
-		klog.Infof("Found change from secret %s, trying to update it.", json)
-		current.Data = clusterSecret.Data
-
-		// Apply the latest change to configmap
-		if err = i.Update(current); err != nil {
-			return fmt.Errorf("failure updating secret: %v for %q: %v", secretName, i.managementIngress.Name, err)
-		}
-	}
-
-	klog.Infof("Created secret: %s.", secretName)
-	i.recorder.Eventf(i.managementIngress, "Normal", "CreatedSecret", "Successfully created or updated secret %q", secretName)
-
-	return nil
-}
-
-func handleUpdate(i *IngressRequest, current *route.Route, src *route.Route) error {
-	isChange := false
-	name := src.ObjectMeta.Name
-
-	// Check owner reference
-	err := controllerutil.SetControllerReference(i.managementIngress, current, i.scheme)
-	if err != nil {
-		if _, ok := err.(*controllerutil.AlreadyOwnedError); !ok {
-			klog.Errorf("Error updating owner reference for route: %v", err)
-			return err
-		}
-	} else {
-		// Successfully set owner reference.
-		isChange = true
-	}
-
-	// in the Route, spec.Host is immutable. If we have a new host,
-	// delete the route and re-create it instead of doing an Update.
-	if current.Spec.Host != src.Spec.Host {
-		klog.Infof("Found new host for route: %s, trying to re-create it ...", name)
-		err := i.Delete(current)
-		if err != nil && !errors.IsNotFound(err) {
-			return fmt.Errorf("failure deleting %s route: %v", name, err)
-		}
-		err = handleCreate(i, src)
-		return err
-	}
-
-	// spec.Host didn't change. Look for other changes.
-	if equal := reflect.DeepEqual(current.Spec, src.Spec); !equal {
-		klog.Infof("Found change for route: %s, trying to update it ...", name)
-		current.Spec = src.Spec
-		isChange = true
-	}
-
-	if isChange {
-		err := i.Update(current)
-		if err != nil {
-			i.recorder.Eventf(i.managementIngress, "Warning", "UpdateRoute", "Failed to update route %q", name)
-			return fmt.Errorf("failure updating route: %v", err)
-		}
-
-		klog.Infof("Updated route: %s.", name)
-		i.recorder.Eventf(i.managementIngress, "Normal", "UpdateRoute", "Successfully updated route %q", name)
-	}
-
-	return nil
-}
-
-func handleCreate(i *IngressRequest, r *route.Route) error {
-	name := r.ObjectMeta.Name
-
-	if err := controllerutil.SetControllerReference(i.managementIngress, r, i.scheme); err != nil {
-		klog.Errorf("Error setting owner reference for route: %v", err)
-		return err
-	}
-
-	// Try to create the route resource
-	err := i.Create(r)
-	if err != nil {
-		i.recorder.Eventf(i.managementIngress, "Warning", "CreateRoute", "Failed to create route %q", name)
-		return fmt.Errorf("failure creating %s route: %v", name, err)
+	if _, err := i.Apply(clusterSecret); err != nil {
+		return fmt.Errorf("failure applying secret: %q for %q: %v", secretName, i.managementIngress.Name, err)
 	}
 
-	klog.Infof("Created route: %s.", name)
-	i.recorder.Eventf(i.managementIngress, "Normal", "CreateRoute", "Successfully created route %q", name)
 	return nil
 }
 
+// syncRoute applies the route. spec.host of a route is immutable, so a route with a new host
+// is deleted and re-created.
 func syncRoute(i *IngressRequest, r *route.Route) error {
-
 	name := r.ObjectMeta.Name
-	ns := r.ObjectMeta.Namespace
 	current := &route.Route{}
 
-	err := i.Get(name, ns, current)
+	err := i.Get(name, r.ObjectMeta.Namespace, current)
 	if err != nil && !errors.IsNotFound(err) {
 		klog.Errorf("Error getting route: %v", err)
 		return fmt.Errorf("failure getting current route: %v", err)
 	}
 
-	// Create the route if it's not found.
-	if err != nil && errors.IsNotFound(err) {
-		err := handleCreate(i, r)
-		return err
+	if err == nil && current.Spec.Host != r.Spec.Host {
+		klog.Infof("Found new host for route: %s, trying to re-create it ...", name)
+		if err := i.Delete(current); err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failure deleting %s route: %v", name, err)
+		}
 	}
 
-	// Try to update the route
-	err = handleUpdate(i, current, r)
-	return err
+	if _, err := i.Apply(r); err != nil {
+		return fmt.Errorf("failure applying %s route: %v", name, err)
+	}
+	return nil
 }
 
 func getRouteCertificate(i *IngressRequest, ns string) ([]byte, []byte, []byte, []byte, error) {
 	var cert, key, caCert, destinationCAcert []byte
 
-	// Wait for route secret before creating route. Just avoid the case reconciling failed many times.
-	stop := WaitForTimeout(10 * time.Minute)
-	secret, err := waitForRouteSecret(i, RouteSecret, stop)
+	// The route secret is issued by cert-manager, the reconcile is requeued until it exists.
+	secret, err := i.getDependencySecret(i.routeSecretName())
 	if err != nil {
 		return cert, key, caCert, destinationCAcert, err
 	}
 
 	cert = secret.Data[core.TLSCertKey]
 	key = secret.Data[core.TLSPrivateKeyKey]
-	caCert = secret.Data["ca.crt"]
+	caCert = getCACert(secret)
 
 	// Get TLS secret of management ingress service, then get CA cert for OCP route
-	ingressSecret := &core.Secret{}
-	if err := i.Get(TLSSecretName, ns, ingressSecret); err != nil {
+	ingressSecret, err := i.getDependencySecret(i.tlsSecretName())
+	if err != nil {
 		return cert, key, caCert, destinationCAcert, err
 	}
-	destinationCAcert = ingressSecret.Data["ca.crt"]
+	destinationCAcert = getCACert(ingressSecret)
 
 	return cert, key, caCert, destinationCAcert, nil
 }
@@ -297,21 +180,33 @@ func (ingressRequest *IngressRequest) CreateOrUpdateRoute() error {
 	}
 
 	// Create or update secret ibmcloud-cluster-ca-cert
-	if err := createClusterCACert(ingressRequest, ClusterSecretName, os.Getenv(PODNAMESPACE), caCert); err != nil {
-		return fmt.Errorf("failure creating or updating secret: %v", err)
+	maintainer, err := ingressRequest.isMaintainer()
+	if err != nil {
+		return err
+	}
+	if maintainer {
+		if err := createClusterCACert(ingressRequest, ClusterSecretName, os.Getenv(PODNAMESPACE), caCert); err != nil {
+			return fmt.Errorf("failure creating or updating secret: %v", err)
+		}
+	}
+
+	// Without the certificates the route is passthrough
+	if ingressRequest.tlsTermination() == operatorv1alpha1.TLSTerminationPassthrough {
+		cert, key, destinationCAcert = []byte{}, []byte{}, []byte{}
 	}
 
 	// Create cp-console route
+	names := ingressRequest.names()
 	consoleRoute := NewRoute(
-		ConsoleRouteName,
+		names.ConsoleRoute,
 		ingressRequest.managementIngress.ObjectMeta.Namespace,
-		ServiceName,
+		names.Service,
 		ingressRequest.managementIngress.Status.Host,
 		cert,
 		key,
 		caCert,
 		destinationCAcert,
-		getRouteAnnotations(),
+		utils.AppendAnnotations(getRouteAnnotations(), ingressRequest.exposureAnnotations()),
 	)
 
 	if err := syncRoute(ingressRequest, consoleRoute); err != nil {
@@ -324,7 +219,7 @@ func (ingressRequest *IngressRequest) CreateOrUpdateRoute() error {
 		return fmt.Errorf("failure getting proxy route host: %v", err)
 	}
 	proxyRoute := NewRoute(
-		ProxyRouteName,
+		names.ProxyRoute,
 		ingressRequest.managementIngress.ObjectMeta.Namespace,
 		ProxyServiceName,
 		proxyRouteHost,
@@ -363,7 +258,7 @@ func (ingressRequest *IngressRequest) GetRouteAppDomain() (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("failure creating or getting cluster client: %v", err)
 	}
-	if err := clusterClient.Get(context.TODO(), types.NamespacedName{Name: "default", Namespace: "openshift-ingress-operator"}, ing); err != nil {
+	if err := clusterClient.Get(context.TODO(), types.NamespacedName{Name: DefaultOpenShiftConfigName, Namespace: IngressControllerNamespace}, ing); err != nil {
 		return "", err
 	}
 
@@ -391,10 +286,11 @@ func (ingressRequest *IngressRequest) GetProxyRouteHost() (string, error) {
 		return "", err
 	}
 
+	proxyRouteName := ingressRequest.names().ProxyRoute
 	if ingressRequest.managementIngress.Spec.MultipleInstancesEnabled {
-		multipleInstanceRouteName := strings.Join([]string{ProxyRouteName, ingressRequest.managementIngress.Namespace}, "-")
+		multipleInstanceRouteName := strings.Join([]string{proxyRouteName, ingressRequest.managementIngress.Namespace}, "-")
 		return strings.Join([]string{multipleInstanceRouteName, appDomain}, "."), nil
 	}
 
-	return strings.Join([]string{ProxyRouteName, appDomain}, "."), nil
+	return strings.Join([]string{proxyRouteName, appDomain}, "."), nil
 }
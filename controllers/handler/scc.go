@@ -17,18 +17,22 @@ package handler
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
 	scc "github.com/openshift/api/security/v1"
 	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/klog"
+
+	"github.com/IBM/ibm-management-ingress-operator/utils"
 )
 
 //NewSecurityContextConstraint stubs an instance of a SecurityContextConstraint
 func NewSecurityContextConstraint(serviceaccount, name, namespace string) *scc.SecurityContextConstraints {
-	user := strings.Join([]string{"system:serviceaccount", name, namespace}, ":")
+	user := strings.Join([]string{"system:serviceaccount", namespace, serviceaccount}, ":")
 	privilegeEscalation := false
 	var priority int32 = 1
 
@@ -36,7 +40,7 @@ func NewSecurityContextConstraint(serviceaccount, name, namespace string) *scc.S
 
 	return &scc.SecurityContextConstraints{
 		TypeMeta: metav1.TypeMeta{
-			Kind:       "SecurityContextConstraint",
+			Kind:       "SecurityContextConstraints",
 			APIVersion: scc.SchemeGroupVersion.String(),
 		},
 		ObjectMeta: metav1.ObjectMeta{
@@ -69,19 +73,51 @@ func NewSecurityContextConstraint(serviceaccount, name, namespace string) *scc.S
 	}
 }
 
-func (ingressRequest *IngressRequest) CreateSecurityContextConstraint() error {
-	scc := NewSecurityContextConstraint(
+func (ingressRequest *IngressRequest) CreateOrUpdateSecurityContextConstraint() error {
+	desired := NewSecurityContextConstraint(
 		ServiceAccountName,
 		SCCName,
 		ingressRequest.managementIngress.Namespace,
 	)
 
-	klog.Infof("Creating SecurityContextConstraint %q for %q.", SCCName, ingressRequest.managementIngress.Name)
-	err := ingressRequest.Create(scc)
-	if err != nil && !errors.IsAlreadyExists(err) {
-		return fmt.Errorf("failure constructing SecurityContextConstraint for %q: %v", ingressRequest.managementIngress.Name, err)
+	current := &scc.SecurityContextConstraints{}
+	err := ingressRequest.GetUncached(SCCName, "", current)
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failure getting SecurityContextConstraint for %q: %v", ingressRequest.managementIngress.Name, err)
+	}
+	if errors.IsNotFound(err) {
+		klog.Infof("Creating SecurityContextConstraint %q for %q.", SCCName, ingressRequest.managementIngress.Name)
+		if err := ingressRequest.Create(desired); err != nil && !errors.IsAlreadyExists(err) {
+			return fmt.Errorf("failure constructing SecurityContextConstraint for %q: %v", ingressRequest.managementIngress.Name, err)
+		}
+		ingressRequest.recorder.Eventf(ingressRequest.managementIngress, "Normal", "CreatedSecurityContextConstraint", "Successfully created SecurityContextConstraint %q", SCCName)
+		return nil
+	}
+
+	// Every ManagementIngress instance adds the service account of its namespace to the users.
+	for _, user := range current.Users {
+		if !utils.ContainsString(desired.Users, user) {
+			desired.Users = append(desired.Users, user)
+		}
+	}
+	sort.Strings(desired.Users)
+
+	updated := desired.DeepCopy()
+	updated.TypeMeta = current.TypeMeta
+	current.ObjectMeta.DeepCopyInto(&updated.ObjectMeta)
+	if updated.ObjectMeta.Labels == nil {
+		updated.ObjectMeta.Labels = map[string]string{}
+	}
+	updated.ObjectMeta.Labels = utils.AppendLabels(updated.ObjectMeta.Labels, desired.ObjectMeta.Labels)
+	if equality.Semantic.DeepEqual(current, updated) {
+		return nil
+	}
+
+	klog.Infof("Found change for SecurityContextConstraint %q, trying to update it.", SCCName)
+	if err := ingressRequest.Update(updated); err != nil {
+		return fmt.Errorf("failure updating SecurityContextConstraint for %q: %v", ingressRequest.managementIngress.Name, err)
 	}
-	ingressRequest.recorder.Eventf(ingressRequest.managementIngress, "Normal", "CreatedSecurityContextConstraint", "Successfully created SecurityContextConstraint %q", SCCName)
+	ingressRequest.recorder.Eventf(ingressRequest.managementIngress, "Normal", "UpdatedSecurityContextConstraint", "Successfully updated SecurityContextConstraint %q", SCCName)
 
 	return nil
 }
@@ -91,7 +127,7 @@ func (ingressRequest *IngressRequest) RemoveSecurityContextConstraint(name strin
 
 	scc := &scc.SecurityContextConstraints{
 		TypeMeta: metav1.TypeMeta{
-			Kind:       "SecurityContextConstraint",
+			Kind:       "SecurityContextConstraints",
 			APIVersion: scc.SchemeGroupVersion.String(),
 		},
 		ObjectMeta: metav1.ObjectMeta{
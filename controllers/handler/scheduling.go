@@ -0,0 +1,111 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package handler
+
+import (
+	core "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	operatorv1alpha1 "github.com/IBM/ibm-management-ingress-operator/api/v1alpha1"
+)
+
+// DefaultArchitectures are the architectures the management ingress pods run on when the CR does not set them.
+var DefaultArchitectures = []string{"amd64", "ppc64le", "s390x"}
+
+// newAffinity returns the affinity of the management ingress pods: the architectures of the nodes,
+// and an anti-affinity which keeps the pods of selector on different nodes, merged with the affinity of the CR.
+func newAffinity(scheduling *operatorv1alpha1.Scheduling, selector *metav1.LabelSelector) *core.Affinity {
+	if scheduling == nil {
+		scheduling = &operatorv1alpha1.Scheduling{}
+	}
+
+	architectures := DefaultArchitectures
+	if len(scheduling.Architectures) > 0 {
+		architectures = scheduling.Architectures
+	}
+	archRequirement := core.NodeSelectorRequirement{
+		Key:      "kubernetes.io/arch",
+		Operator: core.NodeSelectorOpIn,
+		Values:   architectures,
+	}
+
+	affinity := &core.Affinity{}
+	if scheduling.Affinity != nil {
+		affinity = scheduling.Affinity.DeepCopy()
+	}
+
+	// Node selector terms are ORed, so each term of the CR must also select the architectures.
+	if affinity.NodeAffinity == nil {
+		affinity.NodeAffinity = &core.NodeAffinity{}
+	}
+	required := affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+	if required == nil || len(required.NodeSelectorTerms) == 0 {
+		required = &core.NodeSelector{NodeSelectorTerms: []core.NodeSelectorTerm{{}}}
+	}
+	for i := range required.NodeSelectorTerms {
+		term := &required.NodeSelectorTerms[i]
+		term.MatchExpressions = append(term.MatchExpressions, archRequirement)
+	}
+	affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution = required
+
+	hostnameTerm := core.PodAffinityTerm{
+		LabelSelector: selector.DeepCopy(),
+		TopologyKey:   "kubernetes.io/hostname",
+	}
+	if affinity.PodAntiAffinity == nil {
+		affinity.PodAntiAffinity = &core.PodAntiAffinity{}
+	}
+	antiAffinity := affinity.PodAntiAffinity
+	if scheduling.PodAntiAffinity == operatorv1alpha1.PodAntiAffinityRequired {
+		antiAffinity.RequiredDuringSchedulingIgnoredDuringExecution = append(antiAffinity.RequiredDuringSchedulingIgnoredDuringExecution, hostnameTerm)
+	} else {
+		antiAffinity.PreferredDuringSchedulingIgnoredDuringExecution = append(antiAffinity.PreferredDuringSchedulingIgnoredDuringExecution,
+			core.WeightedPodAffinityTerm{PodAffinityTerm: hostnameTerm, Weight: 100})
+	}
+
+	return affinity
+}
+
+// newTopologySpreadConstraints returns the topology spread constraints of the management ingress pods,
+// the pods of selector are spread across zones and regions when possible unless the CR sets constraints
+// for them.
+func newTopologySpreadConstraints(scheduling *operatorv1alpha1.Scheduling, selector *metav1.LabelSelector) []core.TopologySpreadConstraint {
+	var constraints []core.TopologySpreadConstraint
+	topologyKeys := map[string]bool{}
+	if scheduling != nil {
+		for _, constraint := range scheduling.TopologySpreadConstraints {
+			constraint = *constraint.DeepCopy()
+			if constraint.LabelSelector == nil {
+				constraint.LabelSelector = selector.DeepCopy()
+			}
+			constraints = append(constraints, constraint)
+			topologyKeys[constraint.TopologyKey] = true
+		}
+	}
+
+	for _, topologyKey := range []string{"topology.kubernetes.io/zone", "topology.kubernetes.io/region"} {
+		if topologyKeys[topologyKey] {
+			continue
+		}
+		constraints = append(constraints, core.TopologySpreadConstraint{
+			MaxSkew:           1,
+			TopologyKey:       topologyKey,
+			WhenUnsatisfiable: core.ScheduleAnyway,
+			LabelSelector:     selector.DeepCopy(),
+		})
+	}
+	return constraints
+}
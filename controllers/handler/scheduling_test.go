@@ -0,0 +1,106 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package handler
+
+import (
+	"reflect"
+	"testing"
+
+	core "k8s.io/api/core/v1"
+
+	operatorv1alpha1 "github.com/IBM/ibm-management-ingress-operator/api/v1alpha1"
+)
+
+func TestNewAffinity(t *testing.T) {
+	selector := getInstanceSelector(NewObjectNames(operatorv1alpha1.DefaultName))
+	affinity := newAffinity(nil, selector)
+	terms := affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+	if len(terms) != 1 || !reflect.DeepEqual(terms[0].MatchExpressions[0].Values, DefaultArchitectures) {
+		t.Errorf("expected a single term with the default architectures, got %v", terms)
+	}
+	if len(affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution) != 1 ||
+		len(affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution) != 0 {
+		t.Errorf("expected a preferred pod anti-affinity, got %v", affinity.PodAntiAffinity)
+	}
+
+	infra := core.NodeSelectorRequirement{Key: "node-role.kubernetes.io/infra", Operator: core.NodeSelectorOpExists}
+	scheduling := &operatorv1alpha1.Scheduling{
+		Architectures:   []string{"amd64", "arm64"},
+		PodAntiAffinity: operatorv1alpha1.PodAntiAffinityRequired,
+		Affinity: &core.Affinity{
+			NodeAffinity: &core.NodeAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution: &core.NodeSelector{
+					NodeSelectorTerms: []core.NodeSelectorTerm{
+						{MatchExpressions: []core.NodeSelectorRequirement{infra}},
+						{MatchFields: []core.NodeSelectorRequirement{{Key: "metadata.name", Operator: core.NodeSelectorOpIn, Values: []string{"node1"}}}},
+					},
+				},
+			},
+		},
+	}
+	affinity = newAffinity(scheduling, selector)
+	terms = affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+	if len(terms) != 2 {
+		t.Fatalf("expected the two terms of the CR, got %v", terms)
+	}
+	for _, term := range terms {
+		arch := term.MatchExpressions[len(term.MatchExpressions)-1]
+		if arch.Key != "kubernetes.io/arch" || !reflect.DeepEqual(arch.Values, scheduling.Architectures) {
+			t.Errorf("expected each term to select the architectures of the CR, got %v", term)
+		}
+	}
+	if !reflect.DeepEqual(terms[0].MatchExpressions[0], infra) {
+		t.Errorf("expected the term of the CR to be kept, got %v", terms[0])
+	}
+	if len(affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution) != 1 ||
+		len(affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution) != 0 {
+		t.Errorf("expected a required pod anti-affinity, got %v", affinity.PodAntiAffinity)
+	}
+
+	// The CR is not changed.
+	if len(scheduling.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms[0].MatchExpressions) != 1 ||
+		scheduling.Affinity.PodAntiAffinity != nil {
+		t.Errorf("expected the affinity of the CR to be unchanged, got %v", scheduling.Affinity)
+	}
+}
+
+func TestNewTopologySpreadConstraints(t *testing.T) {
+	selector := getInstanceSelector(NewObjectNames(operatorv1alpha1.DefaultName))
+	constraints := newTopologySpreadConstraints(nil, selector)
+	if len(constraints) != 2 || constraints[0].WhenUnsatisfiable != core.ScheduleAnyway {
+		t.Errorf("expected the zone and region constraints, got %v", constraints)
+	}
+
+	scheduling := &operatorv1alpha1.Scheduling{
+		TopologySpreadConstraints: []core.TopologySpreadConstraint{
+			{MaxSkew: 1, TopologyKey: "topology.kubernetes.io/zone", WhenUnsatisfiable: core.DoNotSchedule},
+		},
+	}
+	constraints = newTopologySpreadConstraints(scheduling, selector)
+	if len(constraints) != 2 {
+		t.Fatalf("expected the zone constraint of the CR and the default region constraint, got %v", constraints)
+	}
+	zone, region := constraints[0], constraints[1]
+	if zone.TopologyKey != "topology.kubernetes.io/zone" || zone.WhenUnsatisfiable != core.DoNotSchedule {
+		t.Errorf("expected the zone constraint of the CR, got %v", zone)
+	}
+	if !reflect.DeepEqual(zone.LabelSelector, selector) {
+		t.Errorf("expected the constraint to select the management ingress pods, got %v", zone.LabelSelector)
+	}
+	if region.TopologyKey != "topology.kubernetes.io/region" || region.WhenUnsatisfiable != core.ScheduleAnyway {
+		t.Errorf("expected the default region constraint, got %v", region)
+	}
+}
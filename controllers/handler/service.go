@@ -19,17 +19,12 @@ import (
 	"fmt"
 
 	core "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
-	"k8s.io/klog"
-	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
-
-	"github.com/IBM/ibm-management-ingress-operator/utils"
 )
 
-//NewService stubs an instance of a Service
-func NewService(name string, namespace string, servicePorts []core.ServicePort) *core.Service {
+//NewService stubs an instance of a Service of the pods of selector
+func NewService(name string, namespace string, selector map[string]string, servicePorts []core.ServicePort) *core.Service {
 
 	labels := GetCommonLabels()
 
@@ -44,18 +39,18 @@ func NewService(name string, namespace string, servicePorts []core.ServicePort)
 			Labels:    labels,
 		},
 		Spec: core.ServiceSpec{
-			Selector: map[string]string{
-				"component": AppName,
-			},
-			Ports: servicePorts,
+			Selector: selector,
+			Ports:    servicePorts,
 		},
 	}
 }
 
 func (ingressRequest *IngressRequest) CreateOrUpdateService() error {
+	name := ingressRequest.names().Service
 	service := NewService(
-		ServiceName,
+		name,
 		ingressRequest.managementIngress.Namespace,
+		ingressRequest.getLabels(),
 		[]core.ServicePort{
 			{
 				Name:     "https",
@@ -68,41 +63,9 @@ func (ingressRequest *IngressRequest) CreateOrUpdateService() error {
 			},
 		})
 
-	if err := controllerutil.SetControllerReference(ingressRequest.managementIngress, service, ingressRequest.scheme); err != nil {
-		klog.Errorf("Error setting controller reference on Service: %v", err)
-	}
-
-	err := ingressRequest.Create(service)
-	if err != nil {
-		if !errors.IsAlreadyExists(err) {
-			ingressRequest.recorder.Eventf(ingressRequest.managementIngress, "Warning", "CreateService", "Failed to create service %q", ServiceName)
-			return fmt.Errorf("failure creating service: %v", err)
-		}
-
-		klog.Infof("Trying to update service: %s as it already existed.", ServiceName)
-		current := &core.Service{}
-		if err = ingressRequest.Get(ServiceName, ingressRequest.managementIngress.ObjectMeta.Namespace, current); err != nil {
-			return fmt.Errorf("failure getting %q service for %q: %v", ServiceName, ingressRequest.managementIngress.Name, err)
-		}
-
-		desired, different := utils.IsServiceDifferent(current, service)
-		if !different {
-			klog.Infof("No change found from the service: %s, skip updating current service.", ServiceName)
-			return nil
-		}
-		klog.Infof("Found change for service: %s, trying to update it.", ServiceName)
-		err = ingressRequest.Update(desired)
-		if err != nil {
-			ingressRequest.recorder.Eventf(ingressRequest.managementIngress, "Warning", "UpdateService", "Failed to update service: %s", ServiceName)
-			return fmt.Errorf("failure updating %q service for %q: %v", ServiceName, ingressRequest.managementIngress.Name, err)
-		}
-
-		ingressRequest.recorder.Eventf(ingressRequest.managementIngress, "Normal", "UpdateService", "Successfully updated service %q", ServiceName)
-		return nil
+	if _, err := ingressRequest.Apply(service); err != nil {
+		return fmt.Errorf("failure applying %q service for %q: %v", name, ingressRequest.managementIngress.Name, err)
 	}
 
-	klog.Infof("Created Service: %q.", ServiceName)
-	ingressRequest.recorder.Eventf(ingressRequest.managementIngress, "Normal", "CreateService", "Successfully created service %q", ServiceName)
-
 	return nil
 }
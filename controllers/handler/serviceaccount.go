@@ -24,6 +24,8 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/klog"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/IBM/ibm-management-ingress-operator/utils"
 )
 
 func NewServiceAccount(name string, namespace string) *core.ServiceAccount {
@@ -43,7 +45,7 @@ func NewServiceAccount(name string, namespace string) *core.ServiceAccount {
 	}
 }
 
-func (ingressRequest *IngressRequest) CreateServiceAccount() error {
+func (ingressRequest *IngressRequest) CreateOrUpdateServiceAccount() error {
 	sa := NewServiceAccount(
 		ServiceAccountName,
 		ingressRequest.managementIngress.Namespace)
@@ -52,20 +54,34 @@ func (ingressRequest *IngressRequest) CreateServiceAccount() error {
 		klog.Errorf("Error setting controller reference on ServiceAccount: %v", err)
 	}
 
-	klog.Infof("Creating ServiceAccount %q for %q.", ServiceAccountName, ingressRequest.managementIngress.Name)
-	err := ingressRequest.Create(sa)
-	if err != nil && !errors.IsAlreadyExists(err) {
-		return fmt.Errorf("failure constructing ServiceAccount for %q: %v", ingressRequest.managementIngress.Name, err)
+	// The service account may also be created by OLM from the CSV, the labels are added to an existing one
+	// and the rest of it, e.g. the pull secrets added by OpenShift, is kept.
+	current := &core.ServiceAccount{}
+	err := ingressRequest.Get(ServiceAccountName, ingressRequest.managementIngress.Namespace, current)
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failure getting ServiceAccount for %q: %v", ingressRequest.managementIngress.Name, err)
+	}
+	if errors.IsNotFound(err) {
+		klog.Infof("Creating ServiceAccount %q for %q.", ServiceAccountName, ingressRequest.managementIngress.Name)
+		if err := ingressRequest.Create(sa); err != nil && !errors.IsAlreadyExists(err) {
+			return fmt.Errorf("failure constructing ServiceAccount for %q: %v", ingressRequest.managementIngress.Name, err)
+		}
+		ingressRequest.recorder.Eventf(ingressRequest.managementIngress, "Normal", "CreatedServiceAccount", "Successfully created service account %q", ServiceAccountName)
+	} else if !hasLabels(current.Labels, sa.Labels) {
+		klog.Infof("Found change for ServiceAccount: %s, trying to update it.", ServiceAccountName)
+		current.Labels = utils.AppendLabels(nonNilMap(current.Labels), sa.Labels)
+		if err := ingressRequest.Update(current); err != nil {
+			return fmt.Errorf("failure updating ServiceAccount for %q: %v", ingressRequest.managementIngress.Name, err)
+		}
+		ingressRequest.recorder.Eventf(ingressRequest.managementIngress, "Normal", "UpdatedServiceAccount", "Successfully updated service account %q", ServiceAccountName)
 	}
 
-	// Create required clusterRole
-	klog.Infof("Creating ClusterRole: %q for %q.", AppName, ingressRequest.managementIngress.Name)
-	_, err = ingressRequest.CreateClusterRole(AppName, defaultRules)
-	if err != nil && !errors.IsAlreadyExists(err) {
+	// Create or update required clusterRole
+	if _, err := ingressRequest.CreateOrUpdateClusterRole(AppName, defaultRules); err != nil {
 		return fmt.Errorf("failure constructing ClusterRole for %q: %v", ingressRequest.managementIngress.Name, err)
 	}
 
-	// Create required clusterRoleBinding
+	// Create or update required clusterRoleBinding
 	subject := rbac.Subject{
 		Kind:      "ServiceAccount",
 		Name:      ServiceAccountName,
@@ -78,13 +94,21 @@ func (ingressRequest *IngressRequest) CreateServiceAccount() error {
 			subject,
 		),
 	)
+	clusterRoleBinding.ObjectMeta.Labels = GetCommonLabels()
 
-	klog.Infof("Creating ClusterRoleBingding: %q for %q.", AppName, ingressRequest.managementIngress.Name)
-	err = ingressRequest.CreateClusterRoleBinding(clusterRoleBinding)
-	if err != nil && !errors.IsAlreadyExists(err) {
+	if err := ingressRequest.CreateOrUpdateClusterRoleBinding(clusterRoleBinding); err != nil {
 		return fmt.Errorf("failure constructing ClusterRoleBinding for %q: %v", ingressRequest.managementIngress.Name, err)
 	}
-	ingressRequest.recorder.Eventf(ingressRequest.managementIngress, "Normal", "CreatedServiceAccount", "Successfully created service account %q", ServiceAccountName)
 
 	return nil
 }
+
+// hasLabels tells whether labels holds every label of expected.
+func hasLabels(labels, expected map[string]string) bool {
+	for k, v := range expected {
+		if value, ok := labels[k]; !ok || value != v {
+			return false
+		}
+	}
+	return true
+}
@@ -16,11 +16,74 @@
 package handler
 
 import (
+	"context"
 	"testing"
+
+	core "k8s.io/api/core/v1"
+	rbac "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 )
 
 func TestCreateOrUpdateServiceAccount(t *testing.T) {
+	drifted := &rbac.ClusterRole{
+		ObjectMeta: metav1.ObjectMeta{Name: AppName},
+		Rules:      []rbac.PolicyRule{NewPolicyRule([]string{""}, []string{"pods"}, nil, []string{"get"})},
+	}
+	otherSubject := rbac.Subject{Kind: "ServiceAccount", Name: ServiceAccountName, Namespace: "tenant"}
+	binding := NewClusterRoleBinding(AppName, AppName, NewSubjects(otherSubject))
+
+	ingressRequest := newFakeIngressRequest(drifted, binding)
+	if err := ingressRequest.CreateOrUpdateServiceAccount(); err != nil {
+		t.Fatalf("CreateOrUpdateServiceAccount returned unexpected error: %v", err)
+	}
+
+	role := &rbac.ClusterRole{}
+	if err := ingressRequest.client.Get(context.TODO(), types.NamespacedName{Name: AppName}, role); err != nil {
+		t.Fatalf("failure getting clusterrole: %v", err)
+	}
+	if len(role.Rules) != len(defaultRules) {
+		t.Errorf("expected drifted clusterrole rules to be replaced by the default rules, got %v", role.Rules)
+	}
+
+	current := &rbac.ClusterRoleBinding{}
+	if err := ingressRequest.client.Get(context.TODO(), types.NamespacedName{Name: AppName}, current); err != nil {
+		t.Fatalf("failure getting clusterrolebinding: %v", err)
+	}
+	if len(current.Subjects) != 2 || current.Subjects[0] != otherSubject || current.Subjects[1].Namespace != "ibm-common-services" {
+		t.Errorf("expected subject of this namespace to be added to the binding, got %v", current.Subjects)
+	}
+}
+
+func TestCreateOrUpdateServiceAccountExisting(t *testing.T) {
+	existing := &core.ServiceAccount{
+		ObjectMeta:       metav1.ObjectMeta{Name: ServiceAccountName, Namespace: "ibm-common-services", Labels: map[string]string{"olm.owner": "csv"}},
+		ImagePullSecrets: []core.LocalObjectReference{{Name: "management-ingress-dockercfg"}},
+	}
+
+	ingressRequest := newFakeIngressRequest(existing)
+	if err := ingressRequest.CreateOrUpdateServiceAccount(); err != nil {
+		t.Fatalf("CreateOrUpdateServiceAccount returned unexpected error: %v", err)
+	}
+
+	sa := &core.ServiceAccount{}
+	if err := ingressRequest.client.Get(context.TODO(), types.NamespacedName{Name: ServiceAccountName, Namespace: "ibm-common-services"}, sa); err != nil {
+		t.Fatalf("failure getting serviceaccount: %v", err)
+	}
+	if !hasLabels(sa.Labels, GetCommonLabels()) || sa.Labels["olm.owner"] != "csv" {
+		t.Errorf("expected the common labels to be added to the existing labels, got %v", sa.Labels)
+	}
+	if len(sa.ImagePullSecrets) != 1 {
+		t.Errorf("expected the pull secrets to be kept, got %v", sa.ImagePullSecrets)
+	}
+}
 
+func TestNewSecurityContextConstraintUser(t *testing.T) {
+	scc := NewSecurityContextConstraint(ServiceAccountName, SCCName, "ibm-common-services")
+	expected := "system:serviceaccount:ibm-common-services:" + ServiceAccountName
+	if len(scc.Users) != 1 || scc.Users[0] != expected {
+		t.Errorf("expected users [%s], got %v", expected, scc.Users)
+	}
 }
 
 func TestRemoveServiceAccount(t *testing.T) {
@@ -0,0 +1,232 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package handler
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	apps "k8s.io/api/apps/v1"
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog"
+
+	operatorv1alpha1 "github.com/IBM/ibm-management-ingress-operator/api/v1alpha1"
+)
+
+const (
+	ReasonReconciled      string = "Reconciled"
+	ReasonReconcileFailed string = "ReconcileFailed"
+	ReasonDependencyWait  string = "DependencyNotReady"
+)
+
+// recordPhase sets the condition of a reconcile phase from its outcome and returns the outcome unchanged.
+func (ingressRequest *IngressRequest) recordPhase(phase string, err error) error {
+	if IsDependencyNotReady(err) {
+		ingressRequest.setCondition(phase, operatorv1alpha1.WaitingResource, operatorv1alpha1.ConditionTrue, ReasonDependencyWait, err.Error())
+		return err
+	}
+	if err != nil {
+		ingressRequest.setCondition(phase, operatorv1alpha1.ResourceFailedOnCreation, operatorv1alpha1.ConditionTrue, ReasonReconcileFailed, err.Error())
+		return err
+	}
+
+	ingressRequest.setCondition(phase, operatorv1alpha1.ResourceReady, operatorv1alpha1.ConditionTrue, ReasonReconciled, "")
+	return nil
+}
+
+// setCondition replaces the condition of a reconcile phase. LastTransitionTime is kept
+// when neither the type nor the status of the condition changed.
+func (ingressRequest *IngressRequest) setCondition(phase string, condType operatorv1alpha1.ConditionType, status operatorv1alpha1.ConditionStatus, reason, message string) {
+	ingressStatus := &ingressRequest.managementIngress.Status
+	if ingressStatus.Conditions == nil {
+		ingressStatus.Conditions = map[string]operatorv1alpha1.ConditionList{}
+	}
+
+	condition := operatorv1alpha1.Condition{
+		Type:               condType,
+		Status:             status,
+		LastTransitionTime: metav1.Now(),
+		Reason:             reason,
+		Message:            message,
+	}
+
+	for _, current := range ingressStatus.Conditions[phase] {
+		if current.Type == condType && current.Status == status {
+			condition.LastTransitionTime = current.LastTransitionTime
+			break
+		}
+	}
+
+	ingressStatus.Conditions[phase] = operatorv1alpha1.ConditionList{condition}
+}
+
+// getPodState groups the management ingress pods by readiness.
+func getPodState(pods []core.Pod) operatorv1alpha1.PodStateMap {
+	ready := []string{}
+	notReady := []string{}
+	failed := []string{}
+
+	for _, pod := range pods {
+		switch {
+		case isPodFailed(&pod):
+			failed = append(failed, pod.Name)
+		case isPodReady(&pod):
+			ready = append(ready, pod.Name)
+		default:
+			notReady = append(notReady, pod.Name)
+		}
+	}
+
+	sort.Strings(ready)
+	sort.Strings(notReady)
+	sort.Strings(failed)
+
+	return operatorv1alpha1.PodStateMap{
+		operatorv1alpha1.PodStateTypeReady:    ready,
+		operatorv1alpha1.PodStateTypeNotReady: notReady,
+		operatorv1alpha1.PodStateTypeFailed:   failed,
+	}
+}
+
+func isPodReady(pod *core.Pod) bool {
+	if pod.Status.Phase != core.PodRunning || pod.DeletionTimestamp != nil {
+		return false
+	}
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == core.PodReady {
+			return cond.Status == core.ConditionTrue
+		}
+	}
+	return false
+}
+
+// isPodFailed reports pods which will not become ready without intervention.
+func isPodFailed(pod *core.Pod) bool {
+	if pod.Status.Phase == core.PodFailed {
+		return true
+	}
+	for _, cs := range pod.Status.ContainerStatuses {
+		if cs.State.Waiting == nil {
+			continue
+		}
+		switch cs.State.Waiting.Reason {
+		case "CrashLoopBackOff", "ImagePullBackOff", "ErrImagePull", "InvalidImageName", "CreateContainerConfigError":
+			return true
+		}
+	}
+	return false
+}
+
+// getOperandState summarizes the operand from the reconcile result and the management ingress deployment.
+func (ingressRequest *IngressRequest) getOperandState(reconcileErr error, podState operatorv1alpha1.PodStateMap) operatorv1alpha1.OperandState {
+	if IsDependencyNotReady(reconcileErr) {
+		if waiting := ingressRequest.waitingSince(); time.Since(waiting) > DependencyTimeout {
+			return operatorv1alpha1.OperandState{
+				Status:  operatorv1alpha1.StatusFailed,
+				Message: fmt.Sprintf("Timed out after %v %s", DependencyTimeout, reconcileErr.Error()),
+			}
+		}
+		return operatorv1alpha1.OperandState{
+			Status:  operatorv1alpha1.StatusDeploying,
+			Message: reconcileErr.Error(),
+		}
+	}
+
+	if reconcileErr != nil {
+		return operatorv1alpha1.OperandState{
+			Status:  operatorv1alpha1.StatusFailed,
+			Message: reconcileErr.Error(),
+		}
+	}
+
+	if failed := podState[operatorv1alpha1.PodStateTypeFailed]; len(failed) > 0 {
+		return operatorv1alpha1.OperandState{
+			Status:  operatorv1alpha1.StatusFailed,
+			Message: fmt.Sprintf("Management ingress pods failed: %v", failed),
+		}
+	}
+
+	ds := &apps.Deployment{}
+	name := ingressRequest.names().Deployment
+	if err := ingressRequest.Get(name, ingressRequest.managementIngress.Namespace, ds); err != nil {
+		return operatorv1alpha1.OperandState{
+			Status:  operatorv1alpha1.StatusDeploying,
+			Message: fmt.Sprintf("Waiting for deployment %s: %v", name, err),
+		}
+	}
+
+	replicas := int32(1)
+	if ds.Spec.Replicas != nil {
+		replicas = *ds.Spec.Replicas
+	}
+
+	if ds.Status.ObservedGeneration >= ds.Generation &&
+		ds.Status.UpdatedReplicas >= replicas &&
+		ds.Status.AvailableReplicas >= replicas &&
+		len(podState[operatorv1alpha1.PodStateTypeNotReady]) == 0 {
+		return operatorv1alpha1.OperandState{
+			Status:  operatorv1alpha1.StatusSuccessful,
+			Message: fmt.Sprintf("Management ingress is available with %d ready replicas", ds.Status.AvailableReplicas),
+		}
+	}
+
+	return operatorv1alpha1.OperandState{
+		Status:  operatorv1alpha1.StatusDeploying,
+		Message: fmt.Sprintf("Waiting for management ingress rollout, %d of %d replicas available", ds.Status.AvailableReplicas, replicas),
+	}
+}
+
+// waitingSince returns the earliest time a reconcile phase started waiting for a dependency.
+func (ingressRequest *IngressRequest) waitingSince() time.Time {
+	since := time.Now()
+	for _, conditions := range ingressRequest.managementIngress.Status.Conditions {
+		for _, condition := range conditions {
+			if condition.Type == operatorv1alpha1.WaitingResource && condition.LastTransitionTime.Time.Before(since) {
+				since = condition.LastTransitionTime.Time
+			}
+		}
+	}
+	return since
+}
+
+// updateStatus fills in the pod state and operand state, then writes the status if it
+// differs from the one read at the beginning of the reconcile.
+func (ingressRequest *IngressRequest) updateStatus(original *operatorv1alpha1.ManagementIngressStatus, reconcileErr error) error {
+	ingressStatus := &ingressRequest.managementIngress.Status
+
+	pods, err := ingressRequest.GetDeploymentPods()
+	if err != nil && !errors.IsNotFound(err) {
+		klog.Errorf("Failure listing management ingress pods: %v", err)
+	} else if err == nil {
+		ingressStatus.PodState = getPodState(pods.Items)
+	}
+	if ingressStatus.PodState == nil {
+		ingressStatus.PodState = operatorv1alpha1.PodStateMap{}
+	}
+
+	ingressStatus.State = ingressRequest.getOperandState(reconcileErr, ingressStatus.PodState)
+
+	if reflect.DeepEqual(original, ingressStatus) {
+		return nil
+	}
+
+	klog.Infof("Updating status of managementingress: %s/%s to %s", ingressRequest.managementIngress.Namespace, ingressRequest.managementIngress.Name, ingressStatus.State.Status)
+	return ingressRequest.UpdateStatus(ingressRequest.managementIngress)
+}
@@ -0,0 +1,138 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package handler
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	core "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	operatorv1alpha1 "github.com/IBM/ibm-management-ingress-operator/api/v1alpha1"
+)
+
+func newTestPod(name string, phase core.PodPhase, ready bool, waitingReason string) core.Pod {
+	pod := core.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status:     core.PodStatus{Phase: phase},
+	}
+	readyStatus := core.ConditionFalse
+	if ready {
+		readyStatus = core.ConditionTrue
+	}
+	pod.Status.Conditions = []core.PodCondition{{Type: core.PodReady, Status: readyStatus}}
+	if waitingReason != "" {
+		pod.Status.ContainerStatuses = []core.ContainerStatus{
+			{State: core.ContainerState{Waiting: &core.ContainerStateWaiting{Reason: waitingReason}}},
+		}
+	}
+	return pod
+}
+
+func TestGetPodState(t *testing.T) {
+	pods := []core.Pod{
+		newTestPod("pod-c", core.PodRunning, true, ""),
+		newTestPod("pod-a", core.PodRunning, true, ""),
+		newTestPod("pod-b", core.PodPending, false, ""),
+		newTestPod("pod-d", core.PodRunning, false, "CrashLoopBackOff"),
+		newTestPod("pod-e", core.PodFailed, false, ""),
+	}
+
+	expected := operatorv1alpha1.PodStateMap{
+		operatorv1alpha1.PodStateTypeReady:    {"pod-a", "pod-c"},
+		operatorv1alpha1.PodStateTypeNotReady: {"pod-b"},
+		operatorv1alpha1.PodStateTypeFailed:   {"pod-d", "pod-e"},
+	}
+
+	if state := getPodState(pods); !reflect.DeepEqual(state, expected) {
+		t.Errorf("getPodState returned %v, expected %v", state, expected)
+	}
+}
+
+func TestGetDeploymentPods(t *testing.T) {
+	newPod := func(name string, labels map[string]string) *core.Pod {
+		pod := newTestPod(name, core.PodRunning, true, "")
+		pod.Namespace, pod.Labels = "ibm-common-services", labels
+		return &pod
+	}
+	other := NewObjectNames("tenant")
+
+	ingressRequest := newFakeIngressRequest(
+		newPod("management-ingress-a", GetInstanceLabels(NewObjectNames("default"))),
+		newPod("tenant-management-ingress-a", GetInstanceLabels(other)),
+	)
+	pods, err := ingressRequest.GetDeploymentPods()
+	if err != nil {
+		t.Fatalf("GetDeploymentPods returned unexpected error: %v", err)
+	}
+	if len(pods.Items) != 1 || pods.Items[0].Name != "management-ingress-a" {
+		t.Errorf("expected only the pods of the instance, got %v", pods.Items)
+	}
+}
+
+func TestRecordPhaseKeepsTransitionTime(t *testing.T) {
+	ingressRequest := &IngressRequest{managementIngress: &operatorv1alpha1.ManagementIngress{}}
+
+	if err := ingressRequest.recordPhase(operatorv1alpha1.ServicePhase, nil); err != nil {
+		t.Fatalf("recordPhase returned unexpected error: %v", err)
+	}
+
+	past := metav1.NewTime(time.Now().Add(-time.Hour))
+	ingressRequest.managementIngress.Status.Conditions[operatorv1alpha1.ServicePhase][0].LastTransitionTime = past
+
+	_ = ingressRequest.recordPhase(operatorv1alpha1.ServicePhase, nil)
+	condition := ingressRequest.managementIngress.Status.Conditions[operatorv1alpha1.ServicePhase][0]
+	if !condition.LastTransitionTime.Equal(&past) {
+		t.Errorf("expected transition time to be kept when the condition did not change")
+	}
+
+	err := ingressRequest.recordPhase(operatorv1alpha1.ServicePhase, fmt.Errorf("boom"))
+	if err == nil {
+		t.Fatalf("expected recordPhase to return the phase error")
+	}
+	conditions := ingressRequest.managementIngress.Status.Conditions[operatorv1alpha1.ServicePhase]
+	if len(conditions) != 1 || conditions[0].Type != operatorv1alpha1.ResourceFailedOnCreation || conditions[0].Message != "boom" {
+		t.Errorf("unexpected conditions after failure: %v", conditions)
+	}
+	if conditions[0].LastTransitionTime.Equal(&past) {
+		t.Errorf("expected transition time to move when the condition changed")
+	}
+}
+
+func TestDependencyNotReadyTimesOut(t *testing.T) {
+	ingressRequest := &IngressRequest{managementIngress: &operatorv1alpha1.ManagementIngress{}}
+	notReady := fmt.Errorf("unable to create route: %w", &DependencyNotReadyError{Kind: "Secret", Name: RouteSecret, Namespace: "ibm-common-services"})
+
+	if !IsDependencyNotReady(notReady) {
+		t.Fatalf("expected wrapped error to be reported as dependency not ready")
+	}
+	if IsDependencyNotReady(fmt.Errorf("boom")) {
+		t.Fatalf("expected plain error not to be reported as dependency not ready")
+	}
+
+	_ = ingressRequest.recordPhase(operatorv1alpha1.RoutePhase, notReady)
+	if state := ingressRequest.getOperandState(notReady, nil); state.Status != operatorv1alpha1.StatusDeploying {
+		t.Errorf("expected Deploying while waiting for a dependency, got %s", state.Status)
+	}
+
+	ingressRequest.managementIngress.Status.Conditions[operatorv1alpha1.RoutePhase][0].LastTransitionTime = metav1.NewTime(time.Now().Add(-2 * DependencyTimeout))
+	if state := ingressRequest.getOperandState(notReady, nil); state.Status != operatorv1alpha1.StatusFailed {
+		t.Errorf("expected Failed after waiting longer than %v, got %s", DependencyTimeout, state.Status)
+	}
+}
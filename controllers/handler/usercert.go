@@ -0,0 +1,148 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package handler
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"strings"
+	"time"
+
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/klog"
+)
+
+// tlsSecretName returns the secret with the certificate of the management ingress service,
+// the user provided one or the one issued by cert-manager.
+func (ingressRequest *IngressRequest) tlsSecretName() string {
+	if cert := ingressRequest.managementIngress.Spec.Cert; cert != nil && cert.TLSSecretRef != nil && len(cert.TLSSecretRef.Name) > 0 {
+		return cert.TLSSecretRef.Name
+	}
+	return ingressRequest.names().TLSSecret
+}
+
+// routeSecretName returns the secret with the certificate of the console route,
+// the user provided one or the one issued by cert-manager.
+func (ingressRequest *IngressRequest) routeSecretName() string {
+	if cert := ingressRequest.managementIngress.Spec.Cert; cert != nil && cert.RouteSecretRef != nil && len(cert.RouteSecretRef.Name) > 0 {
+		return cert.RouteSecretRef.Name
+	}
+	return ingressRequest.names().RouteSecret
+}
+
+// syncUserCertificate validates a user provided certificate secret in place of the cert-manager
+// certificate certName, which is removed in case it was created before the secret was provided.
+func (ingressRequest *IngressRequest) syncUserCertificate(secretName, certName string, hosts []string) error {
+	secret, err := ingressRequest.getDependencySecret(secretName)
+	if err != nil {
+		return err
+	}
+
+	if err := validateCertificateSecret(secret, hosts, time.Now()); err != nil {
+		ingressRequest.recorder.Eventf(ingressRequest.managementIngress, "Warning", "InvalidCertificate", "Secret %q is not a valid certificate: %v", secretName, err)
+		return fmt.Errorf("failure validating certificate secret %s: %v", secretName, err)
+	}
+	klog.Infof("Using certificate from secret: %s instead of certificate: %s.", secretName, certName)
+
+	return ingressRequest.removeCertificate(certName)
+}
+
+// removeCertificate deletes a certificate created by the operator, it is fine if it does not exist.
+func (ingressRequest *IngressRequest) removeCertificate(name string) error {
+	cert := newUnstructured(ingressRequest.getCertificateGVK(), name, ingressRequest.managementIngress.Namespace, nil, nil)
+	if err := ingressRequest.Delete(cert); err != nil && !errors.IsNotFound(err) && !meta.IsNoMatchError(err) {
+		return fmt.Errorf("failure removing certificate %s: %v", name, err)
+	}
+	return nil
+}
+
+// validateCertificateSecret checks that the secret has a PEM encoded certificate and a matching private key,
+// and that the certificate is currently valid for every host.
+func validateCertificateSecret(secret *core.Secret, hosts []string, now time.Time) error {
+	certPEM := secret.Data[core.TLSCertKey]
+	keyPEM := secret.Data[core.TLSPrivateKeyKey]
+	if len(certPEM) == 0 || len(keyPEM) == 0 {
+		return fmt.Errorf("%s and %s are required", core.TLSCertKey, core.TLSPrivateKeyKey)
+	}
+
+	pair, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return fmt.Errorf("failure loading key pair: %v", err)
+	}
+	leaf, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return fmt.Errorf("failure parsing %s: %v", core.TLSCertKey, err)
+	}
+
+	if now.Before(leaf.NotBefore) {
+		return fmt.Errorf("certificate is not valid before %s", leaf.NotBefore.UTC().Format(time.RFC3339))
+	}
+	if now.After(leaf.NotAfter) {
+		return fmt.Errorf("certificate expired at %s", leaf.NotAfter.UTC().Format(time.RFC3339))
+	}
+
+	var uncovered []string
+	for _, host := range hosts {
+		if len(host) == 0 {
+			continue
+		}
+		if err := leaf.VerifyHostname(host); err != nil {
+			uncovered = append(uncovered, host)
+		}
+	}
+	if len(uncovered) > 0 {
+		return fmt.Errorf("certificate does not cover %s", strings.Join(uncovered, ", "))
+	}
+
+	if ca := secret.Data["ca.crt"]; len(ca) > 0 {
+		if block, _ := pem.Decode(ca); block == nil || block.Type != "CERTIFICATE" {
+			return fmt.Errorf("ca.crt is not a PEM encoded certificate")
+		}
+	}
+
+	return nil
+}
+
+// getCACert returns the CA of a certificate secret. User provided secrets may have no ca.crt,
+// then the last certificate of the chain in tls.crt is used when there is a chain.
+func getCACert(secret *core.Secret) []byte {
+	if ca := secret.Data["ca.crt"]; len(ca) > 0 {
+		return ca
+	}
+
+	var last *pem.Block
+	count := 0
+	rest := secret.Data[core.TLSCertKey]
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type == "CERTIFICATE" {
+			last = block
+			count++
+		}
+	}
+	if count < 2 {
+		return []byte{}
+	}
+	return pem.EncodeToMemory(last)
+}
@@ -0,0 +1,145 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package handler
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	core "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	operatorv1alpha1 "github.com/IBM/ibm-management-ingress-operator/api/v1alpha1"
+)
+
+// newTestCertificate returns a PEM encoded certificate and key for dnsNames, signed by parent,
+// or self-signed when parent is nil.
+func newTestCertificate(t *testing.T, dnsNames []string, notBefore, notAfter time.Time, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) ([]byte, []byte, *x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failure generating key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: AppName},
+		DNSNames:              dnsNames,
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		IsCA:                  parent == nil,
+		BasicConstraintsValid: true,
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatalf("failure creating certificate: %v", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("failure marshalling key: %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), cert, key
+}
+
+func TestValidateCertificateSecret(t *testing.T) {
+	now := time.Now()
+	host := "cp-console.apps.example.com"
+	certPEM, keyPEM, _, _ := newTestCertificate(t, []string{host}, now.Add(-time.Hour), now.Add(time.Hour), nil, nil)
+	_, otherKeyPEM, _, _ := newTestCertificate(t, []string{host}, now.Add(-time.Hour), now.Add(time.Hour), nil, nil)
+
+	tests := []struct {
+		name    string
+		data    map[string][]byte
+		hosts   []string
+		now     time.Time
+		wantErr bool
+	}{
+		{name: "valid", data: map[string][]byte{"tls.crt": certPEM, "tls.key": keyPEM, "ca.crt": certPEM}, hosts: []string{host}, now: now},
+		{name: "missing key", data: map[string][]byte{"tls.crt": certPEM}, hosts: []string{host}, now: now, wantErr: true},
+		{name: "key mismatch", data: map[string][]byte{"tls.crt": certPEM, "tls.key": otherKeyPEM}, hosts: []string{host}, now: now, wantErr: true},
+		{name: "host not covered", data: map[string][]byte{"tls.crt": certPEM, "tls.key": keyPEM}, hosts: []string{"cp-console.other.com"}, now: now, wantErr: true},
+		{name: "expired", data: map[string][]byte{"tls.crt": certPEM, "tls.key": keyPEM}, hosts: []string{host}, now: now.Add(2 * time.Hour), wantErr: true},
+		{name: "invalid ca", data: map[string][]byte{"tls.crt": certPEM, "tls.key": keyPEM, "ca.crt": []byte("not a certificate")}, hosts: []string{host}, now: now, wantErr: true},
+	}
+
+	for _, test := range tests {
+		secret := &core.Secret{Data: test.data}
+		if err := validateCertificateSecret(secret, test.hosts, test.now); (err != nil) != test.wantErr {
+			t.Errorf("%s: validateCertificateSecret() error = %v, wantErr %v", test.name, err, test.wantErr)
+		}
+	}
+}
+
+func TestGetCACert(t *testing.T) {
+	now := time.Now()
+	caPEM, _, ca, caKey := newTestCertificate(t, nil, now.Add(-time.Hour), now.Add(time.Hour), nil, nil)
+	leafPEM, _, _, _ := newTestCertificate(t, []string{"cp-console.apps.example.com"}, now.Add(-time.Hour), now.Add(time.Hour), ca, caKey)
+
+	if got := getCACert(&core.Secret{Data: map[string][]byte{"ca.crt": caPEM, "tls.crt": leafPEM}}); !bytes.Equal(got, caPEM) {
+		t.Errorf("expected ca.crt to be used when present")
+	}
+	if got := getCACert(&core.Secret{Data: map[string][]byte{"tls.crt": append(leafPEM, caPEM...)}}); !bytes.Equal(got, caPEM) {
+		t.Errorf("expected the last certificate of the chain, got %s", got)
+	}
+	if got := getCACert(&core.Secret{Data: map[string][]byte{"tls.crt": leafPEM}}); len(got) != 0 {
+		t.Errorf("expected no CA for a single certificate, got %s", got)
+	}
+}
+
+func TestCreateOrUpdateCertificatesUserSecrets(t *testing.T) {
+	now := time.Now()
+	newSecret := func(name, host string) *core.Secret {
+		certPEM, keyPEM, _, _ := newTestCertificate(t, []string{host}, now.Add(-time.Hour), now.Add(time.Hour), nil, nil)
+		return &core.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ibm-common-services"},
+			Data:       map[string][]byte{core.TLSCertKey: certPEM, core.TLSPrivateKeyKey: keyPEM},
+		}
+	}
+
+	ingressRequest := newFakeIngressRequest(
+		newSecret("my-tls", ServiceName+".ibm-common-services.svc"),
+		newSecret("my-route", "cp-console.example.com"),
+	)
+	instance := ingressRequest.managementIngress
+	instance.Status.Host = "cp-console.example.com"
+	instance.Spec.Cert = &operatorv1alpha1.Cert{
+		TLSSecretRef:   &core.LocalObjectReference{Name: "my-tls"},
+		RouteSecretRef: &core.LocalObjectReference{Name: "my-route"},
+	}
+	if err := ingressRequest.CreateOrUpdateCertificates(); err != nil {
+		t.Fatalf("CreateOrUpdateCertificates returned unexpected error: %v", err)
+	}
+	if instance.Status.Issuer != nil {
+		t.Errorf("expected no issuer with user provided secrets, got %v", instance.Status.Issuer)
+	}
+
+	// A CR which was not defaulted by the webhook has no spec.cert.
+	instance.Spec.Cert = nil
+	if err := ingressRequest.CreateOrUpdateCertificates(); err == nil {
+		t.Errorf("expected an error without the issuer of the certificates")
+	}
+}
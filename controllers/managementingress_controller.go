@@ -18,19 +18,28 @@ package controllers
 import (
 	"context"
 
-	certmanagerv1alpha1 "github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+	operatorv1 "github.com/openshift/api/operator/v1"
 	routev1 "github.com/openshift/api/route/v1"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/klog"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	operatorv1alpha1 "github.com/IBM/ibm-management-ingress-operator/api/v1alpha1"
 	k8shandler "github.com/IBM/ibm-management-ingress-operator/controllers/handler"
+	"github.com/IBM/ibm-management-ingress-operator/utils"
 )
 
 const (
@@ -40,11 +49,31 @@ const (
 // ManagementIngressReconciler reconciles a ManagementIngress object
 type ManagementIngressReconciler struct {
 	client.Client
-	Reader      client.Reader
-	Scheme      *runtime.Scheme
-	Recorder    record.EventRecorder
+	Reader   client.Reader
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+	// ClusterType is the type discovered when the operator started, ibm-cpp-config overrides it
 	ClusterType string
-	DomainName  string
+	// OperatorNamespace is the namespace of ibm-cpp-config
+	OperatorNamespace string
+	// WatchNamespaces are the namespaces of the ManagementIngresses, all namespaces when it only holds ""
+	WatchNamespaces []string
+	// Routes tells whether the cluster serves OpenShift routes
+	Routes bool
+	// OpenShiftOperators tells whether the cluster serves the IngressController and DNS of OpenShift
+	OpenShiftOperators bool
+	// GatewayAPI tells whether the cluster serves the Gateway API, which a Gateway exposure requires
+	GatewayAPI bool
+	// CertificateGVK is the cert-manager Certificate API served by the cluster
+	CertificateGVK schema.GroupVersionKind
+	// AutoscalerGVK is the HorizontalPodAutoscaler API served by the cluster
+	AutoscalerGVK schema.GroupVersionKind
+	// PodDisruptionBudgetGVK is the PodDisruptionBudget API served by the cluster
+	PodDisruptionBudgetGVK schema.GroupVersionKind
+	RESTMapper             meta.RESTMapper
+
+	// clusterConfigReader reads ibm-cpp-config from the cache watching it, the API reader without one.
+	clusterConfigReader client.Reader
 }
 
 // The Controller will requeue the Request to be processed again if the returned error is non-nil or
@@ -68,49 +97,259 @@ func (r *ManagementIngressReconciler) Reconcile(request ctrl.Request) (ctrl.Resu
 		return ctrl.Result{}, err
 	}
 
+	ingresshandler := k8shandler.NewIngressHandler(managementingress, r.Client, r.Reader, r.Recorder, r.Scheme)
+	ingresshandler.SetCertificateGVK(r.CertificateGVK)
+	ingresshandler.SetAutoscalerGVK(r.AutoscalerGVK)
+	ingresshandler.SetPodDisruptionBudgetGVK(r.PodDisruptionBudgetGVK)
+	ingresshandler.SetRESTMapper(r.RESTMapper)
+	ingresshandler.SetGatewayAPI(r.GatewayAPI)
+
+	clusterType, domainName, err := r.getClusterConfig()
+	if err != nil {
+		klog.Errorf("failed to get cluster config for managementingress: %s/%s with error: %v", request.NamespacedName.Namespace, request.NamespacedName.Name, err)
+		return ctrl.Result{}, err
+	}
+
+	// Clean up before releasing the deleted CR, also when it is unmanaged, so nothing is left behind on uninstall.
+	if !managementingress.ObjectMeta.DeletionTimestamp.IsZero() {
+		if !utils.ContainsString(managementingress.ObjectMeta.Finalizers, k8shandler.FinalizerName) {
+			klog.Infof("do nothing for the managementingress: %s/%s because it was deleted", request.NamespacedName.Namespace, request.NamespacedName.Name)
+			return ctrl.Result{}, nil
+		}
+
+		klog.Infof("cleaning up managementingress: %s/%s", request.NamespacedName.Namespace, request.NamespacedName.Name)
+		if err := k8shandler.Cleanup(ingresshandler, clusterType); err != nil {
+			klog.Errorf("failed to clean up managementingress: %s/%s with error: %v", request.NamespacedName.Namespace, request.NamespacedName.Name, err)
+			return ctrl.Result{}, err
+		}
+
+		managementingress.ObjectMeta.Finalizers = utils.RemoveString(managementingress.ObjectMeta.Finalizers, k8shandler.FinalizerName)
+		if err := r.Update(ctx, managementingress); err != nil {
+			klog.Errorf("failed to remove finalizer from managementingress: %s/%s with error: %v", request.NamespacedName.Namespace, request.NamespacedName.Name, err)
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, nil
+	}
+
 	if managementingress.Spec.ManagementState == operatorv1alpha1.ManagementStateUnmanaged {
 		klog.Errorf("do nothing for the managementingress: %s/%s because its state is unmanaged", request.NamespacedName.Namespace, request.NamespacedName.Name)
 		return ctrl.Result{}, nil
 	}
 
-	if !managementingress.ObjectMeta.DeletionTimestamp.IsZero() {
-		klog.Infof("do nothing for the managementingress: %s/%s because it was deleted", request.NamespacedName.Namespace, request.NamespacedName.Name)
-		return ctrl.Result{}, nil
+	if !utils.ContainsString(managementingress.ObjectMeta.Finalizers, k8shandler.FinalizerName) {
+		managementingress.ObjectMeta.Finalizers = append(managementingress.ObjectMeta.Finalizers, k8shandler.FinalizerName)
+		if err := r.Update(ctx, managementingress); err != nil {
+			klog.Errorf("failed to add finalizer to managementingress: %s/%s with error: %v", request.NamespacedName.Namespace, request.NamespacedName.Name, err)
+			return ctrl.Result{}, err
+		}
 	}
 
 	klog.Infof("reconciling managementingress: %s/%s", request.NamespacedName.Namespace, request.NamespacedName.Name)
-	ingresshandler := k8shandler.NewIngressHandler(managementingress, r.Client, r.Recorder, r.Scheme)
 
-	err = k8shandler.Reconcile(ingresshandler, r.ClusterType, r.DomainName)
+	result, err := k8shandler.Reconcile(ingresshandler, clusterType, domainName)
 	if err != nil {
 		klog.Errorf("failed to reconcile managementingress: %s/%s with error: %v", request.NamespacedName.Namespace, request.NamespacedName.Name, err)
 		return ctrl.Result{}, err
 	}
-	klog.Infof("reconciling managementingress: %s/%s was successful", request.NamespacedName.Namespace, request.NamespacedName.Name)
-	return ctrl.Result{}, nil
+	klog.Infof("reconciling managementingress: %s/%s is done, requeue after %v", request.NamespacedName.Namespace, request.NamespacedName.Name, result.RequeueAfter)
+	return result, nil
+}
+
+// getClusterConfig returns the cluster type and domain name, as discovered and overridden by ibm-cpp-config.
+func (r *ManagementIngressReconciler) getClusterConfig() (string, string, error) {
+	reader := r.clusterConfigReader
+	if reader == nil {
+		reader = r.Reader
+	}
+	cppConfig, err := k8shandler.GetCppConfig(reader, r.OperatorNamespace)
+	if err != nil {
+		return "", "", err
+	}
+	clusterType, domainName := k8shandler.ApplyCppConfig(r.ClusterType, cppConfig)
+	return clusterType, domainName, nil
+}
+
+// clusterConfigToRequests enqueues every ManagementIngress when ibm-cpp-config, or the default IngressController
+// or DNS of OpenShift change, so the hosts, certificates and cluster info follow the domains of the cluster.
+func (r *ManagementIngressReconciler) clusterConfigToRequests(obj handler.MapObject) []ctrl.Request {
+	name := k8shandler.DefaultOpenShiftConfigName
+	if _, ok := obj.Object.(*corev1.ConfigMap); ok {
+		name = k8shandler.CppConfigName
+	}
+	if obj.Meta.GetName() != name {
+		return nil
+	}
+
+	ingressList := &operatorv1alpha1.ManagementIngressList{}
+	if err := r.List(context.TODO(), ingressList); err != nil {
+		klog.Errorf("failed to list managementingress: %v", err)
+		return nil
+	}
+
+	requests := []ctrl.Request{}
+	for _, item := range ingressList.Items {
+		requests = append(requests, ctrl.Request{NamespacedName: types.NamespacedName{Name: item.Name, Namespace: item.Namespace}})
+	}
+	return requests
+}
+
+// newClusterConfigCache returns a cache of the objects in namespace, started by the manager. The cache of the
+// manager only holds the objects labeled by the operator.
+func newClusterConfigCache(mgr ctrl.Manager, namespace string) (cache.Cache, error) {
+	c, err := cache.New(mgr.GetConfig(), cache.Options{Scheme: mgr.GetScheme(), Mapper: mgr.GetRESTMapper(), Namespace: namespace})
+	if err != nil {
+		return nil, err
+	}
+	if err := mgr.Add(c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// newDependencyCache returns a cache of the objects in the watched namespaces, started by the manager. Unlike the
+// cache of the manager it also holds the objects not labeled by the operator, e.g. the secrets issued by cert-manager.
+func newDependencyCache(mgr ctrl.Manager, namespaces []string) (cache.Cache, error) {
+	if len(namespaces) < 2 {
+		namespace := ""
+		if len(namespaces) == 1 {
+			namespace = namespaces[0]
+		}
+		return newClusterConfigCache(mgr, namespace)
+	}
+
+	c, err := cache.MultiNamespacedCacheBuilder(namespaces)(mgr.GetConfig(), cache.Options{Scheme: mgr.GetScheme(), Mapper: mgr.GetRESTMapper()})
+	if err != nil {
+		return nil, err
+	}
+	if err := mgr.Add(c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// dependencySecretToRequests enqueues every ManagementIngress in the namespace of a secret
+// which a reconcile phase may be waiting for, e.g. the secrets issued by cert-manager or
+// the certificate secrets provided by the user.
+func (r *ManagementIngressReconciler) dependencySecretToRequests(obj handler.MapObject) []ctrl.Request {
+	ingressList := &operatorv1alpha1.ManagementIngressList{}
+	if err := r.List(context.TODO(), ingressList, client.InNamespace(obj.Meta.GetNamespace())); err != nil {
+		klog.Errorf("failed to list managementingress in namespace %s: %v", obj.Meta.GetNamespace(), err)
+		return nil
+	}
+
+	requests := []ctrl.Request{}
+	for _, item := range ingressList.Items {
+		if utils.ContainsString(dependencySecretNames(&item), obj.Meta.GetName()) {
+			requests = append(requests, ctrl.Request{NamespacedName: types.NamespacedName{Name: item.Name, Namespace: item.Namespace}})
+		}
+	}
+	return requests
+}
+
+// platformAuthConfigToRequests enqueues every ManagementIngress in the namespace of platform-auth-idp, whose
+// checksum annotates the pod template along with the OIDC credentials.
+func (r *ManagementIngressReconciler) platformAuthConfigToRequests(obj handler.MapObject) []ctrl.Request {
+	if obj.Meta.GetName() != k8shandler.PlatformAuthConfigmap {
+		return nil
+	}
+
+	ingressList := &operatorv1alpha1.ManagementIngressList{}
+	if err := r.List(context.TODO(), ingressList, client.InNamespace(obj.Meta.GetNamespace())); err != nil {
+		klog.Errorf("failed to list managementingress in namespace %s: %v", obj.Meta.GetNamespace(), err)
+		return nil
+	}
+
+	requests := []ctrl.Request{}
+	for _, item := range ingressList.Items {
+		requests = append(requests, ctrl.Request{NamespacedName: types.NamespacedName{Name: item.Name, Namespace: item.Namespace}})
+	}
+	return requests
+}
+
+// dependencySecretNames returns the secrets a ManagementIngress depends on, the certificate secrets
+// and the OIDC credentials whose checksums annotate the pod template.
+func dependencySecretNames(instance *operatorv1alpha1.ManagementIngress) []string {
+	objects := k8shandler.NewObjectNames(instance.Name)
+	names := []string{objects.TLSSecret, objects.RouteSecret, k8shandler.PlatformAuthSecret}
+	if cert := instance.Spec.Cert; cert != nil {
+		if cert.TLSSecretRef != nil {
+			names = append(names, cert.TLSSecretRef.Name)
+		}
+		if cert.RouteSecretRef != nil {
+			names = append(names, cert.RouteSecretRef.Name)
+		}
+	}
+	return names
 }
 
 // SetupWithManager set up a new controller that will be started by the provided manager.
 func (r *ManagementIngressReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	if r.ClusterType == "cncf" {
-		return ctrl.NewControllerManagedBy(mgr).
-			For(&operatorv1alpha1.ManagementIngress{}).
-			Owns(&corev1.Service{}).
-			Owns(&corev1.Secret{}).
-			Owns(&corev1.ConfigMap{}).
-			Owns(&corev1.ServiceAccount{}).
-			Owns(&appsv1.Deployment{}).
-			Owns(&certmanagerv1alpha1.Certificate{}).
-			Complete(r)
-	}
-	return ctrl.NewControllerManagedBy(mgr).
+	builder := ctrl.NewControllerManagedBy(mgr).
 		For(&operatorv1alpha1.ManagementIngress{}).
 		Owns(&corev1.Service{}).
 		Owns(&corev1.Secret{}).
 		Owns(&corev1.ConfigMap{}).
 		Owns(&corev1.ServiceAccount{}).
 		Owns(&appsv1.Deployment{}).
-		Owns(&certmanagerv1alpha1.Certificate{}).
-		Owns(&routev1.Route{}).
-		Complete(r)
+		Owns(&networkingv1.NetworkPolicy{})
+
+	// The secrets issued by cert-manager and provided by the user, and the config of platform-auth are not
+	// labeled, so they are watched through a cache of their own and filtered by name.
+	dependencyCache, err := newDependencyCache(mgr, r.WatchNamespaces)
+	if err != nil {
+		return err
+	}
+	builder = builder.
+		Watches(source.NewKindWithCache(&corev1.Secret{}, dependencyCache), &handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(r.dependencySecretToRequests)}).
+		Watches(source.NewKindWithCache(&corev1.ConfigMap{}, dependencyCache), &handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(r.platformAuthConfigToRequests)})
+
+	certificate := &unstructured.Unstructured{}
+	certificate.SetGroupVersionKind(r.CertificateGVK)
+	builder = builder.Owns(certificate)
+
+	for _, gvk := range []schema.GroupVersionKind{r.AutoscalerGVK, r.PodDisruptionBudgetGVK} {
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(gvk)
+		builder = builder.Owns(obj)
+	}
+
+	if r.Routes {
+		builder = builder.Owns(&routev1.Route{})
+	}
+
+	toRequests := &handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(r.clusterConfigToRequests)}
+	cppConfigCache, err := newClusterConfigCache(mgr, r.OperatorNamespace)
+	if err != nil {
+		return err
+	}
+	r.clusterConfigReader = cppConfigCache
+	builder = builder.Watches(source.NewKindWithCache(&corev1.ConfigMap{}, cppConfigCache), toRequests)
+
+	if r.OpenShiftOperators {
+		// The namespace of the IngressController also restricts the cache, the DNS is cluster scoped.
+		openShiftCache, err := newClusterConfigCache(mgr, k8shandler.IngressControllerNamespace)
+		if err != nil {
+			return err
+		}
+		for _, obj := range []runtime.Object{&operatorv1.IngressController{}, &operatorv1.DNS{}} {
+			builder = builder.Watches(source.NewKindWithCache(obj, openShiftCache), toRequests)
+		}
+	}
+
+	// Ingress and Gateway API resources are only watched when their CRDs are installed in the cluster.
+	gvks := []schema.GroupVersionKind{k8shandler.IngressGVK, k8shandler.HTTPRouteGVK, k8shandler.TLSRouteGVK}
+	if policyGVK, served := k8shandler.ServedGVK(mgr.GetRESTMapper(), k8shandler.BackendTLSPolicyV1GVK, k8shandler.BackendTLSPolicyV1Alpha3GVK); served {
+		gvks = append(gvks, policyGVK)
+	}
+	for _, gvk := range gvks {
+		if _, err := mgr.GetRESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version); err != nil {
+			klog.Infof("Not watching %s: %v", gvk.String(), err)
+			continue
+		}
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(gvk)
+		builder = builder.Owns(obj)
+	}
+
+	return builder.Complete(r)
 }
@@ -16,7 +16,6 @@
 package main
 
 import (
-	"context"
 	"flag"
 	"fmt"
 	"os"
@@ -24,19 +23,22 @@ import (
 	"strings"
 
 	certmanagerv1alpha1 "github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha1"
+	operatorv1 "github.com/openshift/api/operator/v1"
 	routev1 "github.com/openshift/api/route/v1"
+	securityv1 "github.com/openshift/api/security/v1"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/api/errors"
+	networkingv1 "k8s.io/api/networking/v1"
 	labels "k8s.io/apimachinery/pkg/labels"
 	k8sruntime "k8s.io/apimachinery/pkg/runtime"
 	schema "k8s.io/apimachinery/pkg/runtime/schema"
-	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/discovery"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
 	"k8s.io/klog"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 
 	"github.com/IBM/controller-filtered-cache/filteredcache"
@@ -100,6 +102,9 @@ func main() {
 		corev1.SchemeGroupVersion.WithKind("Secret"): {
 			LabelSelector: labelSelector,
 		},
+		networkingv1.SchemeGroupVersion.WithKind("NetworkPolicy"): {
+			LabelSelector: labelSelector,
+		},
 	}
 
 	scheme := k8sruntime.NewScheme()
@@ -107,6 +112,55 @@ func main() {
 	utilruntime.Must(operatorv1alpha1.AddToScheme(scheme))
 	utilruntime.Must(certmanagerv1alpha1.AddToScheme(scheme))
 
+	// The capabilities of the cluster are discovered before the manager is created, so it is created once
+	// with the scheme and watches of the cluster.
+	cfg := ctrl.GetConfigOrDie()
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(cfg)
+	if err != nil {
+		klog.Errorf("unable to create discovery client: %v", err)
+		os.Exit(1)
+	}
+	reader, err := client.New(cfg, client.Options{Scheme: scheme})
+	if err != nil {
+		klog.Errorf("unable to create client: %v", err)
+		os.Exit(1)
+	}
+	cppConfig, err := handler.GetCppConfig(reader, operatorNs)
+	if err != nil {
+		klog.Errorf("unable to read cluster config: %v", err)
+		os.Exit(1)
+	}
+	if cppConfig == nil {
+		klog.Infof("No %s config map in namespace %s, discovering the cluster type", handler.CppConfigName, operatorNs)
+	}
+	// The reconciler applies ibm-cpp-config to the discovered cluster type itself, as it may change at runtime.
+	discovered, err := handler.DiscoverCapabilities(discoveryClient)
+	if err != nil {
+		klog.Errorf("unable to discover cluster capabilities: %v", err)
+		os.Exit(1)
+	}
+	capabilities := discovered
+	capabilities.ClusterType, capabilities.DomainName = handler.ApplyCppConfig(discovered.ClusterType, cppConfig)
+	klog.Infof("Cluster type %s, capabilities: %+v", capabilities.ClusterType, capabilities)
+	if !capabilities.CertManager {
+		klog.Warning("No cert-manager API found, the certificates of management ingress cannot be issued")
+	}
+	if capabilities.ClusterType == handler.CNCF && len(capabilities.DomainName) == 0 {
+		klog.Warningf("No %s in %s config map, the console host is not set", handler.CppConfigDomainName, handler.CppConfigName)
+	}
+
+	// The OpenShift types are also known when the cluster type is overridden, so the reconcile reports the
+	// missing APIs instead of unknown types.
+	if capabilities.Routes || capabilities.ClusterType != handler.CNCF {
+		utilruntime.Must(routev1.AddToScheme(scheme))
+	}
+	if capabilities.SecurityContextConstraints || capabilities.ClusterType != handler.CNCF {
+		utilruntime.Must(securityv1.AddToScheme(scheme))
+	}
+	if capabilities.OpenShiftOperators {
+		utilruntime.Must(operatorv1.Install(scheme))
+	}
+
 	var ctrlOpt ctrl.Options
 	if strings.Contains(watchNS, ",") {
 		namespaces := strings.Split(watchNS, ",")
@@ -132,46 +186,46 @@ func main() {
 		}
 	}
 
-	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrlOpt)
-
+	mgr, err := ctrl.NewManager(cfg, ctrlOpt)
 	if err != nil {
 		klog.Errorf("unable to start manager: %v", err)
 		os.Exit(1)
 	}
 
-	var clusterType string
-	var domainName string
-	// var nodePort string
-	ibmCppConfig := &corev1.ConfigMap{}
-	if err := mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: handler.CppConfigName, Namespace: operatorNs}, ibmCppConfig); !errors.IsNotFound(err) {
-		utilruntime.Must(routev1.AddToScheme(scheme))
-		ctrlOpt.Scheme = scheme
-		mgr, err = ctrl.NewManager(ctrl.GetConfigOrDie(), ctrlOpt)
-		if err != nil {
-			klog.Errorf("unable to start manager: %v", err)
-			os.Exit(1)
-		} else {
-			clusterType = ibmCppConfig.Data[handler.KubernetesClusterType]
-			domainName = ibmCppConfig.Data[handler.CppConfigDomainName]
-			// dns = projectkConfig.Data["dns"]
-		}
-	} else if err != nil {
-		klog.Errorf("unable to start manager because %s configmap not found in ns %s: %v", handler.CppConfigName, operatorNs, err)
-		os.Exit(1)
-	}
+	certificateGVK := handler.DiscoverCertificateGVK(mgr.GetRESTMapper())
+	klog.Infof("Using cert-manager Certificate API %s", certificateGVK.GroupVersion())
+	autoscalerGVK := handler.DiscoverAutoscalerGVK(mgr.GetRESTMapper())
+	podDisruptionBudgetGVK := handler.DiscoverPodDisruptionBudgetGVK(mgr.GetRESTMapper())
 
 	if err = (&controllers.ManagementIngressReconciler{
-		Client:      mgr.GetClient(),
-		Reader:      mgr.GetAPIReader(),
-		Scheme:      mgr.GetScheme(),
-		Recorder:    mgr.GetEventRecorderFor(controllers.ControllerName),
-		ClusterType: clusterType,
-		DomainName:  domainName,
+		Client:                 mgr.GetClient(),
+		Reader:                 mgr.GetAPIReader(),
+		Scheme:                 mgr.GetScheme(),
+		Recorder:               mgr.GetEventRecorderFor(controllers.ControllerName),
+		ClusterType:            discovered.ClusterType,
+		OperatorNamespace:      operatorNs,
+		WatchNamespaces:        strings.Split(watchNS, ","),
+		Routes:                 capabilities.Routes,
+		OpenShiftOperators:     capabilities.OpenShiftOperators,
+		GatewayAPI:             capabilities.GatewayAPI,
+		CertificateGVK:         certificateGVK,
+		AutoscalerGVK:          autoscalerGVK,
+		PodDisruptionBudgetGVK: podDisruptionBudgetGVK,
+		RESTMapper:             mgr.GetRESTMapper(),
 	}).SetupWithManager(mgr); err != nil {
 		klog.Errorf("unable to create controller: %v", err)
 		os.Exit(1)
 	}
 
+	// The webhook server needs a serving certificate, so the webhooks are only enabled when the deployment
+	// mounts one: OLM provisions it for the webhookdefinitions of the CSV, cert-manager for config/default.
+	if os.Getenv("ENABLE_WEBHOOKS") == "true" {
+		if err = (&operatorv1alpha1.ManagementIngress{}).SetupWebhookWithManager(mgr); err != nil {
+			klog.Errorf("unable to create webhook: %v", err)
+			os.Exit(1)
+		}
+	}
+
 	klog.Info("Setting up liveness and readiness probes")
 	if err := mgr.AddHealthzCheck("health", healthz.Ping); err != nil {
 		klog.Errorf("unable to set up health check: %v", err)
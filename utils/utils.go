@@ -18,17 +18,12 @@ package utils
 import (
 	"reflect"
 
-	apps "k8s.io/api/apps/v1"
 	core "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	operatorv1alpha1 "github.com/IBM/ibm-management-ingress-operator/api/v1alpha1"
 )
 
-const (
-	CertManagerTimeRestartLabel string = "certmanager.k8s.io/time-restarted"
-)
-
 // GetAnnotation returns the value of an annoation for a given key and true if the key was found
 func GetAnnotation(key string, meta metav1.ObjectMeta) (string, bool) {
 	for k, value := range meta.Annotations {
@@ -278,242 +273,52 @@ func AppendAnnotations(lhsAnnotation, rhsAnnotation map[string]string) map[strin
 	return lhsAnnotation
 }
 
-func IsDeploymentDifferent(current *apps.Deployment, desired *apps.Deployment) (*apps.Deployment, bool) {
-
-	different := false
-
-	if !AreMapsSame(current.Spec.Template.Spec.NodeSelector, desired.Spec.Template.Spec.NodeSelector) {
-		current.Spec.Template.Spec.NodeSelector = desired.Spec.Template.Spec.NodeSelector
-		different = true
-	}
-
-	if !AreTolerationsSame(current.Spec.Template.Spec.Tolerations, desired.Spec.Template.Spec.Tolerations) {
-		current.Spec.Template.Spec.Tolerations = desired.Spec.Template.Spec.Tolerations
-		different = true
-	}
-
-	if isImageDifference(&current.Spec.Template.Spec, &desired.Spec.Template.Spec) {
-		podSpec := updateCurrentImages(&current.Spec.Template.Spec, &desired.Spec.Template.Spec)
-		current.Spec.Template.Spec = *podSpec
-		different = true
-	}
-
-	// Ignore cert manager label 'certmanager.k8s.io/time-restarted: 2020-11-24.2045' which was used to restart the pod when certificate was renewed.
-	if val, ok := current.Spec.Template.ObjectMeta.Labels[CertManagerTimeRestartLabel]; ok {
-		desired.Spec.Template.ObjectMeta.Labels[CertManagerTimeRestartLabel] = val
-	}
-	if !reflect.DeepEqual(current.Spec.Template.ObjectMeta.Labels, desired.Spec.Template.ObjectMeta.Labels) {
-		current.Spec.Template.ObjectMeta.Labels = desired.Spec.Template.ObjectMeta.Labels
-		different = true
-	}
-
-	if *current.Spec.Replicas != *desired.Spec.Replicas {
-		current.Spec.Replicas = desired.Spec.Replicas
-		different = true
-	}
-
-	if AreResourcesDifferent(current, desired) {
-		different = true
-	}
-
-	if !EnvValueEqual(current.Spec.Template.Spec.Containers[0].Env, desired.Spec.Template.Spec.Containers[0].Env) {
-		current.Spec.Template.Spec.Containers[0].Env = desired.Spec.Template.Spec.Containers[0].Env
-		different = true
-	}
-	if !PodVolumeEquivalent(current.Spec.Template.Spec.Volumes, desired.Spec.Template.Spec.Volumes) {
-		current.Spec.Template.Spec.Volumes = desired.Spec.Template.Spec.Volumes
-		different = true
-	}
-	if !reflect.DeepEqual(current.Spec.Template.Spec.Containers[0].VolumeMounts, desired.Spec.Template.Spec.Containers[0].VolumeMounts) {
-		current.Spec.Template.Spec.Containers[0].VolumeMounts = desired.Spec.Template.Spec.Containers[0].VolumeMounts
-		different = true
-	}
-	if !reflect.DeepEqual(current.Spec.Template.Spec.Containers[0].Ports, desired.Spec.Template.Spec.Containers[0].Ports) {
-		current.Spec.Template.Spec.Containers[0].Ports = desired.Spec.Template.Spec.Containers[0].Ports
-		different = true
-	}
-	if isCommandDifference(&current.Spec.Template.Spec, &desired.Spec.Template.Spec) {
-		podSpec := updateCurrentCommand(&current.Spec.Template.Spec, &desired.Spec.Template.Spec)
-		current.Spec.Template.Spec = *podSpec
-		different = true
-	}
-
-	return current, different
-}
-
-func IsDaemonsetDifferent(current *apps.DaemonSet, desired *apps.DaemonSet) (*apps.DaemonSet, bool) {
-
-	different := false
-
-	if !AreMapsSame(current.Spec.Template.Spec.NodeSelector, desired.Spec.Template.Spec.NodeSelector) {
-		current.Spec.Template.Spec.NodeSelector = desired.Spec.Template.Spec.NodeSelector
-		different = true
-	}
-
-	if !AreTolerationsSame(current.Spec.Template.Spec.Tolerations, desired.Spec.Template.Spec.Tolerations) {
-		current.Spec.Template.Spec.Tolerations = desired.Spec.Template.Spec.Tolerations
-		different = true
-	}
-
-	if isImageDifference(&current.Spec.Template.Spec, &desired.Spec.Template.Spec) {
-		podSpec := updateCurrentImages(&current.Spec.Template.Spec, &desired.Spec.Template.Spec)
-		current.Spec.Template.Spec = *podSpec
-		different = true
-	}
-
-	if !reflect.DeepEqual(current.Spec.Template.ObjectMeta.Labels, desired.Spec.Template.ObjectMeta.Labels) {
-		current.Spec.Template.ObjectMeta.Labels = desired.Spec.Template.ObjectMeta.Labels
-		different = true
-	}
-
-	if AreResourcesDifferent(current, desired) {
-		different = true
-	}
-
-	if !EnvValueEqual(current.Spec.Template.Spec.Containers[0].Env, desired.Spec.Template.Spec.Containers[0].Env) {
-		current.Spec.Template.Spec.Containers[0].Env = desired.Spec.Template.Spec.Containers[0].Env
-		different = true
-	}
-	if !PodVolumeEquivalent(current.Spec.Template.Spec.Volumes, desired.Spec.Template.Spec.Volumes) {
-		current.Spec.Template.Spec.Volumes = desired.Spec.Template.Spec.Volumes
-		different = true
-	}
-	if !reflect.DeepEqual(current.Spec.Template.Spec.Containers[0].VolumeMounts, desired.Spec.Template.Spec.Containers[0].VolumeMounts) {
-		current.Spec.Template.Spec.Containers[0].VolumeMounts = desired.Spec.Template.Spec.Containers[0].VolumeMounts
-		different = true
-	}
-	if !reflect.DeepEqual(current.Spec.Template.Spec.Containers[0].Ports, desired.Spec.Template.Spec.Containers[0].Ports) {
-		current.Spec.Template.Spec.Containers[0].Ports = desired.Spec.Template.Spec.Containers[0].Ports
-		different = true
-	}
-	if isCommandDifference(&current.Spec.Template.Spec, &desired.Spec.Template.Spec) {
-		podSpec := updateCurrentCommand(&current.Spec.Template.Spec, &desired.Spec.Template.Spec)
-		current.Spec.Template.Spec = *podSpec
-		different = true
-	}
-
-	return current, different
-}
-
-func isImageDifference(current *core.PodSpec, desired *core.PodSpec) bool {
-	for _, curr := range current.Containers {
-		for _, des := range desired.Containers {
-			// Only compare the images of containers with the same name
-			if curr.Name == des.Name {
-				if curr.Image != des.Image {
-					return true
-				}
-			}
-		}
-	}
-
-	return false
-}
-
-func updateCurrentImages(current *core.PodSpec, desired *core.PodSpec) *core.PodSpec {
-	containers := current.Containers
-
-	for index, curr := range current.Containers {
-		for _, des := range desired.Containers {
-			// Only compare the images of containers with the same name
-			if curr.Name == des.Name {
-				if curr.Image != des.Image {
-					containers[index].Image = des.Image
-				}
-			}
-		}
+// AppendLabels adds the labels of rhsLabels to lhsLabels, a label in both takes the value of rhsLabels.
+func AppendLabels(lhsLabels, rhsLabels map[string]string) map[string]string {
+	for k, v := range rhsLabels {
+		lhsLabels[k] = v
 	}
 
-	return current
+	return lhsLabels
 }
 
-func isCommandDifference(current *core.PodSpec, desired *core.PodSpec) bool {
-	for _, curr := range current.Containers {
-		for _, des := range desired.Containers {
-			// Only compare the command of containers with the same name
-			if curr.Name == des.Name {
-				if !isStringSliceEqual(curr.Command, des.Command) {
-					return true
-				}
-			}
+// ContainsFields reports whether every field set in desired has the same value in current. It is used
+// to compare unstructured objects, whose current state also holds the fields defaulted by the API server.
+func ContainsFields(current, desired interface{}) bool {
+	switch d := desired.(type) {
+	case map[string]interface{}:
+		c, ok := current.(map[string]interface{})
+		if !ok {
+			return len(d) == 0 && current == nil
 		}
-	}
-
-	return false
-}
-
-func updateCurrentCommand(current *core.PodSpec, desired *core.PodSpec) *core.PodSpec {
-	containers := current.Containers
-
-	for index, curr := range current.Containers {
-		for _, des := range desired.Containers {
-			// Only update the command of containers with the same name
-			if curr.Name == des.Name {
-				if !isStringSliceEqual(curr.Command, des.Command) {
-					containers[index].Command = des.Command
-				}
+		for key, value := range d {
+			if !ContainsFields(c[key], value) {
+				return false
 			}
 		}
-	}
-
-	return current
-}
-
-func isStringSliceEqual(a, b []string) bool {
-	if len(a) != len(b) {
-		return false
-	}
-	for i, v := range a {
-		if v != b[i] {
-			return false
-		}
-	}
-	return true
-}
-
-func IsServiceDifferent(current *core.Service, desired *core.Service) (*core.Service, bool) {
-
-	different := false
-
-	if !reflect.DeepEqual(current.Spec.Selector, desired.Spec.Selector) {
-		current.Spec.Selector = desired.Spec.Selector
-		different = true
-	}
-
-	if !isServicePortEqual(current.Spec.Ports, desired.Spec.Ports) {
-		current.Spec.Ports = desired.Spec.Ports
-		different = true
-	}
-
-	return current, different
-}
-
-func isServicePortEqual(a []core.ServicePort, b []core.ServicePort) bool {
-	if len(a) != len(b) {
-		return false
-	}
-	for i, v := range a {
-		if v.Name != b[i].Name {
-			return false
-		}
-		if string(v.Protocol) != string(b[i].Protocol) {
-			return false
-		}
-		if v.Port != b[i].Port {
-			return false
+		return true
+	case []interface{}:
+		c, ok := current.([]interface{})
+		if !ok {
+			return len(d) == 0 && current == nil
 		}
-		if v.NodePort != b[i].NodePort {
+		if len(c) != len(d) {
 			return false
 		}
-		if v.TargetPort.Type != b[i].TargetPort.Type {
-			return false
+		for i := range d {
+			if !ContainsFields(c[i], d[i]) {
+				return false
+			}
 		}
-		if v.TargetPort.IntVal != b[i].TargetPort.IntVal {
-			return false
+		return true
+	case int64:
+		if c, ok := current.(float64); ok {
+			return float64(d) == c
 		}
-		if v.TargetPort.StrVal != b[i].TargetPort.StrVal {
-			return false
+	case float64:
+		if c, ok := current.(int64); ok {
+			return d == float64(c)
 		}
 	}
-	return true
+	return reflect.DeepEqual(current, desired)
 }
@@ -174,3 +174,52 @@ func TestAppendAnnotations(t *testing.T) {
 		t.Errorf("AppendAnnotations did not return expected result: %v", expectAnnotation)
 	}
 }
+
+func TestAppendLabels(t *testing.T) {
+	lhsLabels := map[string]string{"app": "ibm-management-ingress", "release": "old"}
+	rhsLabels := map[string]string{"release": "new"}
+
+	resultLabels := AppendLabels(lhsLabels, rhsLabels)
+	expectLabels := map[string]string{"app": "ibm-management-ingress", "release": "new"}
+
+	if !reflect.DeepEqual(resultLabels, expectLabels) {
+		t.Errorf("AppendLabels did not return expected result: %v", expectLabels)
+	}
+}
+
+func TestContainsFields(t *testing.T) {
+	current := map[string]interface{}{
+		"hostnames": []interface{}{"cp-console.example.com"},
+		"rules": []interface{}{
+			map[string]interface{}{
+				"backendRefs": []interface{}{
+					map[string]interface{}{"name": "icp-management-ingress", "port": int64(443), "weight": int64(1), "kind": "Service"},
+				},
+			},
+		},
+	}
+
+	desired := map[string]interface{}{
+		"hostnames": []interface{}{"cp-console.example.com"},
+		"rules": []interface{}{
+			map[string]interface{}{
+				"backendRefs": []interface{}{
+					map[string]interface{}{"name": "icp-management-ingress", "port": float64(443)},
+				},
+			},
+		},
+	}
+	if !ContainsFields(current, desired) {
+		t.Errorf("expected fields defaulted by the server to be ignored")
+	}
+
+	desired["hostnames"] = []interface{}{"cp-console.other.com"}
+	if ContainsFields(current, desired) {
+		t.Errorf("expected a changed field to be reported")
+	}
+
+	desired["hostnames"] = []interface{}{"cp-console.example.com", "cp-console.other.com"}
+	if ContainsFields(current, desired) {
+		t.Errorf("expected an added list item to be reported")
+	}
+}